@@ -1,9 +1,9 @@
 package nexmo
 
 import (
-	"encoding/json"
-	"io/ioutil"
+	"context"
 	"net/http"
+	"net/url"
 )
 
 // Account represents the user's account. Used when retrieving e.g current
@@ -19,37 +19,37 @@ func (nexmo *Account) GetBalance() (float64, error) {
 		Value float64 `json:"value"`
 	}
 
-	var accBalance *AccountBalance
-
-	r, reqErr := http.NewRequest("GET", apiRoot+"/account/get-balance/"+
-		nexmo.client.apiKey+"/"+nexmo.client.apiSecret, nil)
-
-	if reqErr != nil {
-		return 0.0, reqErr
+	r, err := nexmo.newBalanceRequest()
+	if err != nil {
+		return 0.0, err
 	}
 
-	r.Header.Add("Accept", "application/json")
-
-	resp, err := nexmo.client.HTTPClient.Do(r)
-	if err != nil {
+	accBalance := new(AccountBalance)
+	if err := nexmo.client.do(context.Background(), r, accBalance); err != nil {
 		return 0.0, err
 	}
 
-	defer func() {
-		if resp != nil && resp.Body != nil {
-			resp.Body.Close()
-		}
-	}()
+	if m := nexmo.client.Metrics; m != nil {
+		m.Balance(accBalance.Value)
+	}
+	return accBalance.Value, nil
+}
 
-	body, readErr := ioutil.ReadAll(resp.Body)
-	if readErr != nil {
-		return 0.0, readErr
+// newBalanceRequest creates the request for GetBalance, authenticated by the
+// Auth of the client in its query string.
+func (nexmo *Account) newBalanceRequest() (*http.Request, error) {
+	header := make(http.Header)
+	params := make(url.Values)
+	if err := nexmo.client.auth(nil).Authenticate(header, params); err != nil {
+		return nil, err
 	}
 
-	err = json.Unmarshal(body, &accBalance)
+	r, err := http.NewRequest("GET", apiRoot+"/account/get-balance?"+params.Encode(), nil)
 	if err != nil {
-		return 0.0, err
+		return nil, err
 	}
 
-	return accBalance.Value, nil
+	copyHeader(r.Header, header)
+	r.Header.Add("Accept", "application/json")
+	return r, nil
 }
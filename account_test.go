@@ -1,19 +1,151 @@
 package nexmo
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetAccountBalance(t *testing.T) {
-	client, err := NewClient(testAPIKey, testAPISecret)
-	if err != nil {
-		t.Error("Failed to create Client with error:", err)
-	}
+	client := liveClient(t)
 
-	balance, err := client.Account.GetBalance()
+	balance, err := client.Account().GetBalance()
 	if err != nil {
 		t.Error("Failed to get account balance with error:", err)
 	}
 
 	t.Log("Got account balance: ", balance, "€")
 }
+
+func TestBalanceGuard(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"value":10.0}`)),
+		}, nil
+	})}
+
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	var low []float64
+	guard := &BalanceGuard{
+		Account: client.Account(),
+		Reserve: 1,
+		Clock:   clock,
+		OnLow:   func(balance, cost float64) { low = append(low, cost) },
+	}
+
+	if err := guard.CheckBatch(100, 0.05); err != nil {
+		t.Fatal(err)
+	}
+	// 5 EUR of the 10 EUR are spent, and 1 EUR is reserved.
+	err = guard.CheckBatch(100, 0.05)
+	if e, ok := err.(*InsufficientBalanceError); !ok || e.Balance != 5 {
+		t.Errorf("got error %v", err)
+	}
+	if len(low) != 1 || requests != 1 {
+		t.Errorf("got %d warnings and %d requests", len(low), requests)
+	}
+
+	// The balance is refreshed after the interval.
+	clock.now = clock.now.Add(5 * time.Minute)
+	if err := guard.CheckBatch(100, 0.05); err != nil || requests != 2 {
+		t.Errorf("got error %v after %d requests", err, requests)
+	}
+
+	guard.Warn = true
+	if err := guard.CheckBatch(200, 0.05); err != nil || len(low) != 2 {
+		t.Errorf("got error %v and %d warnings", err, len(low))
+	}
+}
+
+func TestBalanceGuardRun(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	inFlight, release := make(chan struct{}), make(chan struct{})
+	requests := 0
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if requests == 1 {
+			close(inFlight)
+			<-release
+			return nil, errors.New("connection refused")
+		}
+		cancel()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"value":7.5}`)),
+		}, nil
+	})}
+
+	var errs []error
+	guard := &BalanceGuard{
+		Account: client.Account(),
+		Clock:   &instantClock{},
+		OnError: func(err error) { errs = append(errs, err) },
+	}
+	done := make(chan error)
+	go func() { done <- guard.Run(ctx) }()
+
+	// The guard is not locked while the balance is fetched.
+	<-inFlight
+	balance := make(chan float64)
+	go func() { balance <- guard.Balance() }()
+	select {
+	case <-balance:
+	case <-time.After(time.Second):
+		t.Fatal("Balance blocked by the request in flight")
+	}
+	close(release)
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v", err)
+	}
+	if len(errs) != 1 || guard.Balance() != 7.5 {
+		t.Errorf("got errors %v and balance %v", errs, guard.Balance())
+	}
+}
+
+func TestGetBalanceSignatureAuth(t *testing.T) {
+	auth := &SignatureAuth{
+		APIKey: "k3y",
+		Secret: "s1gn4ture",
+		Method: SignatureSHA256,
+		Clock:  &stoppedClock{now: time.Unix(1500000000, 0)},
+	}
+	client, err := NewClient("", "", WithAuth(auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var query url.Values
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query = req.URL.Query()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"value":10.0}`)),
+		}, nil
+	})}
+
+	if balance, err := client.Account().GetBalance(); err != nil || balance != 10 {
+		t.Fatalf("got balance %v and error %v", balance, err)
+	}
+	if query.Get("api_key") != "k3y" || query.Get("api_secret") != "" {
+		t.Errorf("sent query %v", query)
+	}
+	if err := VerifySignature(query, auth.Secret, auth.Method); err != nil {
+		t.Errorf("request is not signed: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package nexmo
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Auth authenticates the requests a Client sends. It is used for the SMS,
+// USSD, Verify and Number Insight requests, and, if it is a *JWTAuth, for the
+// Messages API. The default is APIKeyAuth with the credentials of the Client.
+type Auth interface {
+	// Authenticate adds the credentials of a request to its parameters,
+	// params, or to its header h. The request is encoded after Authenticate
+	// returns.
+	Authenticate(h http.Header, params url.Values) error
+}
+
+// WithAuth makes the client authenticate its requests with a. Requests sent
+// with the credentials of WithSendCredentials still carry those credentials.
+func WithAuth(a Auth) ClientOption {
+	return func(c *Client) {
+		c.Auth = a
+	}
+}
+
+// APIKeyAuth sends the API key and secret supplied by Provider as the
+// api_key and api_secret parameters.
+type APIKeyAuth struct {
+	Provider CredentialsProvider
+}
+
+// Authenticate implements Auth.
+func (a APIKeyAuth) Authenticate(h http.Header, params url.Values) error {
+	creds, err := a.Provider.Credentials()
+	if err != nil {
+		return err
+	}
+	params.Set("api_key", creds.APIKey)
+	params.Set("api_secret", creds.APISecret)
+	return nil
+}
+
+// SignatureAuth signs requests with the signature secret of the account
+// instead of sending the API secret: the parameters, along with the current
+// time, are signed with Sign and the result is sent as "sig". Requests
+// authenticated with a SignatureAuth are always form encoded.
+type SignatureAuth struct {
+	APIKey string
+	Secret string
+	Method SignatureMethod
+
+	// Tells the time of the requests. Defaults to SystemClock if nil.
+	Clock Clock
+}
+
+// Authenticate implements Auth.
+func (a *SignatureAuth) Authenticate(h http.Header, params url.Values) error {
+	params.Set("api_key", a.APIKey)
+	params.Set("timestamp", strconv.FormatInt(clockOrSystem(a.Clock).Now().Unix(), 10))
+	sig, err := Sign(params, a.Secret, a.Method)
+	if err != nil {
+		return err
+	}
+	params.Set("sig", sig)
+	return nil
+}
+
+// DefaultJWTTTL is how long the tokens of a JWTAuth with no TTL are valid.
+const DefaultJWTTTL = 15 * time.Minute
+
+// JWTAuth authenticates requests with a JSON Web Token for a Nexmo
+// application, signed with RS256 using the private key of the application and
+// sent as a bearer token. A new token is generated for every request.
+type JWTAuth struct {
+	ApplicationID string
+	PrivateKey    *rsa.PrivateKey
+
+	// How long the tokens are valid. Defaults to DefaultJWTTTL.
+	TTL time.Duration
+
+	// Tells the time the tokens are issued at. Defaults to SystemClock if nil.
+	Clock Clock
+}
+
+// ErrNoPrivateKey is returned by a JWTAuth without an application ID or
+// private key.
+var ErrNoPrivateKey = errors.New("nexmo: JWTAuth needs an application ID and private key")
+
+// Authenticate implements Auth.
+func (a *JWTAuth) Authenticate(h http.Header, params url.Values) error {
+	token, err := a.Token()
+	if err != nil {
+		return err
+	}
+	h.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token generates a signed token.
+func (a *JWTAuth) Token() (string, error) {
+	if a.ApplicationID == "" || a.PrivateKey == nil {
+		return "", ErrNoPrivateKey
+	}
+	ttl := a.TTL
+	if ttl <= 0 {
+		ttl = DefaultJWTTTL
+	}
+	now := clockOrSystem(a.Clock).Now()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"application_id": a.ApplicationID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(ttl).Unix(),
+		"jti":            newClientReference(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// auth returns the Auth of requests sent with override, which may be nil.
+func (c *Client) auth(override *Credentials) Auth {
+	if override != nil {
+		return APIKeyAuth{StaticCredentials(*override)}
+	}
+	if c.Auth != nil {
+		return c.Auth
+	}
+	return APIKeyAuth{CredentialsFunc(c.credentials)}
+}
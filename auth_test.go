@@ -0,0 +1,134 @@
+package nexmo
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// headerAuth sends a token as a header and the account as a parameter.
+type headerAuth struct{}
+
+func (headerAuth) Authenticate(h http.Header, params url.Values) error {
+	h.Set("X-Token", "t0ken")
+	params.Set("account", "acme")
+	return nil
+}
+
+func TestAuth(t *testing.T) {
+	client, err := NewClient("", "", WithAuth(headerAuth{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *http.Request
+	var body map[string]interface{}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	if _, err := client.SMS().Send(&SMSMessage{From: "12345", To: "447700900001", Type: Text, Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("X-Token") != "t0ken" {
+		t.Errorf("got headers %v", got.Header)
+	}
+	if body["account"] != "acme" || body["text"] != "Hello" {
+		t.Errorf("got body %v", body)
+	}
+	if _, ok := body["api_secret"]; ok {
+		t.Error("request carries an API secret")
+	}
+}
+
+func TestJWTAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := &JWTAuth{
+		ApplicationID: "aaaaaaaa-bbbb-cccc-dddd-0123456789ab",
+		PrivateKey:    key,
+		Clock:         &stoppedClock{now: time.Unix(1500000000, 0)},
+	}
+
+	token, err := auth.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("got token %q", token)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("invalid signature: %v", err)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims struct {
+		ApplicationID string `json:"application_id"`
+		IssuedAt      int64  `json:"iat"`
+		Expires       int64  `json:"exp"`
+		ID            string `json:"jti"`
+	}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.ApplicationID != auth.ApplicationID || claims.IssuedAt != 1500000000 ||
+		claims.Expires != 1500000000+15*60 || claims.ID == "" {
+		t.Errorf("got claims %+v", claims)
+	}
+
+	if _, err := (&JWTAuth{}).Token(); err != ErrNoPrivateKey {
+		t.Errorf("got error %v without a key", err)
+	}
+
+	// The Messages API is sent the token instead of the API key and secret.
+	client, err := NewClient("", "", WithAuth(auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *http.Request
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{
+			StatusCode: http.StatusAccepted,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message_uuid":"aaaaaaaa-bbbb-cccc-dddd-0123456789ab"}`)),
+		}, nil
+	})}
+	msg := &OutboundMessage{
+		From: MessageAddress{Type: "whatsapp", Number: "14157386170"},
+		To:   MessageAddress{Type: "whatsapp", Number: "447700900000"},
+	}
+	msg.Message.Content = MessageContent{Type: "text", Text: "Hello"}
+	if _, err := client.Messages().Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	if h := got.Header.Get("Authorization"); !strings.HasPrefix(h, "Bearer "+parts[0]+".") {
+		t.Errorf("got Authorization %q", h)
+	}
+}
@@ -0,0 +1,197 @@
+package nexmo
+
+import (
+	"bytes"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Keywords with which senders opt out of and back into replies, as required
+// for short codes in most countries.
+var (
+	DefaultOptOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	DefaultOptInKeywords  = []string{"START", "UNSTOP"}
+)
+
+// OptOutStore records the numbers which opted out of receiving messages.
+// Implementations must be safe for concurrent use.
+type OptOutStore interface {
+	OptOut(number string) error
+	OptIn(number string) error
+	IsOptedOut(number string) (bool, error)
+}
+
+// MemoryOptOutStore is an OptOutStore keeping the numbers in memory.
+type MemoryOptOutStore struct {
+	mu      sync.RWMutex
+	numbers map[string]bool
+}
+
+// OptOut implements OptOutStore.
+func (s *MemoryOptOutStore) OptOut(number string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.numbers == nil {
+		s.numbers = make(map[string]bool)
+	}
+	s.numbers[number] = true
+	return nil
+}
+
+// OptIn implements OptOutStore.
+func (s *MemoryOptOutStore) OptIn(number string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.numbers, number)
+	return nil
+}
+
+// IsOptedOut implements OptOutStore.
+func (s *MemoryOptOutStore) IsOptedOut(number string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.numbers[number], nil
+}
+
+// AutoResponder answers received messages with a reply depending on their
+// keyword, e.g. the conditions of a contest to a message starting with INFO.
+// Senders opting out with one of DefaultOptOutKeywords receive no further
+// replies until they opt back in with one of DefaultOptInKeywords.
+//
+// Use it with NewMessageHandlerFunc:
+//
+//	ar := nexmo.NewAutoResponder(client.SMS())
+//	ar.Reply("INFO", template.Must(template.New("").Parse("Reply YES to enter")))
+//	http.Handle("/inbound", nexmo.NewMessageHandlerFunc(ar.Handle, true))
+//
+// If a reply can not be sent, Handle returns the error, so Nexmo retries the
+// message later.
+type AutoResponder struct {
+	// Sender ID of the replies. Defaults to the number the message was sent
+	// to.
+	From string
+
+	// Minimum time between two replies to the same sender; messages received
+	// in between are not answered. Confirmations of opt-outs and opt-ins are
+	// always sent. If zero, replies are not throttled.
+	Throttle time.Duration
+
+	// Defaults to a MemoryOptOutStore.
+	OptOuts OptOutStore
+
+	// Confirmations sent to senders opting out and back in, if not empty.
+	OptOutReply string
+	OptInReply  string
+
+	// Applied to every reply.
+	SendOptions []SendOption
+
+	// Defaults to SystemClock.
+	Clock Clock
+
+	sms    *SMS
+	router *KeywordRouter
+
+	mu        sync.Mutex
+	lastReply map[string]time.Time // By sender.
+}
+
+// NewAutoResponder creates an AutoResponder sending its replies with sms.
+func NewAutoResponder(sms *SMS) *AutoResponder {
+	a := &AutoResponder{
+		OptOuts:   new(MemoryOptOutStore),
+		sms:       sms,
+		router:    NewKeywordRouter(),
+		lastReply: make(map[string]time.Time),
+	}
+	for _, keyword := range DefaultOptOutKeywords {
+		a.router.Handle(keyword, a.optOut)
+	}
+	for _, keyword := range DefaultOptInKeywords {
+		a.router.Handle(keyword, a.optIn)
+	}
+	return a
+}
+
+// Reply answers messages with the given keyword with the text produced by
+// executing tmpl with the *ReceivedMessage.
+func (a *AutoResponder) Reply(keyword string, tmpl *template.Template) {
+	a.router.Handle(keyword, a.replyWith(tmpl))
+}
+
+// ReplyDefault answers messages matching no other keyword with tmpl. Without
+// a default reply, they are not answered.
+func (a *AutoResponder) ReplyDefault(tmpl *template.Template) {
+	a.router.HandleDefault(a.replyWith(tmpl))
+}
+
+// Handle answers m. It can be passed to NewMessageHandlerFunc.
+func (a *AutoResponder) Handle(m *ReceivedMessage) error {
+	return a.router.Route(m)
+}
+
+func (a *AutoResponder) replyWith(tmpl *template.Template) func(*ReceivedMessage) error {
+	return func(m *ReceivedMessage) error {
+		out, err := a.OptOuts.IsOptedOut(m.MSISDN)
+		if err != nil || out {
+			return err
+		}
+
+		now := clockOrSystem(a.Clock).Now()
+		if a.Throttle > 0 {
+			a.mu.Lock()
+			last, ok := a.lastReply[m.MSISDN]
+			a.mu.Unlock()
+			if ok && now.Sub(last) < a.Throttle {
+				return nil
+			}
+		}
+
+		var text bytes.Buffer
+		if err := tmpl.Execute(&text, m); err != nil {
+			return err
+		}
+		if err := a.send(m, text.String()); err != nil {
+			return err
+		}
+
+		if a.Throttle > 0 {
+			a.mu.Lock()
+			a.lastReply[m.MSISDN] = now
+			a.mu.Unlock()
+		}
+		return nil
+	}
+}
+
+func (a *AutoResponder) optOut(m *ReceivedMessage) error {
+	if err := a.OptOuts.OptOut(m.MSISDN); err != nil {
+		return err
+	}
+	return a.send(m, a.OptOutReply)
+}
+
+func (a *AutoResponder) optIn(m *ReceivedMessage) error {
+	if err := a.OptOuts.OptIn(m.MSISDN); err != nil {
+		return err
+	}
+	return a.send(m, a.OptInReply)
+}
+
+// send sends text to the sender of m, unless it is empty.
+func (a *AutoResponder) send(m *ReceivedMessage, text string) error {
+	if text == "" {
+		return nil
+	}
+	from := a.From
+	if from == "" {
+		from = m.To
+	}
+	reply := &SMSMessage{From: from, To: m.MSISDN, Type: Text, Text: text}
+	if CountSegments(text).Encoding == UCS2 {
+		reply.Type = Unicode
+	}
+	_, err := a.sms.Send(reply, a.SendOptions...)
+	return err
+}
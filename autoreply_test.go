@@ -0,0 +1,83 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestAutoResponder(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replies []SMSMessage
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var msg SMSMessage
+		if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, msg)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+
+	ar := NewAutoResponder(client.SMS())
+	ar.Clock = clock
+	ar.Throttle = time.Minute
+	ar.OptOutReply = "You will receive no further messages."
+	ar.Reply("info", template.Must(template.New("").Parse("Hi {{.MSISDN}}, reply YES to enter")))
+
+	receive := func(from, text string) string {
+		t.Helper()
+		replies = nil
+		if err := ar.Handle(&ReceivedMessage{To: "12345", MSISDN: from, Text: text}); err != nil {
+			t.Fatal(err)
+		}
+		switch len(replies) {
+		case 0:
+			return ""
+		case 1:
+			if replies[0].From != "12345" || replies[0].To != from {
+				t.Errorf("reply sent from %s to %s", replies[0].From, replies[0].To)
+			}
+			return replies[0].Text
+		}
+		t.Fatalf("got %d replies", len(replies))
+		return ""
+	}
+
+	if got := receive("447700900001", "Info please"); got != "Hi 447700900001, reply YES to enter" {
+		t.Errorf("got reply %q", got)
+	}
+	if got := receive("447700900001", "INFO"); got != "" {
+		t.Errorf("got throttled reply %q", got)
+	}
+	if got := receive("447700900002", "INFO"); got == "" {
+		t.Error("throttling applied to another sender")
+	}
+	if err := ar.Handle(&ReceivedMessage{MSISDN: "447700900001", Text: "Hello"}); err == nil {
+		t.Error("message without a route was accepted")
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	if got := receive("447700900001", "stop"); got != ar.OptOutReply {
+		t.Errorf("got opt-out reply %q", got)
+	}
+	if got := receive("447700900001", "INFO"); got != "" {
+		t.Errorf("opted out sender got reply %q", got)
+	}
+	if got := receive("447700900001", "START"); got != "" {
+		t.Errorf("got opt-in reply %q without OptInReply", got)
+	}
+	if got := receive("447700900001", "INFO"); got == "" {
+		t.Error("sender who opted back in got no reply")
+	}
+}
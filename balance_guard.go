@@ -0,0 +1,132 @@
+package nexmo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InsufficientBalanceError is returned by BalanceGuard.Check when the
+// balance of the account does not cover the cost of a batch.
+type InsufficientBalanceError struct {
+	Balance float64 // Euros.
+	Cost    float64
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("balance of %.2f EUR does not cover the cost of %.2f EUR", e.Balance, e.Cost)
+}
+
+// BalanceGuard checks that the balance of an account covers the projected
+// cost of a batch before it is sent, so a campaign does not stop halfway
+// when the account runs out of credit.
+//
+// The balance is refreshed when it is older than Interval. In between, the
+// cost of the batches allowed by Check is deducted from it.
+type BalanceGuard struct {
+	Account *Account
+
+	// Defaults to five minutes.
+	Interval time.Duration
+
+	// Amount which must remain after the batch, in euros.
+	Reserve float64
+
+	// Called when a batch is not covered. Unless Warn is set, Check then
+	// also returns an InsufficientBalanceError.
+	OnLow func(balance, cost float64)
+	Warn  bool
+
+	// Called when Run fails to refresh the balance. Run tries again after
+	// Interval.
+	OnError func(err error)
+
+	Clock Clock
+
+	mu      sync.Mutex
+	balance float64
+	updated time.Time
+}
+
+// Check returns an InsufficientBalanceError if the balance does not cover
+// cost, in euros, plus the reserve.
+func (g *BalanceGuard) Check(cost float64) error {
+	if g.stale() {
+		if err := g.refresh(); err != nil {
+			return err
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.balance-cost < g.Reserve {
+		if g.OnLow != nil {
+			g.OnLow(g.balance, cost)
+		}
+		if !g.Warn {
+			return &InsufficientBalanceError{Balance: g.balance, Cost: cost}
+		}
+	}
+	g.balance -= cost
+	return nil
+}
+
+// CheckBatch checks the cost of n messages at price euros each.
+func (g *BalanceGuard) CheckBatch(n int, price float64) error {
+	return g.Check(float64(n) * price)
+}
+
+// Balance returns the balance as last refreshed, minus the cost of the
+// batches allowed since.
+func (g *BalanceGuard) Balance() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.balance
+}
+
+// Run refreshes the balance every Interval until ctx is done, so Check does
+// not have to wait for it. Errors are passed to OnError, if set.
+func (g *BalanceGuard) Run(ctx context.Context) error {
+	clock := clockOrSystem(g.Clock)
+	for {
+		if err := g.refresh(); err != nil && g.OnError != nil {
+			g.OnError(err)
+		}
+
+		select {
+		case <-clock.After(g.interval()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// stale returns true if the balance was never fetched, or longer than
+// Interval ago.
+func (g *BalanceGuard) stale() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.updated.IsZero() || clockOrSystem(g.Clock).Now().Sub(g.updated) >= g.interval()
+}
+
+// refresh fetches the balance. g.mu is only held to store it, so Check and
+// Balance are not blocked by the request.
+func (g *BalanceGuard) refresh() error {
+	balance, err := g.Account.GetBalance()
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.balance, g.updated = balance, clockOrSystem(g.Clock).Now()
+	return nil
+}
+
+func (g *BalanceGuard) interval() time.Duration {
+	if g.Interval <= 0 {
+		return 5 * time.Minute
+	}
+	return g.Interval
+}
@@ -0,0 +1,157 @@
+package nexmo
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of sending a request while the circuit
+// breaker of the client is open.
+var ErrCircuitOpen = errors.New("nexmo: circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+// Circuit states
+const (
+	CircuitClosed   CircuitState = iota // Requests are sent.
+	CircuitOpen                         // Requests fail with ErrCircuitOpen.
+	CircuitHalfOpen                     // A few probes are sent.
+)
+
+var circuitStateMap = map[CircuitState]string{
+	CircuitClosed:   "closed",
+	CircuitOpen:     "open",
+	CircuitHalfOpen: "half-open",
+}
+
+func (s CircuitState) String() string {
+	if str, ok := circuitStateMap[s]; ok {
+		return str
+	}
+	return "undefined"
+}
+
+// CircuitBreaker makes a Client fail fast while Nexmo is down, instead of
+// blocking its callers on requests bound to fail. After threshold consecutive
+// failures, connection errors or 5xx responses, the circuit opens and requests
+// fail with ErrCircuitOpen. Once it has been open for the open duration, the
+// circuit is half-open: up to probes requests are let through, and the circuit
+// closes if they all succeed or opens again as soon as one fails.
+//
+// Pass it to NewClient with WithCircuitBreaker. A CircuitBreaker can be shared
+// by several clients sending to the same API.
+type CircuitBreaker struct {
+	threshold int
+	openFor   time.Duration
+	probes    int
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int       // Consecutive failures while closed.
+	openedAt  time.Time // When the circuit last opened.
+	inFlight  int       // Probes sent while half-open.
+	succeeded int       // Probes which succeeded while half-open.
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker opening after threshold
+// consecutive failures for openFor, then letting probes requests through.
+// threshold and probes are at least 1.
+func NewCircuitBreaker(threshold int, openFor time.Duration, probes int) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	if probes < 1 {
+		probes = 1
+	}
+	return &CircuitBreaker{threshold: threshold, openFor: openFor, probes: probes}
+}
+
+// WithCircuitBreaker makes the client fail fast with ErrCircuitOpen while b
+// is open.
+func WithCircuitBreaker(b *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = b
+	}
+}
+
+// State returns the state of b at now.
+func (b *CircuitBreaker) State(now time.Time) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(now)
+	return b.state
+}
+
+// advance half-opens the circuit once it has been open long enough.
+func (b *CircuitBreaker) advance(now time.Time) {
+	if b.state == CircuitOpen && !now.Before(b.openedAt.Add(b.openFor)) {
+		b.state = CircuitHalfOpen
+		b.inFlight = 0
+		b.succeeded = 0
+	}
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent at now.
+func (b *CircuitBreaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.inFlight >= b.probes {
+			return ErrCircuitOpen
+		}
+		b.inFlight++
+	}
+	return nil
+}
+
+// report records the outcome of a request allowed by allow.
+func (b *CircuitBreaker) report(ok bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitClosed:
+		if ok {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open(now)
+		}
+	case CircuitHalfOpen:
+		if !ok {
+			b.open(now)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.probes {
+			b.state = CircuitClosed
+			b.failures = 0
+		}
+	}
+}
+
+// release gives back the probe of a request which was canceled by its caller
+// and so tells nothing about the health of the API.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.state = CircuitOpen
+	b.openedAt = now
+	b.failures = 0
+}
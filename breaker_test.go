@@ -0,0 +1,76 @@
+package nexmo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	b := NewCircuitBreaker(2, time.Minute, 1)
+	client, err := NewClient("k3y", "s3cr3t", WithCircuitBreaker(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Clock = clock
+
+	var requests int
+	status := http.StatusServiceUnavailable
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		body := `{"value":3.5}`
+		if status != http.StatusOK {
+			body = "Service Unavailable"
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Account().GetBalance(); err == nil || err == ErrCircuitOpen {
+			t.Fatalf("request %d: got error %v", i, err)
+		}
+	}
+	if s := b.State(clock.now); s != CircuitOpen {
+		t.Fatalf("circuit is %s after 2 failures", s)
+	}
+	if _, err := client.Account().GetBalance(); err != ErrCircuitOpen {
+		t.Errorf("got error %v while open", err)
+	}
+	if requests != 2 {
+		t.Errorf("sent %d requests, want 2", requests)
+	}
+
+	// The failing probe opens the circuit again.
+	clock.now = clock.now.Add(time.Minute)
+	if s := b.State(clock.now); s != CircuitHalfOpen {
+		t.Fatalf("circuit is %s after the open duration", s)
+	}
+	client.Account().GetBalance()
+	if s := b.State(clock.now); s != CircuitOpen {
+		t.Fatalf("circuit is %s after a failed probe", s)
+	}
+
+	// Only one probe is let through at once.
+	clock.now = clock.now.Add(time.Minute)
+	if err := b.allow(clock.now); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.allow(clock.now); err != ErrCircuitOpen {
+		t.Errorf("got error %v for a second probe", err)
+	}
+	b.release()
+
+	status = http.StatusOK
+	if _, err := client.Account().GetBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.State(clock.now); s != CircuitClosed {
+		t.Errorf("circuit is %s after a successful probe", s)
+	}
+}
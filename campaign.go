@@ -0,0 +1,480 @@
+package nexmo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Campaign describes a text message sent to a list of recipients, see
+// SMS.StartCampaign.
+type Campaign struct {
+	// Identifies the messages of the campaign in Sent.
+	ID string
+
+	From       string
+	Recipients []Recipient
+
+	// Executed with each Recipient to produce the text of its message, e.g.
+	// "Hi {{.Fields.name}}, your code is {{.Fields.code}}".
+	Template *template.Template
+
+	// When messages may be sent; the zero value allows any time.
+	Window SendWindow
+
+	// Messages per second, and requests in flight, at most. Default to 10
+	// and 10.
+	Rate     float64
+	InFlight int
+
+	// Applied to every message, e.g. WithDLR to track delivery.
+	SendOptions []SendOption
+
+	// If set, the campaign is only started if the guard allows the cost of
+	// the pending messages at MessagePrice euros each.
+	BalanceGuard *BalanceGuard
+	MessagePrice float64
+
+	// Remembers the messages accepted by Nexmo for SentTTL, so they are not
+	// sent again when the campaign is started again. Defaults to a
+	// MemorySentCache, set when the campaign is first started, and to 30
+	// days. Pass the cache given to WithDeduplication, if any, so messages
+	// sent by either are known to both.
+	Sent    SentCache
+	SentTTL time.Duration
+
+	// If set, the receipts passed to CampaignRun.HandleReceipt are saved in
+	// Store. If Store can also list the receipts saved in it, as
+	// nexmosql.Store can, they are applied to the campaign when it is
+	// started again.
+	Store Store
+}
+
+// receiptLister is implemented by Stores which can list the receipts saved in
+// them.
+type receiptLister interface {
+	Receipts() ([]*DeliveryReceipt, error)
+}
+
+// Recipient is a recipient of a Campaign.
+type Recipient struct {
+	To     string
+	Fields map[string]string
+}
+
+// SendWindow is the time of day messages may be sent in, e.g. from 9:00 to
+// 20:00 local time so recipients are not woken up.
+type SendWindow struct {
+	Start, End time.Duration // Since midnight.
+	Location   *time.Location
+}
+
+// wait returns how long to wait from now for the window to open, or 0 if it
+// is open.
+func (w SendWindow) wait(now time.Time) time.Duration {
+	if w.Start == w.End {
+		return 0
+	}
+	loc := w.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	since := now.Sub(midnight)
+
+	inWindow := since >= w.Start && since < w.End
+	if w.Start > w.End { // Spans midnight.
+		inWindow = since >= w.Start || since < w.End
+	}
+	if inWindow {
+		return 0
+	}
+	if since < w.Start {
+		return w.Start - since
+	}
+	return 24*time.Hour - since + w.Start
+}
+
+// RecipientState is the progress of the message to a Recipient.
+type RecipientState int
+
+// Recipient states
+const (
+	RecipientPending     RecipientState = iota + 1 // Not sent yet.
+	RecipientSubmitted                             // Accepted by Nexmo.
+	RecipientFailed                                // Not accepted by Nexmo.
+	RecipientDelivered                             // Delivered to the handset.
+	RecipientUndelivered                           // Will never be delivered.
+)
+
+var recipientStateMap = map[string]RecipientState{
+	"pending":     RecipientPending,
+	"submitted":   RecipientSubmitted,
+	"failed":      RecipientFailed,
+	"delivered":   RecipientDelivered,
+	"undelivered": RecipientUndelivered,
+}
+
+var recipientStateIntMap = map[RecipientState]string{
+	RecipientPending:     "pending",
+	RecipientSubmitted:   "submitted",
+	RecipientFailed:      "failed",
+	RecipientDelivered:   "delivered",
+	RecipientUndelivered: "undelivered",
+}
+
+// String implements the fmt.Stringer interface.
+func (s RecipientState) String() string {
+	if str, ok := recipientStateIntMap[s]; ok {
+		return str
+	}
+	return "undefined"
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (s RecipientState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (s *RecipientState) UnmarshalText(text []byte) error {
+	state, ok := recipientStateMap[string(text)]
+	if !ok {
+		return fmt.Errorf("unknown recipient state %q", text)
+	}
+	*s = state
+	return nil
+}
+
+// RecipientStatus is the progress of the message to a Recipient.
+type RecipientStatus struct {
+	To         string         `json:"to"`
+	State      RecipientState `json:"state"`
+	MessageIDs []string       `json:"message_ids,omitempty"`
+
+	// The parts of the message delivered so far, out of MessageIDs.
+	DeliveredIDs []string `json:"delivered_message_ids,omitempty"`
+
+	// Why the message was not submitted or delivered.
+	Error     string       `json:"error,omitempty"`
+	ErrorCode DLRErrorCode `json:"error_code,omitempty"`
+}
+
+// CampaignRun is a Campaign being sent.
+type CampaignRun struct {
+	campaign *Campaign
+	clock    Clock
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu         sync.Mutex
+	statuses   []RecipientStatus
+	next       int                 // First recipient which may be pending.
+	recipients map[*SMSMessage]int // Messages in flight.
+	messageIDs map[string]int      // Recipients by message ID.
+	resume     chan struct{}       // Non-nil while paused.
+	err        error               // First error saving progress.
+}
+
+// StartCampaign starts sending camp in the background. Recipients whose message
+// is found in camp.Sent, i.e. was accepted by Nexmo when the campaign was
+// started before, are skipped, and the receipts listed by camp.Store, if it
+// can, are applied to them. Messages which were not accepted, or were in
+// flight when the campaign was interrupted, are sent again.
+//
+// The message to each recipient is sent with a client reference derived from
+// the campaign ID and the index of the recipient, so that, with a client
+// created with WithDeduplication and a SentCache outliving the application,
+// messages in flight when the campaign was interrupted are not delivered
+// twice.
+//
+// Delivery receipts of the messages must be passed to HandleReceipt for their
+// outcome to be reported.
+func (c *SMS) StartCampaign(ctx context.Context, camp *Campaign) (*CampaignRun, error) {
+	if camp.Sent == nil {
+		camp.Sent = &MemorySentCache{}
+	}
+
+	statuses := make([]RecipientStatus, len(camp.Recipients))
+	messageIDs := make(map[string]int)
+	pending := 0
+	for i, r := range camp.Recipients {
+		statuses[i] = RecipientStatus{To: r.To, State: RecipientPending}
+		resp, err := camp.Sent.Load(sentKey(campaignReference(camp.ID, i), r.To))
+		if err != nil {
+			return nil, err
+		}
+		if resp == nil {
+			pending++
+			continue
+		}
+		statuses[i].State = RecipientSubmitted
+		for _, report := range resp.Messages {
+			if report.MessageID != "" {
+				statuses[i].MessageIDs = append(statuses[i].MessageIDs, report.MessageID)
+				messageIDs[report.MessageID] = i
+			}
+		}
+	}
+
+	if camp.BalanceGuard != nil {
+		if err := camp.BalanceGuard.CheckBatch(pending, camp.MessagePrice); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	run := &CampaignRun{
+		campaign:   camp,
+		clock:      clockOrSystem(c.client.Clock),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		statuses:   statuses,
+		recipients: make(map[*SMSMessage]int),
+		messageIDs: messageIDs,
+	}
+	if lister, ok := camp.Store.(receiptLister); ok {
+		receipts, err := lister.Receipts()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		for _, rcpt := range receipts {
+			run.applyReceiptLocked(rcpt)
+		}
+	}
+
+	rate, inFlight := camp.Rate, camp.InFlight
+	if rate <= 0 {
+		rate = 10
+	}
+	if inFlight <= 0 {
+		inFlight = 10
+	}
+	out := c.SendFromSource(ctx, run, WithInFlight(inFlight), WithSendRate(rate, 1),
+		WithStreamSendOptions(camp.SendOptions...))
+	go func() {
+		defer close(run.done)
+		for res := range out {
+			run.record(res)
+		}
+	}()
+	return run, nil
+}
+
+// Next implements MessageSource, handing out the messages of pending
+// recipients while the campaign is not paused and its window is open.
+func (r *CampaignRun) Next(ctx context.Context) (*SMSMessage, func(error), error) {
+	for {
+		r.mu.Lock()
+		if resume := r.resume; resume != nil {
+			r.mu.Unlock()
+			select {
+			case <-resume:
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+		for r.next < len(r.statuses) && r.statuses[r.next].State != RecipientPending {
+			r.next++
+		}
+		if r.next == len(r.statuses) {
+			r.mu.Unlock()
+			return nil, nil, io.EOF
+		}
+		r.mu.Unlock()
+
+		if wait := r.campaign.Window.wait(r.clock.Now()); wait > 0 {
+			select {
+			case <-r.clock.After(wait):
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+
+		r.mu.Lock()
+		i := r.next
+		r.next++
+		recipient := r.campaign.Recipients[i]
+		var text bytes.Buffer
+		if err := r.campaign.Template.Execute(&text, recipient); err != nil {
+			r.statuses[i].State = RecipientFailed
+			r.statuses[i].Error = err.Error()
+			r.mu.Unlock()
+			continue
+		}
+		msg := &SMSMessage{From: r.campaign.From, To: recipient.To, Type: Text, Text: text.String(),
+			ClientReference: campaignReference(r.campaign.ID, i)}
+		if CountSegments(msg.Text).Encoding == UCS2 {
+			msg.Type = Unicode
+		}
+		r.recipients[msg] = i
+		r.mu.Unlock()
+		return msg, nil, nil
+	}
+}
+
+// record updates the status of the recipient res is for.
+func (r *CampaignRun) record(res SendResult) {
+	if res.Message == nil {
+		return
+	}
+
+	r.mu.Lock()
+	i := r.recipients[res.Message]
+	delete(r.recipients, res.Message)
+	if res.Err != nil && r.ctx.Err() != nil {
+		// Interrupted by Cancel; still pending.
+		r.mu.Unlock()
+		return
+	}
+
+	status := &r.statuses[i]
+	submitErr := res.submitErr()
+	if submitErr != nil {
+		status.State = RecipientFailed
+		status.Error = submitErr.Error()
+	} else {
+		status.State = RecipientSubmitted
+	}
+	if res.Response != nil {
+		for _, report := range res.Response.Messages {
+			if report.MessageID != "" {
+				status.MessageIDs = append(status.MessageIDs, report.MessageID)
+				r.messageIDs[report.MessageID] = i
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	if submitErr == nil {
+		ttl := r.campaign.SentTTL
+		if ttl <= 0 {
+			ttl = 30 * 24 * time.Hour
+		}
+		r.saveErr(r.campaign.Sent.Store(sentKey(res.Message.ClientReference, res.Message.To), res.Response, ttl))
+	}
+}
+
+// campaignReference returns the client reference of the message to the
+// recipient at index i of the campaign with the given ID.
+func campaignReference(id string, i int) string {
+	ref := id + "-" + strconv.Itoa(i)
+	if len(ref) > maxClientRefLength {
+		sum := sha256.Sum256([]byte(id))
+		ref = hex.EncodeToString(sum[:8]) + "-" + strconv.Itoa(i)
+	}
+	return ref
+}
+
+// HandleReceipt records the outcome of the message rcpt is for, if it belongs
+// to the campaign, and saves rcpt in the Store of the campaign. It returns
+// false otherwise, so receipts of several campaigns can be passed to each of
+// them.
+func (r *CampaignRun) HandleReceipt(rcpt *DeliveryReceipt) bool {
+	r.mu.Lock()
+	ok := r.applyReceiptLocked(rcpt)
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	if r.campaign.Store != nil {
+		r.saveErr(r.campaign.Store.SaveReceipt(rcpt))
+	}
+	return true
+}
+
+// applyReceiptLocked records the outcome of the message rcpt is for,
+// returning false if it does not belong to the campaign. r.mu must be held.
+func (r *CampaignRun) applyReceiptLocked(rcpt *DeliveryReceipt) bool {
+	i, ok := r.messageIDs[rcpt.MessageID]
+	if !ok {
+		return false
+	}
+	status := &r.statuses[i]
+	switch {
+	case rcpt.Status.IsFailure():
+		status.State = RecipientUndelivered
+		status.ErrorCode = rcpt.ErrorCode
+	case rcpt.Status.IsDelivered() && status.State != RecipientUndelivered:
+		if contains(status.DeliveredIDs, rcpt.MessageID) {
+			break
+		}
+		status.DeliveredIDs = append(status.DeliveredIDs, rcpt.MessageID)
+		// A message is delivered once all of its parts are.
+		if len(status.DeliveredIDs) >= len(status.MessageIDs) {
+			status.State = RecipientDelivered
+		}
+	}
+	return true
+}
+
+// saveErr records err, if it is the first error saving progress.
+func (r *CampaignRun) saveErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Pause stops the campaign from sending further messages until Resume is
+// called. Messages in flight are still sent.
+func (r *CampaignRun) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.resume == nil {
+		r.resume = make(chan struct{})
+	}
+}
+
+// Resume resumes a paused campaign.
+func (r *CampaignRun) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.resume != nil {
+		close(r.resume)
+		r.resume = nil
+	}
+}
+
+// Cancel stops the campaign, canceling the messages in flight. Its progress
+// is kept, so it can be started again later.
+func (r *CampaignRun) Cancel() {
+	r.cancel()
+}
+
+// Wait waits for all messages of the campaign to be sent, or for it to be
+// canceled. It returns the first error saving its progress, if any.
+func (r *CampaignRun) Wait() error {
+	<-r.done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Progress returns the status of every recipient, in the order of
+// Campaign.Recipients.
+func (r *CampaignRun) Progress() []RecipientStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]RecipientStatus, len(r.statuses))
+	for i, s := range r.statuses {
+		s.MessageIDs = append([]string(nil), s.MessageIDs...)
+		s.DeliveredIDs = append([]string(nil), s.DeliveredIDs...)
+		statuses[i] = s
+	}
+	return statuses
+}
@@ -0,0 +1,245 @@
+package nexmo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+)
+
+// listingStore is a Store which can list the receipts saved in it.
+type listingStore struct {
+	testStore
+}
+
+func (s *listingStore) Receipts() ([]*DeliveryReceipt, error) {
+	return s.receipts, s.err
+}
+
+func TestCampaign(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	sent := make(map[string]*SMSMessage)
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var msg SMSMessage
+		json.NewDecoder(req.Body).Decode(&msg)
+		mu.Lock()
+		sent[msg.To] = &msg
+		mu.Unlock()
+
+		status := "0"
+		if msg.To == "447700900002" {
+			status = "6"
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"` + status +
+				`","message-id":"ID-` + msg.To + `"}]}`)),
+		}, nil
+	})}
+
+	camp := &Campaign{
+		ID:       "spring",
+		From:     "Shop",
+		Template: template.Must(template.New("").Parse("Hi {{.Fields.name}}")),
+		Recipients: []Recipient{
+			{To: "447700900000", Fields: map[string]string{"name": "Alice"}},
+			{To: "447700900001", Fields: map[string]string{"name": "Łukasz"}},
+			{To: "447700900002", Fields: map[string]string{"name": "Carol"}},
+		},
+		Rate:  1000,
+		Sent:  &MemorySentCache{},
+		Store: &listingStore{},
+	}
+
+	// Sent when the campaign was started before.
+	camp.Sent.Store(sentKey("spring-0", "447700900000"), &MessageResponse{
+		Messages: []MessageReport{{Status: ResponseSuccess, MessageID: "ID-447700900000"}},
+	}, time.Hour)
+
+	run, err := client.SMS().StartCampaign(context.Background(), camp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent["447700900000"] != nil {
+		t.Errorf("sent to %d recipients, including the first: %t", len(sent), sent["447700900000"] != nil)
+	}
+	if msg := sent["447700900001"]; msg == nil || msg.Text != "Hi Łukasz" || msg.Type != Unicode {
+		t.Errorf("sent %+v", msg)
+	}
+	if msg := sent["447700900002"]; msg == nil || msg.Type != Text {
+		t.Errorf("sent %+v", msg)
+	}
+
+	run.HandleReceipt(&DeliveryReceipt{MessageID: "ID-447700900000", Status: DeliveryDelivered})
+	run.HandleReceipt(&DeliveryReceipt{MessageID: "ID-447700900001", Status: DeliveryFailed, ErrorCode: 6})
+	if run.HandleReceipt(&DeliveryReceipt{MessageID: "other"}) {
+		t.Error("HandleReceipt accepted a receipt of another message")
+	}
+
+	want := []RecipientState{RecipientDelivered, RecipientUndelivered, RecipientFailed}
+	for i, status := range run.Progress() {
+		if status.State != want[i] {
+			t.Errorf("recipient %d is %v, want %v", i, status.State, want[i])
+		}
+	}
+	if n := len(camp.Store.(*listingStore).receipts); n != 2 {
+		t.Errorf("saved %d receipts", n)
+	}
+
+	// Started again, only the rejected message is sent again, and the
+	// outcome of the others is restored.
+	sent = make(map[string]*SMSMessage)
+	run, err = client.SMS().StartCampaign(context.Background(), camp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent["447700900002"] == nil {
+		t.Errorf("sent to %d recipients on restart", len(sent))
+	}
+	for i, status := range run.Progress() {
+		if status.State != want[i] {
+			t.Errorf("recipient %d is %v after restart, want %v", i, status.State, want[i])
+		}
+	}
+	if code := run.Progress()[1].ErrorCode; code != 6 {
+		t.Errorf("restored error code %d", code)
+	}
+}
+
+func TestCampaignMultipart(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var msg SMSMessage
+		json.NewDecoder(req.Body).Decode(&msg)
+		refs = append(refs, msg.ClientReference)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(`{"message-count":"2","messages":[` +
+				`{"status":"0","message-id":"ID-1"},{"status":"0","message-id":"ID-2"}]}`)),
+		}, nil
+	})}
+
+	camp := &Campaign{
+		ID:         "long",
+		From:       "Shop",
+		Template:   template.Must(template.New("").Parse(strings.Repeat("Hello ", 40))),
+		Recipients: []Recipient{{To: "447700900000"}},
+	}
+	run, err := client.SMS().StartCampaign(context.Background(), camp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0] != "long-0" {
+		t.Errorf("sent client references %q", refs)
+	}
+
+	// Delivered only once both parts are, even if a receipt is repeated.
+	run.HandleReceipt(&DeliveryReceipt{MessageID: "ID-1", Status: DeliveryDelivered})
+	run.HandleReceipt(&DeliveryReceipt{MessageID: "ID-1", Status: DeliveryDelivered})
+	if state := run.Progress()[0].State; state != RecipientSubmitted {
+		t.Errorf("recipient is %v after delivery of one part", state)
+	}
+	run.HandleReceipt(&DeliveryReceipt{MessageID: "ID-2", Status: DeliveryDelivered})
+	if state := run.Progress()[0].State; state != RecipientDelivered {
+		t.Errorf("recipient is %v after delivery of both parts", state)
+	}
+
+	// Both parts are remembered in the default cache.
+	resp, _ := camp.Sent.Load(sentKey("long-0", "447700900000"))
+	if resp == nil || len(resp.Messages) != 2 {
+		t.Errorf("remembered %+v", resp)
+	}
+}
+
+func TestCampaignReference(t *testing.T) {
+	if ref := campaignReference("spring", 3); ref != "spring-3" {
+		t.Errorf("got %q", ref)
+	}
+	long := strings.Repeat("x", 40)
+	if ref := campaignReference(long, 3); len(ref) > 40 || ref != campaignReference(long, 3) || ref == campaignReference(long, 4) {
+		t.Errorf("got %q", ref)
+	}
+}
+
+func TestCampaignPause(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := make(chan string, 10)
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var msg SMSMessage
+		json.NewDecoder(req.Body).Decode(&msg)
+		sent <- msg.To
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	camp := &Campaign{
+		ID:         "paused",
+		From:       "Shop",
+		Template:   template.Must(template.New("").Parse("Hello")),
+		Recipients: []Recipient{{To: "447700900000"}, {To: "447700900001"}},
+	}
+	run, err := client.SMS().StartCampaign(context.Background(), camp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run.Pause()
+	defer run.Cancel()
+
+	// At most the message already handed out before pausing is sent.
+	time.Sleep(10 * time.Millisecond)
+	if len(sent) > 1 {
+		t.Errorf("%d messages sent while paused", len(sent))
+	}
+	run.Resume()
+	if err := run.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("%d messages sent", len(sent))
+	}
+}
+
+func TestSendWindow(t *testing.T) {
+	w := SendWindow{Start: 9 * time.Hour, End: 20 * time.Hour, Location: time.UTC}
+	for _, tc := range []struct {
+		now  string
+		want time.Duration
+	}{
+		{"2017-01-02T08:30:00Z", 30 * time.Minute},
+		{"2017-01-02T12:00:00Z", 0},
+		{"2017-01-02T21:00:00Z", 12 * time.Hour},
+	} {
+		now, _ := time.Parse(time.RFC3339, tc.now)
+		if got := w.wait(now); got != tc.want {
+			t.Errorf("wait at %s = %v, want %v", tc.now, got, tc.want)
+		}
+	}
+}
@@ -3,40 +3,157 @@ package nexmo
 import (
 	"errors"
 	"net/http"
+	"sync"
 )
 
 // Client encapsulates the Nexmo functions.
-// Should be created with NewClient()
+//
+// A Client is usually created with NewClient, but it can be constructed as a
+// struct literal as well, with APIKey and APISecret set. The modules, reached
+// through the SMS, Verify, etc. methods, and the HTTPClient if left nil, are
+// then set up on first use.
 type Client struct {
-	Account    *Account
-	SMS        *SMS
-	USSD       *USSD
-	Verify     *Verification
 	HTTPClient *http.Client
-	apiKey     string
-	apiSecret  string
-	useOauth   bool
+
+	// Number of times requests answered with a 429 Too Many Requests are
+	// retried. Defaults to 0.
+	MaxRetries int
+
+	// Used to wait between retries. Defaults to SystemClock.
+	Clock Clock
+
+	// Receives measurements of the requests sent by the client, if set.
+	Metrics MetricsCollector
+
+	// Logs the requests sent by the client, if set.
+	Logger Logger
+
+	// Ranges callbacks are accepted from by the handlers mounted with
+	// MountWebhooks. Defaults to DefaultTrustedIPs if nil.
+	TrustedIPs *TrustedIPs
+
+	// Credentials of the account. Change them with SetCredentials once the
+	// client is in use.
+	APIKey    string
+	APISecret string
+
+	// If set, supplies the credentials instead of APIKey and APISecret.
+	CredentialsProvider CredentialsProvider
+
+	// Authenticates the requests. Defaults to APIKeyAuth with the
+	// credentials above if nil.
+	Auth Auth
+
+	encodings      map[Endpoint]Encoding
+	retryBudget    *RetryBudget
+	breaker        *CircuitBreaker
+	hedging        *hedger
+	failovers      map[string]*failover // By primary host.
+	contentFilters []ContentFilter
+	autoClientRef  bool
+	dryRun         bool
+	logLevels      LogLevels
+	tracing        bool
+	dedup          *deduplicator
+	traceSuccesses bool
+	credsMu        sync.RWMutex
+	once           sync.Once
+
+	account  *Account
+	sms      *SMS
+	ussd     *USSD
+	verify   *Verification
+	messages *Messages
+	insight  *Insight
 }
 
-// NewClient creates a new Client type with the
-// provided API key / API secret.
-func NewClient(apiKey, apiSecret string) (*Client, error) {
-	if apiKey == "" {
-		return nil, errors.New("apiKey can not be empty")
-	} else if apiSecret == "" {
-		return nil, errors.New("apiSecret can not be empty")
+// ClientOption configures a Client created with NewClient.
+type ClientOption func(*Client)
+
+// WithMessagesSandbox makes the client send Messages API messages to the
+// sandbox at messages-sandbox.nexmo.com, which only delivers to allowlisted
+// test numbers. Responses from the sandbox have their Sandbox field set.
+func WithMessagesSandbox() ClientOption {
+	return func(c *Client) {
+		c.Messages().sandbox = true
 	}
+}
 
+// NewClient creates a new Client type with the
+// provided API key / API secret. Both may be empty if the credentials are
+// supplied by a provider given with WithCredentials, or if the requests are
+// authenticated otherwise with WithAuth.
+func NewClient(apiKey, apiSecret string, opts ...ClientOption) (*Client, error) {
 	c := &Client{
-		apiKey:    apiKey,
-		apiSecret: apiSecret,
-		useOauth:  false,
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+	}
+	c.Init()
+
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	c.Account = &Account{c}
-	c.SMS = &SMS{c}
-	c.USSD = &USSD{c}
-	c.Verify = &Verification{c}
-	c.HTTPClient = http.DefaultClient
+	if c.CredentialsProvider == nil && c.Auth == nil {
+		if apiKey == "" {
+			return nil, errors.New("apiKey can not be empty")
+		} else if apiSecret == "" {
+			return nil, errors.New("apiSecret can not be empty")
+		}
+	}
 	return c, nil
 }
+
+// Init creates the modules of c, and sets HTTPClient to DefaultHTTPClient if
+// it is nil. Only the first call has an effect, and it is safe to call
+// concurrently. It is called by NewClient, by the module accessors and
+// whenever c sends a request, so calling it is never required.
+func (c *Client) Init() {
+	c.once.Do(func() {
+		c.account = &Account{c}
+		c.sms = &SMS{c}
+		c.ussd = &USSD{c}
+		c.verify = &Verification{c}
+		c.messages = &Messages{client: c}
+		c.insight = &Insight{c}
+		if c.HTTPClient == nil {
+			c.HTTPClient = DefaultHTTPClient
+		}
+	})
+}
+
+// Account returns the Account API of c.
+func (c *Client) Account() *Account {
+	c.Init()
+	return c.account
+}
+
+// SMS returns the SMS API of c.
+func (c *Client) SMS() *SMS {
+	c.Init()
+	return c.sms
+}
+
+// USSD returns the USSD API of c.
+func (c *Client) USSD() *USSD {
+	c.Init()
+	return c.ussd
+}
+
+// Verify returns the Verify API of c.
+func (c *Client) Verify() *Verification {
+	c.Init()
+	return c.verify
+}
+
+// Messages returns the Messages API of c.
+func (c *Client) Messages() *Messages {
+	c.Init()
+	return c.messages
+}
+
+// Insight returns the Number Insight API of c.
+func (c *Client) Insight() *Insight {
+	c.Init()
+	return c.insight
+}
@@ -0,0 +1,87 @@
+package nexmo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClientLiteral(t *testing.T) {
+	var got *http.Request
+	client := &Client{
+		APIKey:    "k3y",
+		APISecret: "s3cr3t",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			got = req
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value":1.5}`)),
+			}, nil
+		})},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Init()
+		}()
+	}
+	wg.Wait()
+
+	if client.account == nil || client.sms == nil || client.ussd == nil ||
+		client.verify == nil || client.messages == nil || client.insight == nil {
+		t.Fatalf("modules were not initialized: %#v", client)
+	}
+
+	if _, err := client.Account().GetBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if q := got.URL.Query(); q.Get("api_key") != "k3y" || q.Get("api_secret") != "s3cr3t" {
+		t.Errorf("request was sent to %s", got.URL)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.HTTPClient != DefaultHTTPClient || client.HTTPClient.Timeout == 0 {
+		t.Errorf("got HTTP client %#v", client.HTTPClient)
+	}
+
+	client, err = NewClient("k3y", "s3cr3t", WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.HTTPClient != http.DefaultClient {
+		t.Errorf("got HTTP client %#v", client.HTTPClient)
+	}
+}
+
+func TestClientLiteralWithoutInit(t *testing.T) {
+	var got *http.Request
+	client := &Client{
+		APIKey:    "k3y",
+		APISecret: "s3cr3t",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			got = req
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+			}, nil
+		})},
+	}
+
+	resp, err := client.SMS().Send(&SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Messages) != 1 || got == nil || got.URL.Path != "/sms/json" {
+		t.Errorf("got response %+v for request %v", resp, got)
+	}
+}
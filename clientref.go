@@ -0,0 +1,27 @@
+package nexmo
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// WithAutoClientReference makes the client send messages without a
+// ClientReference with a random UUID as their reference, so that every
+// message can be traced through its delivery receipts. The reference is
+// reported in MessageResponse.ClientReference.
+func WithAutoClientReference() ClientOption {
+	return func(c *Client) {
+		c.autoClientRef = true
+	}
+}
+
+// newClientReference generates a random version 4 UUID.
+func newClientReference() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
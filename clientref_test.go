@@ -0,0 +1,48 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAutoClientReference(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithAutoClientReference())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent map[string]interface{}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	resp, err := client.SMS().Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(resp.ClientReference) || sent["client-ref"] != resp.ClientReference {
+		t.Errorf("sent reference %v, reported %q", sent["client-ref"], resp.ClientReference)
+	}
+	if msg.ClientReference != "" {
+		t.Error("the reference was set on the message")
+	}
+
+	msg.ClientReference = "order-42"
+	if resp, err = client.SMS().Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	if sent["client-ref"] != "order-42" || resp.ClientReference != "order-42" {
+		t.Errorf("sent reference %v, reported %q", sent["client-ref"], resp.ClientReference)
+	}
+}
@@ -0,0 +1,27 @@
+package nexmo
+
+import "time"
+
+// Clock tells the time. Everything in this package that depends on the
+// current time or waits goes through a Clock, so tests can control time
+// instead of sleeping. nexmotest.Clock is a manually advanced implementation.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock used unless another one is configured.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockOrSystem returns c, or SystemClock if c is nil.
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
@@ -0,0 +1,108 @@
+/*
+Command gonexmo-webhook runs the nexmo.WebhookServer and prints every callback
+it receives as a line of JSON, which is handy during local development,
+e.g. behind a tunnel:
+
+	gonexmo-webhook -addr :8080 -forward http://localhost:3000/nexmo
+
+Callbacks are printed to stdout. With -forward, they are also POSTed as JSON
+to the given URL, wrapped in an object with the kind of callback:
+
+	{"type": "message", "payload": {...}}
+*/
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+type callback struct {
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload"`
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	forward := flag.String("forward", "", "URL to forward callbacks to")
+	verifyIPs := flag.Bool("verify-ips", false, "only accept callbacks from Nexmo IPs")
+	flag.Parse()
+
+	srv := nexmo.NewWebhookServer(*addr)
+	srv.VerifyIPs = *verifyIPs
+	srv.Options = []nexmo.HandlerOption{
+		nexmo.WithErrorLog(log.New(os.Stderr, "", log.LstdFlags)),
+	}
+
+	go func() {
+		enc := json.NewEncoder(os.Stdout)
+		for {
+			var cb callback
+			select {
+			case m := <-srv.Messages:
+				cb = callback{"message", m}
+			case r := <-srv.Receipts:
+				cb = callback{"delivery", r}
+			case s := <-srv.Statuses:
+				cb = callback{"status", s}
+			case v := <-srv.VerifyEvents:
+				cb = callback{"verify", v}
+			case v := <-srv.VoiceEvents:
+				cb = callback{"voice", v}
+			}
+
+			enc.Encode(cb)
+			if *forward != "" {
+				if err := post(*forward, cb); err != nil {
+					log.Printf("forwarding %s callback: %v", cb.Type, err)
+				}
+			}
+		}
+	}()
+
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt)
+		<-stop
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	log.Printf("listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+var forwardClient = &http.Client{Timeout: 10 * time.Second}
+
+// post forwards cb to url as JSON.
+func post(url string, cb callback) error {
+	buf, err := json.Marshal(cb)
+	if err != nil {
+		return err
+	}
+
+	resp, err := forwardClient.Post(url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("got %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package nexmo
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ConsumerOption configures Consume.
+type ConsumerOption func(*consumerConfig)
+
+type consumerConfig struct {
+	workers   int
+	retries   int
+	backoff   time.Duration
+	onFailure func(v interface{}, err error)
+	clock     Clock
+}
+
+// WithWorkers sets how many values are processed concurrently. Defaults to 10.
+func WithWorkers(n int) ConsumerOption {
+	return func(cfg *consumerConfig) {
+		cfg.workers = n
+	}
+}
+
+// WithConsumerRetries makes Consume process a value up to n more times when
+// the function processing it returns an error or panics, waiting backoff
+// before the first retry and twice as long before each following one.
+func WithConsumerRetries(n int, backoff time.Duration) ConsumerOption {
+	return func(cfg *consumerConfig) {
+		cfg.retries = n
+		cfg.backoff = backoff
+	}
+}
+
+// WithFailureHandler sets a function called with the values which could not
+// be processed and the last error, e.g. to log them or move them to a dead
+// letter queue. It is called concurrently from the workers.
+func WithFailureHandler(fn func(v interface{}, err error)) ConsumerOption {
+	return func(cfg *consumerConfig) {
+		cfg.onFailure = fn
+	}
+}
+
+// WithConsumerClock makes Consume wait between retries with c.
+func WithConsumerClock(c Clock) ConsumerOption {
+	return func(cfg *consumerConfig) {
+		cfg.clock = c
+	}
+}
+
+// PanicError is the error of a value whose processing panicked.
+type PanicError struct {
+	Value interface{} // Passed to panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Consume processes the values received from in, e.g. the channel of a
+// message or delivery handler, with fn on a pool of workers. Panics in fn are
+// recovered and treated like errors. Consume returns nil once in is closed
+// and all values are processed, or the error of ctx once it is done, after
+// waiting for the workers to finish.
+func Consume[T any](ctx context.Context, in <-chan T, fn func(context.Context, T) error, opts ...ConsumerOption) error {
+	cfg := &consumerConfig{workers: 10}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+	clock := clockOrSystem(cfg.clock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					if err := consumeOne(ctx, cfg, clock, fn, v); err != nil && cfg.onFailure != nil {
+						cfg.onFailure(v, err)
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// consumeOne processes v with fn, retrying according to cfg.
+func consumeOne[T any](ctx context.Context, cfg *consumerConfig, clock Clock, fn func(context.Context, T) error, v T) error {
+	backoff := cfg.backoff
+	for attempt := 0; ; attempt++ {
+		err := safeCall(ctx, fn, v)
+		if err == nil || attempt >= cfg.retries {
+			return err
+		}
+
+		select {
+		case <-clock.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// safeCall calls fn, converting a panic to a PanicError.
+func safeCall[T any](ctx context.Context, fn func(context.Context, T) error, v T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx, v)
+}
@@ -0,0 +1,67 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsume(t *testing.T) {
+	in := make(chan *ReceivedMessage, 10)
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		in <- &ReceivedMessage{ID: id}
+	}
+	close(in)
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	failed := make(map[string]error)
+	clock := &instantClock{}
+	err := Consume(context.Background(), in, func(ctx context.Context, m *ReceivedMessage) error {
+		mu.Lock()
+		attempts[m.ID]++
+		n := attempts[m.ID]
+		mu.Unlock()
+
+		switch m.ID {
+		case "2":
+			panic("boom")
+		case "3":
+			if n == 1 {
+				return errors.New("temporary failure")
+			}
+		}
+		return nil
+	},
+		WithWorkers(2),
+		WithConsumerRetries(2, 10),
+		WithConsumerClock(clock),
+		WithFailureHandler(func(v interface{}, err error) {
+			mu.Lock()
+			failed[v.(*ReceivedMessage).ID] = err
+			mu.Unlock()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(attempts) != 5 || attempts["1"] != 1 || attempts["2"] != 3 || attempts["3"] != 2 {
+		t.Errorf("got attempts %v", attempts)
+	}
+	if len(failed) != 1 {
+		t.Errorf("got failures %v", failed)
+	}
+	if e, ok := failed["2"].(*PanicError); !ok || e.Value != "boom" {
+		t.Errorf("got error %v for the panicking message", failed["2"])
+	}
+	// Message 2 waits 10 and 20, message 3 waits 10.
+	var total time.Duration
+	for _, d := range clock.delays {
+		total += d
+	}
+	if len(clock.delays) != 3 || total != 40 {
+		t.Errorf("got delays %v", clock.delays)
+	}
+}
@@ -0,0 +1,58 @@
+package nexmo
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContentFilter checks the text of text and unicode messages before they are
+// sent, e.g. for profanity, personal data or links to unapproved domains. It
+// returns the text to send, which may be rewritten, or an error to reject the
+// message, preferably a *ContentRejectedError.
+type ContentFilter interface {
+	FilterContent(ctx context.Context, msg *SMSMessage) (string, error)
+}
+
+// ContentFilterFunc adapts a function to a ContentFilter.
+type ContentFilterFunc func(ctx context.Context, msg *SMSMessage) (string, error)
+
+// FilterContent implements ContentFilter.
+func (f ContentFilterFunc) FilterContent(ctx context.Context, msg *SMSMessage) (string, error) {
+	return f(ctx, msg)
+}
+
+// ContentRejectedError is returned by content filters rejecting a message.
+// SMS.Send returns it unchanged, and streams report it in SendResult.Err.
+type ContentRejectedError struct {
+	Reason string
+}
+
+func (e *ContentRejectedError) Error() string {
+	return fmt.Sprintf("message content rejected: %s", e.Reason)
+}
+
+// WithContentFilter makes the client pass the messages it sends through f.
+// Filters are applied in the order they are added.
+func WithContentFilter(f ContentFilter) ClientOption {
+	return func(c *Client) {
+		c.contentFilters = append(c.contentFilters, f)
+	}
+}
+
+// filterContent passes msg through the content filters of c, updating its
+// text.
+func (c *Client) filterContent(ctx context.Context, msg *SMSMessage) error {
+	switch msg.typeName() {
+	case Text, Unicode:
+	default:
+		return nil
+	}
+	for _, f := range c.contentFilters {
+		text, err := f.FilterContent(ctx, msg)
+		if err != nil {
+			return err
+		}
+		msg.Text = text
+	}
+	return nil
+}
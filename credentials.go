@@ -0,0 +1,169 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials is returned by the providers of a ChainCredentials which
+// have no credentials to offer.
+var ErrNoCredentials = errors.New("no credentials available")
+
+// Credentials are the API key and secret of a Nexmo account.
+type Credentials struct {
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+}
+
+// CredentialsProvider supplies the credentials a Client sends with its
+// requests. It is asked before every request, so credentials can be changed
+// at runtime; implementations should cache credentials which are expensive to
+// retrieve.
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// WithCredentials makes the client use the credentials supplied by p instead
+// of the API key and secret given to NewClient, which may then be empty.
+func WithCredentials(p CredentialsProvider) ClientOption {
+	return func(c *Client) {
+		c.CredentialsProvider = p
+	}
+}
+
+// SetCredentials changes the API key and secret of c. It is safe to call
+// while requests are being sent: requests already sent keep the credentials
+// they were sent with, and later ones use the new credentials. Credentials
+// supplied by a CredentialsProvider take precedence over them.
+func (c *Client) SetCredentials(apiKey, apiSecret string) {
+	c.credsMu.Lock()
+	c.APIKey = apiKey
+	c.APISecret = apiSecret
+	c.credsMu.Unlock()
+}
+
+// credentials returns the credentials to send with a request.
+func (c *Client) credentials() (Credentials, error) {
+	if c.CredentialsProvider == nil {
+		c.credsMu.RLock()
+		defer c.credsMu.RUnlock()
+		return Credentials{APIKey: c.APIKey, APISecret: c.APISecret}, nil
+	}
+	return c.CredentialsProvider.Credentials()
+}
+
+// requestCredentials returns override if not nil, or the credentials of c.
+func (c *Client) requestCredentials(override *Credentials) (Credentials, error) {
+	if override != nil {
+		return *override, nil
+	}
+	return c.credentials()
+}
+
+// StaticCredentials always supplies the same credentials.
+type StaticCredentials Credentials
+
+// Credentials implements CredentialsProvider.
+func (s StaticCredentials) Credentials() (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// CredentialsFunc adapts a function, e.g. reading from a secret store, to a
+// CredentialsProvider.
+type CredentialsFunc func() (Credentials, error)
+
+// Credentials implements CredentialsProvider.
+func (f CredentialsFunc) Credentials() (Credentials, error) {
+	return f()
+}
+
+// EnvCredentials supplies credentials read from environment variables. They
+// are read again for every request.
+type EnvCredentials struct {
+	// Default to NEXMO_KEY and NEXMO_SECRET.
+	KeyVar    string
+	SecretVar string
+}
+
+// Credentials implements CredentialsProvider. It returns ErrNoCredentials if
+// one of the variables is not set.
+func (e EnvCredentials) Credentials() (Credentials, error) {
+	keyVar, secretVar := e.KeyVar, e.SecretVar
+	if keyVar == "" {
+		keyVar = "NEXMO_KEY"
+	}
+	if secretVar == "" {
+		secretVar = "NEXMO_SECRET"
+	}
+
+	creds := Credentials{APIKey: os.Getenv(keyVar), APISecret: os.Getenv(secretVar)}
+	if creds.APIKey == "" || creds.APISecret == "" {
+		return Credentials{}, ErrNoCredentials
+	}
+	return creds, nil
+}
+
+// FileCredentials supplies credentials read from a JSON file, e.g. one
+// mounted from a secret store:
+//
+//	{"api_key": "abcd1234", "api_secret": "s3cr3t"}
+//
+// The file is read again whenever its modification time changes, so the
+// credentials can be rotated by replacing it.
+type FileCredentials struct {
+	Path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	creds   Credentials
+}
+
+// Credentials implements CredentialsProvider. It returns ErrNoCredentials if
+// the file does not exist.
+func (f *FileCredentials) Credentials() (Credentials, error) {
+	fi, err := os.Stat(f.Path)
+	if os.IsNotExist(err) {
+		return Credentials{}, ErrNoCredentials
+	} else if err != nil {
+		return Credentials{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if fi.ModTime().Equal(f.modTime) {
+		return f.creds, nil
+	}
+
+	buf, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var creds Credentials
+	if err := json.Unmarshal(buf, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("reading credentials from %s: %v", f.Path, err)
+	}
+
+	f.creds, f.modTime = creds, fi.ModTime()
+	return creds, nil
+}
+
+// ChainCredentials asks its providers in order and supplies the credentials
+// of the first one not returning ErrNoCredentials.
+type ChainCredentials []CredentialsProvider
+
+// Credentials implements CredentialsProvider.
+func (c ChainCredentials) Credentials() (Credentials, error) {
+	for _, p := range c {
+		creds, err := p.Credentials()
+		if err != ErrNoCredentials {
+			return creds, err
+		}
+	}
+	return Credentials{}, ErrNoCredentials
+}
@@ -0,0 +1,104 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCredentialsChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexmo.json")
+	provider := ChainCredentials{
+		EnvCredentials{KeyVar: "GONEXMO_TEST_UNSET_KEY", SecretVar: "GONEXMO_TEST_UNSET_SECRET"},
+		&FileCredentials{Path: path},
+	}
+
+	client, err := NewClient("", "", WithCredentials(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Account().newBalanceRequest(); err != ErrNoCredentials {
+		t.Errorf("got error %v without credentials", err)
+	}
+
+	writeCredentials := func(json string, modTime time.Time) {
+		if err := ioutil.WriteFile(path, []byte(json), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	for i, key := range []string{"k3y", "r0tated"} {
+		writeCredentials(`{"api_key":"`+key+`","api_secret":"s3cr3t"}`, now.Add(time.Duration(i)*time.Second))
+
+		r, err := client.Account().newBalanceRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q := r.URL.Query(); q.Get("api_key") != key || q.Get("api_secret") != "s3cr3t" {
+			t.Errorf("request was sent to %s", r.URL)
+		}
+	}
+}
+
+func TestNewClientWithoutCredentials(t *testing.T) {
+	if _, err := NewClient("", "s3cr3t"); err == nil {
+		t.Error("client was created without an API key")
+	}
+	if _, err := NewClient("k3y", ""); err == nil {
+		t.Error("client was created without an API secret")
+	}
+}
+
+func TestSetCredentials(t *testing.T) {
+	client, err := NewClient("key0", "secret0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var creds Credentials
+		if err := json.NewDecoder(req.Body).Decode(&creds); err != nil {
+			t.Error(err)
+		}
+		if strings.TrimPrefix(creds.APIKey, "key") != strings.TrimPrefix(creds.APISecret, "secret") {
+			t.Errorf("sent mismatched credentials %+v", creds)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				msg := &SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: "Hello"}
+				if _, err := client.SMS().Send(msg); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 10; i++ {
+		client.SetCredentials(fmt.Sprint("key", i), fmt.Sprint("secret", i))
+	}
+	wg.Wait()
+
+	if creds, _ := client.credentials(); creds.APIKey != "key10" || creds.APISecret != "secret10" {
+		t.Errorf("got credentials %+v", creds)
+	}
+}
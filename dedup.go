@@ -0,0 +1,174 @@
+package nexmo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SentCache remembers the responses to recently sent messages, by their
+// client reference and recipient. The MemorySentCache keeps them in memory;
+// implementations sharing them between several instances of an application
+// can be plugged in instead. Implementations must be safe for concurrent use.
+type SentCache interface {
+	// Load returns the response stored for key, or nil if there is none or
+	// it has expired.
+	Load(key string) (*MessageResponse, error)
+
+	// Store records resp for key for ttl.
+	Store(key string, resp *MessageResponse, ttl time.Duration) error
+}
+
+// WithDeduplication makes the client suppress messages sent with a client
+// reference, e.g. set with WithIdempotencyKey, which was already sent to the
+// same recipient in the last ttl. Instead of sending them again, SMS.Send
+// returns the response of the original send, with Duplicate set. Only sends
+// accepted by Nexmo are remembered, so failed ones can be retried. If cache
+// is nil, every client the option is applied to gets its own
+// MemorySentCache.
+//
+// This protects against retries in the application causing double texts,
+// e.g. when a job is run again after a crash.
+func WithDeduplication(cache SentCache, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		cache := cache
+		if cache == nil {
+			// Each client gets its own, so clients sharing the option,
+			// e.g. those of a ClientManager, don't suppress each other's
+			// messages.
+			cache = &MemorySentCache{}
+		}
+		c.dedup = &deduplicator{
+			cache:    cache,
+			ttl:      ttl,
+			inFlight: make(map[string]chan struct{}),
+		}
+	}
+}
+
+type deduplicator struct {
+	cache SentCache
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	inFlight map[string]chan struct{} // Closed when the send is done.
+}
+
+// sentKey returns the key of the response to the message with the given
+// client reference sent to to.
+func sentKey(clientRef, to string) string {
+	return clientRef + "\x00" + to
+}
+
+// send calls fn to send m, unless it has been sent before. Concurrent sends
+// of the same message wait for the first one.
+func (d *deduplicator) send(ctx context.Context, m *SMSMessage, fn func() (*MessageResponse, error)) (*MessageResponse, error) {
+	key := sentKey(m.ClientReference, m.To)
+	var done chan struct{}
+	for {
+		d.mu.Lock()
+		pending, ok := d.inFlight[key]
+		if !ok {
+			done = make(chan struct{})
+			d.inFlight[key] = done
+		}
+		d.mu.Unlock()
+		if !ok {
+			break
+		}
+
+		select {
+		case <-pending:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	defer func() {
+		d.mu.Lock()
+		delete(d.inFlight, key)
+		d.mu.Unlock()
+		close(done)
+	}()
+
+	resp, err := d.cache.Load(key)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		dup := *resp
+		dup.Duplicate = true
+		return &dup, nil
+	}
+
+	resp, err = fn()
+	if err != nil || !accepted(resp) {
+		return resp, err
+	}
+	return resp, d.cache.Store(key, resp, d.ttl)
+}
+
+// accepted returns true if Nexmo accepted every part of the message resp is
+// the response to.
+func accepted(resp *MessageResponse) bool {
+	if len(resp.Messages) == 0 {
+		return false
+	}
+	for _, report := range resp.Messages {
+		if report.Status != ResponseSuccess {
+			return false
+		}
+	}
+	return true
+}
+
+// MemorySentCache is a SentCache keeping responses in memory.
+type MemorySentCache struct {
+	// Defaults to SystemClock.
+	Clock Clock
+
+	mu      sync.Mutex
+	entries map[string]sentEntry
+	pruned  time.Time
+}
+
+type sentEntry struct {
+	resp    *MessageResponse
+	expires time.Time
+}
+
+// Load implements SentCache.
+func (s *MemorySentCache) Load(key string) (*MessageResponse, error) {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return e.resp, nil
+	}
+	return nil, nil
+}
+
+// Store implements SentCache.
+func (s *MemorySentCache) Store(key string, resp *MessageResponse, ttl time.Duration) error {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries == nil {
+		s.entries = make(map[string]sentEntry)
+	}
+
+	// Get rid of expired entries every now and then.
+	if now.Sub(s.pruned) > ttl {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.pruned = now
+	}
+
+	s.entries[key] = sentEntry{resp: resp, expires: now.Add(ttl)}
+	return nil
+}
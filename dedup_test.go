@@ -0,0 +1,133 @@
+package nexmo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeduplication(t *testing.T) {
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	client, err := NewClient("k3y", "s3cr3t", WithDeduplication(&MemorySentCache{Clock: clock}, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	sent := 0
+	status := "0"
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		sent++
+		mu.Unlock()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"` + status + `","message-id":"0A01"}]}`)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	send := func(opts ...SendOption) *MessageResponse {
+		t.Helper()
+		resp, err := client.SMS().Send(msg, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Concurrent sends with the same reference result in a single request.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			send(WithIdempotencyKey("order-42"))
+		}()
+	}
+	wg.Wait()
+	if sent != 1 {
+		t.Errorf("sent %d requests", sent)
+	}
+	if resp := send(WithIdempotencyKey("order-42")); !resp.Duplicate || resp.Messages[0].MessageID != "0A01" {
+		t.Errorf("got response %+v", resp)
+	}
+
+	// Messages without a reference are always sent.
+	send()
+	send()
+	if sent != 3 {
+		t.Errorf("sent %d requests", sent)
+	}
+
+	// Rejected messages can be retried.
+	status = "1"
+	send(WithIdempotencyKey("order-43"))
+	status = "0"
+	if resp := send(WithIdempotencyKey("order-43")); resp.Duplicate || sent != 5 {
+		t.Errorf("retry of a rejected message was suppressed")
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	if resp := send(WithIdempotencyKey("order-42")); resp.Duplicate || sent != 6 {
+		t.Errorf("message was suppressed after the ttl expired")
+	}
+}
+
+func TestDeduplicationDefaultCache(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithDeduplication(nil, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := 0
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0","message-id":"0A01"}]}`)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	for i := 0; i < 2; i++ {
+		if _, err := client.SMS().Send(msg, WithIdempotencyKey("order-42")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sent != 1 {
+		t.Errorf("sent %d requests, want 1", sent)
+	}
+}
+
+func TestDeduplicationDefaultCachePerClient(t *testing.T) {
+	opt := WithDeduplication(nil, time.Hour)
+	sent := 0
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0","message-id":"0A01"}]}`)),
+		}, nil
+	})
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	for _, key := range []string{"k3y", "0th3r"} {
+		client, err := NewClient(key, "s3cr3t", opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.HTTPClient = &http.Client{Transport: transport}
+		resp, err := client.SMS().Send(msg, WithIdempotencyKey("order-42"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Duplicate {
+			t.Errorf("message of %s suppressed as a duplicate", key)
+		}
+	}
+	if sent != 2 {
+		t.Errorf("sent %d requests, want 2", sent)
+	}
+}
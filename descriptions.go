@@ -0,0 +1,36 @@
+package nexmo
+
+import "sync/atomic"
+
+// Descriptions holds alternate descriptions of the codes reported by Nexmo,
+// e.g. translated or company-specific wording for admin interfaces. They are
+// returned by the String methods of the code types once installed with
+// SetDescriptions. Codes missing from the maps keep their default
+// description.
+type Descriptions struct {
+	ResponseCodes  map[ResponseCode]string
+	VerifyStatuses map[VerifyStatus]string
+	DLRErrorCodes  map[DLRErrorCode]string
+}
+
+var descriptions atomic.Value // *Descriptions
+
+// SetDescriptions installs d as the descriptions used by the String methods
+// of ResponseCode, VerifyStatus and DLRErrorCode. Passing nil restores the
+// default descriptions. It is safe to call concurrently with String, but the
+// maps of d must not be modified afterwards.
+func SetDescriptions(d *Descriptions) {
+	if d == nil {
+		d = &Descriptions{}
+	}
+	descriptions.Store(d)
+}
+
+// currentDescriptions returns the descriptions installed with
+// SetDescriptions, or an empty Descriptions.
+func currentDescriptions() *Descriptions {
+	if d, ok := descriptions.Load().(*Descriptions); ok {
+		return d
+	}
+	return &Descriptions{}
+}
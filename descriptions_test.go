@@ -0,0 +1,32 @@
+package nexmo
+
+import "testing"
+
+func TestSetDescriptions(t *testing.T) {
+	SetDescriptions(&Descriptions{
+		ResponseCodes:  map[ResponseCode]string{ResponseThrottled: "Gedrosselt"},
+		VerifyStatuses: map[VerifyStatus]string{VerifySuccess: "Erfolgreich"},
+		DLRErrorCodes:  map[DLRErrorCode]string{DLRHandsetBusy: "Telefon besetzt"},
+	})
+	defer SetDescriptions(nil)
+
+	for _, test := range []struct {
+		got, want string
+	}{
+		{ResponseThrottled.String(), "Gedrosselt"},
+		{ResponseSuccess.String(), "Success"},
+		{VerifySuccess.String(), "Erfolgreich"},
+		{VerifyFailed.String(), "FAILED"},
+		{DLRHandsetBusy.String(), "Telefon besetzt"},
+		{DLRNetworkError.String(), "Network error"},
+	} {
+		if test.got != test.want {
+			t.Errorf("got %q, want %q", test.got, test.want)
+		}
+	}
+
+	SetDescriptions(nil)
+	if got := ResponseThrottled.String(); got != "Throttled" {
+		t.Errorf("got %q after restoring the defaults", got)
+	}
+}
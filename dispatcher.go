@@ -0,0 +1,177 @@
+package nexmo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// EventType identifies the kind of callback carried by an Event.
+type EventType int
+
+// Event types
+const (
+	EventInboundMessage EventType = iota + 1
+	EventDeliveryReceipt
+	EventVerify
+	EventVoice
+	EventMessageStatus
+)
+
+var eventTypeMap = map[EventType]string{
+	EventInboundMessage:  "inbound message",
+	EventDeliveryReceipt: "delivery receipt",
+	EventVerify:          "verify event",
+	EventVoice:           "voice event",
+	EventMessageStatus:   "message status",
+}
+
+func (t EventType) String() string {
+	if s, ok := eventTypeMap[t]; ok {
+		return s
+	}
+	return "undefined"
+}
+
+// ErrUnknownEvent is returned by ParseEvent when a callback does not look like
+// any of the callbacks it knows about.
+var ErrUnknownEvent = errors.New("unknown callback type")
+
+// Event is any callback sent by Nexmo. Exactly one of the pointers, the one
+// matching Type, is set.
+type Event struct {
+	Type EventType
+
+	Message       *ReceivedMessage
+	Receipt       *DeliveryReceipt
+	Verify        *VerifyEvent
+	Voice         *VoiceEvent
+	MessageStatus *MessageStatus
+}
+
+func (e *Event) setRaw(raw *RawPayload) {
+	switch {
+	case e.Message != nil:
+		e.Message.setRaw(raw)
+	case e.Receipt != nil:
+		e.Receipt.setRaw(raw)
+	}
+}
+
+// NewEventHandler creates a new http.HandlerFunc accepting every kind of
+// callback sent by Nexmo, for applications which use a single callback URL
+// for everything. Callbacks are decoded with ParseEvent and passed to the out
+// chan.
+func NewEventHandler(out chan *Event, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	// Pass it out on the chan
+	send := channelSender(out, newHandlerConfig(handlerName("event", opts)))
+	return NewEventHandlerFunc(send, verifyIPs, opts...)
+}
+
+// NewEventHandlerFunc is like NewEventHandler, but passes the events to fn.
+// If fn returns an error, Nexmo is answered with a 500, or with the status
+// code of a returned HandlerError.
+func NewEventHandlerFunc(fn func(*Event) error, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	return newWebhookHandler(parseEvent, fn, verifyIPs, handlerName("event", opts))
+}
+
+// ParseEvent identifies the kind of callback in req based on the fields it
+// contains and decodes it. Timestamps are parsed with DefaultTimestampParser.
+func ParseEvent(req *http.Request) (*Event, error) {
+	return parseEvent(req, DefaultTimestampParser)
+}
+
+func parseEvent(req *http.Request, tp *TimestampParser) (*Event, error) {
+	fields, err := payloadFields(req)
+	if err != nil {
+		return nil, err
+	}
+
+	e := new(Event)
+	switch {
+	case fields["message_uuid"]:
+		e.Type = EventMessageStatus
+		e.MessageStatus, err = ParseMessageStatus(req)
+	case fields["conversation_uuid"]:
+		e.Type = EventVoice
+		e.Voice, err = ParseVoiceEvent(req)
+	case fields["request_id"]:
+		e.Type = EventVerify
+		e.Verify, err = ParseVerifyEvent(req)
+	case isReceiptPayload(fields):
+		e.Type = EventDeliveryReceipt
+		e.Receipt, err = parseDeliveryReceipt(req, tp)
+	case fields["messageId"]:
+		e.Type = EventInboundMessage
+		e.Message, err = parseReceivedMessage(req, tp)
+	default:
+		return nil, ErrUnknownEvent
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// isReceiptPayload returns true if the callback with the given top level
+// fields is a delivery receipt.
+func isReceiptPayload[V any](fields map[string]V) bool {
+	_, id := fields["messageId"]
+	_, scts := fields["scts"]
+	return id && scts
+}
+
+// payloadFields returns the names of the top level fields of the callback in
+// req, leaving the request to be parsed again.
+func payloadFields(req *http.Request) (map[string]bool, error) {
+	values, err := payloadValues(req)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool, len(values))
+	for key := range values {
+		fields[key] = true
+	}
+	return fields, nil
+}
+
+// payloadValues returns the top level fields of the callback in req, leaving
+// the request to be parsed again. Fields of JSON callbacks which are neither
+// strings nor numbers have an empty value.
+func payloadValues(req *http.Request) (url.Values, error) {
+	payload, err := capturePayload(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isJSON(req) {
+		return url.ParseQuery(string(payload))
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return nil, err
+	}
+
+	values := make(url.Values, len(m))
+	for key, raw := range m {
+		var v interface{}
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		d.Decode(&v)
+
+		switch v := v.(type) {
+		case string:
+			values.Set(key, v)
+		case json.Number:
+			values.Set(key, v.String())
+		default:
+			values.Set(key, "")
+		}
+	}
+	return values, nil
+}
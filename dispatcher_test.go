@@ -0,0 +1,61 @@
+package nexmo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newJSONRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		req  *http.Request
+		want EventType
+	}{
+		{newFormRequest("GET", testInboundValues), EventInboundMessage},
+		{newFormRequest("POST", testInboundValues), EventInboundMessage},
+		{newFormRequest("POST", testReceiptValues), EventDeliveryReceipt},
+		{newJSONRequest(`{"request_id":"abcdef","type":"summary","status":"completed"}`), EventVerify},
+		{newJSONRequest(`{"uuid":"aaaa","conversation_uuid":"CON-bbbb","status":"ringing",` +
+			`"timestamp":"2018-08-06T12:00:00.000Z"}`), EventVoice},
+		{newJSONRequest(`{"message_uuid":"aaaa-bbbb","to":"447700900000","from":"gonexmo",` +
+			`"timestamp":"2018-08-06T12:00:00.000Z","status":"delivered"}`), EventMessageStatus},
+	}
+
+	for _, test := range tests {
+		e, err := ParseEvent(test.req)
+		if err != nil {
+			t.Errorf("%v: failed to parse: %v", test.want, err)
+			continue
+		}
+		if e.Type != test.want {
+			t.Errorf("got %v, want %v", e.Type, test.want)
+		}
+	}
+
+	if _, err := ParseEvent(newJSONRequest(`{"foo":"bar"}`)); err != ErrUnknownEvent {
+		t.Errorf("got error %v, want %v", err, ErrUnknownEvent)
+	}
+}
+
+func TestEventHandler(t *testing.T) {
+	out := make(chan *Event, 1)
+	h := NewEventHandler(out, false)
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", testReceiptValues))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	e := <-out
+	if e.Type != EventDeliveryReceipt || e.Receipt == nil || e.Receipt.Status != DeliveryDelivered {
+		t.Errorf("unexpected event %#v", e)
+	}
+}
@@ -0,0 +1,212 @@
+package nexmo
+
+import "fmt"
+
+// DeliveryStatus is the status reported in a DeliveryReceipt. It can be one
+// of the following:
+//   - DeliveryDelivered
+//   - DeliveryExpired
+//   - DeliveryFailed
+//   - DeliveryRejected
+//   - DeliveryAccepted
+//   - DeliveryBuffered
+//   - DeliveryUnknown
+type DeliveryStatus int
+
+// Delivery statuses
+const (
+	// The message was delivered to the handset.
+	DeliveryDelivered DeliveryStatus = iota + 1
+
+	// The message could not be delivered before its TTL ran out.
+	DeliveryExpired
+
+	// The message failed to be delivered.
+	DeliveryFailed
+
+	// The message was rejected, either by Nexmo or by the carrier.
+	DeliveryRejected
+
+	// The message was accepted by the carrier, but no final status is known.
+	DeliveryAccepted
+
+	// The message is queued by the carrier, e.g. because the handset is
+	// switched off.
+	DeliveryBuffered
+
+	// The carrier did not report a meaningful status.
+	DeliveryUnknown
+)
+
+var deliveryStatusMap = map[string]DeliveryStatus{
+	"delivered": DeliveryDelivered,
+	"expired":   DeliveryExpired,
+	"failed":    DeliveryFailed,
+	"rejected":  DeliveryRejected,
+	"accepted":  DeliveryAccepted,
+	"buffered":  DeliveryBuffered,
+	"unknown":   DeliveryUnknown,
+}
+
+var deliveryStatusIntMap = map[DeliveryStatus]string{
+	DeliveryDelivered: "delivered",
+	DeliveryExpired:   "expired",
+	DeliveryFailed:    "failed",
+	DeliveryRejected:  "rejected",
+	DeliveryAccepted:  "accepted",
+	DeliveryBuffered:  "buffered",
+	DeliveryUnknown:   "unknown",
+}
+
+// ParseDeliveryStatus converts the status string used by Nexmo into a
+// DeliveryStatus. Statuses this package does not know about are reported as
+// DeliveryUnknown.
+func ParseDeliveryStatus(s string) DeliveryStatus {
+	if status, ok := deliveryStatusMap[s]; ok {
+		return status
+	}
+	return DeliveryUnknown
+}
+
+func (s DeliveryStatus) String() string {
+	if str, ok := deliveryStatusIntMap[s]; ok {
+		return str
+	}
+	return "undefined"
+}
+
+// IsFinal returns true if the status will not change any more, i.e. the
+// message was either delivered or will never be.
+func (s DeliveryStatus) IsFinal() bool {
+	switch s {
+	case DeliveryDelivered, DeliveryExpired, DeliveryFailed, DeliveryRejected:
+		return true
+	}
+	return false
+}
+
+// IsDelivered returns true if the message reached the handset.
+func (s DeliveryStatus) IsDelivered() bool {
+	return s == DeliveryDelivered
+}
+
+// IsFailure returns true if the message will never be delivered.
+func (s DeliveryStatus) IsFailure() bool {
+	return s.IsFinal() && s != DeliveryDelivered
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so statuses are
+// serialized using the same strings as Nexmo does.
+func (s DeliveryStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (s *DeliveryStatus) UnmarshalText(text []byte) error {
+	*s = ParseDeliveryStatus(string(text))
+	return nil
+}
+
+// DLRErrorCode is the err-code reported in a DeliveryReceipt. It ranges from 0
+// (delivered) to 99 (general error).
+type DLRErrorCode int
+
+// Delivery receipt error codes
+const (
+	DLRDelivered                 DLRErrorCode = 0
+	DLRUnknown                   DLRErrorCode = 1
+	DLRAbsentSubscriberTemporary DLRErrorCode = 2
+	DLRAbsentSubscriberPermanent DLRErrorCode = 3
+	DLRCallBarred                DLRErrorCode = 4
+	DLRPortabilityError          DLRErrorCode = 5
+	DLRAntiSpamRejection         DLRErrorCode = 6
+	DLRHandsetBusy               DLRErrorCode = 7
+	DLRNetworkError              DLRErrorCode = 8
+	DLRIllegalNumber             DLRErrorCode = 9
+	DLRIllegalMessage            DLRErrorCode = 10
+	DLRUnroutable                DLRErrorCode = 11
+	DLRDestinationUnreachable    DLRErrorCode = 12
+	DLRAgeRestriction            DLRErrorCode = 13
+	DLRBlockedByCarrier          DLRErrorCode = 14
+	DLRInsufficientFunds         DLRErrorCode = 15
+	DLRGatewayQuotaExceeded      DLRErrorCode = 16
+	DLREntityFilter              DLRErrorCode = 50
+	DLRHeaderFilter              DLRErrorCode = 51
+	DLRContentFilter             DLRErrorCode = 52
+	DLRConsentFilter             DLRErrorCode = 53
+	DLRRegulationError           DLRErrorCode = 54
+	DLRGeneralError              DLRErrorCode = 99
+)
+
+var dlrErrorCodeMap = map[DLRErrorCode]string{
+	DLRDelivered:                 "Delivered",
+	DLRUnknown:                   "Unknown",
+	DLRAbsentSubscriberTemporary: "Absent subscriber - temporary",
+	DLRAbsentSubscriberPermanent: "Absent subscriber - permanent",
+	DLRCallBarred:                "Call barred by user",
+	DLRPortabilityError:          "Portability error",
+	DLRAntiSpamRejection:         "Anti-spam rejection",
+	DLRHandsetBusy:               "Handset busy",
+	DLRNetworkError:              "Network error",
+	DLRIllegalNumber:             "Illegal number",
+	DLRIllegalMessage:            "Illegal message",
+	DLRUnroutable:                "Unroutable",
+	DLRDestinationUnreachable:    "Destination unreachable",
+	DLRAgeRestriction:            "Subscriber age restriction",
+	DLRBlockedByCarrier:          "Number blocked by carrier",
+	DLRInsufficientFunds:         "Prepaid insufficient funds",
+	DLRGatewayQuotaExceeded:      "Gateway quota exceeded",
+	DLREntityFilter:              "Entity filter",
+	DLRHeaderFilter:              "Header filter",
+	DLRContentFilter:             "Content filter",
+	DLRConsentFilter:             "Consent filter",
+	DLRRegulationError:           "Regulation error",
+	DLRGeneralError:              "General error",
+}
+
+// String implements the fmt.Stringer interface. The description can be
+// replaced with SetDescriptions.
+func (c DLRErrorCode) String() string {
+	if str, ok := currentDescriptions().DLRErrorCodes[c]; ok {
+		return str
+	}
+	if str, ok := dlrErrorCodeMap[c]; ok {
+		return str
+	}
+	return fmt.Sprintf("Error code %d", int(c))
+}
+
+// IsSuccess returns true if the code reports a successful delivery.
+func (c DLRErrorCode) IsSuccess() bool {
+	return c == 0
+}
+
+// IsKnown returns true if the code is one documented by Nexmo.
+func (c DLRErrorCode) IsKnown() bool {
+	_, ok := dlrErrorCodeMap[c]
+	return ok
+}
+
+// IsTemporary returns true if the code reports a failure which may not occur
+// again, so sending the message again later can succeed, e.g. when the
+// handset was switched off or busy.
+//
+// DLRInsufficientFunds and DLRGeneralError are permanent: the former lasts
+// until the account is topped up, and the latter reports failures which
+// usually occur again. ResendPolicy.Include opts in to resending them.
+func (c DLRErrorCode) IsTemporary() bool {
+	switch c {
+	case DLRUnknown, DLRAbsentSubscriberTemporary, DLRHandsetBusy,
+		DLRNetworkError, DLRDestinationUnreachable, DLRGatewayQuotaExceeded:
+		return true
+	}
+	return false
+}
+
+// IsPermanent returns true if the code reports a failure which will occur
+// again if the message is resent unchanged, e.g. because the number does not
+// exist or the content was filtered. Unknown codes are neither temporary nor
+// permanent.
+func (c DLRErrorCode) IsPermanent() bool {
+	return c.IsKnown() && !c.IsSuccess() && !c.IsTemporary()
+}
@@ -0,0 +1,88 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var deliveryStatusTests = []struct {
+	in        string
+	want      DeliveryStatus
+	final     bool
+	delivered bool
+}{
+	{"delivered", DeliveryDelivered, true, true},
+	{"expired", DeliveryExpired, true, false},
+	{"failed", DeliveryFailed, true, false},
+	{"rejected", DeliveryRejected, true, false},
+	{"accepted", DeliveryAccepted, false, false},
+	{"buffered", DeliveryBuffered, false, false},
+	{"unknown", DeliveryUnknown, false, false},
+	{"something-new", DeliveryUnknown, false, false},
+}
+
+func TestParseDeliveryStatus(t *testing.T) {
+	for _, test := range deliveryStatusTests {
+		got := ParseDeliveryStatus(test.in)
+		if got != test.want {
+			t.Errorf("ParseDeliveryStatus(%q) = %v, want %v", test.in, got, test.want)
+		}
+		if got.IsFinal() != test.final {
+			t.Errorf("%v.IsFinal() = %v, want %v", got, got.IsFinal(), test.final)
+		}
+		if got.IsDelivered() != test.delivered {
+			t.Errorf("%v.IsDelivered() = %v, want %v", got, got.IsDelivered(), test.delivered)
+		}
+	}
+}
+
+func TestDeliveryStatusJSON(t *testing.T) {
+	b, err := json.Marshal(&DeliveryReceipt{Status: DeliveryExpired})
+	if err != nil {
+		t.Fatal("failed to marshal receipt:", err)
+	}
+
+	var r DeliveryReceipt
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatal("failed to unmarshal receipt:", err)
+	}
+	if r.Status != DeliveryExpired {
+		t.Errorf("got status %v after round trip, want %v", r.Status, DeliveryExpired)
+	}
+}
+
+func TestDLRErrorCodeString(t *testing.T) {
+	if s := DLRErrorCode(2).String(); s != "Absent subscriber - temporary" {
+		t.Errorf("DLRErrorCode(2).String() = %q", s)
+	}
+	if s := DLRErrorCode(42).String(); s != "Error code 42" {
+		t.Errorf("DLRErrorCode(42).String() = %q", s)
+	}
+	if !DLRErrorCode(0).IsSuccess() || DLRErrorCode(1).IsSuccess() {
+		t.Error("IsSuccess is only expected to be true for code 0")
+	}
+}
+
+func TestDLRErrorCodeClassification(t *testing.T) {
+	for _, test := range []struct {
+		code                 DLRErrorCode
+		temporary, permanent bool
+	}{
+		{DLRDelivered, false, false},
+		{DLRAbsentSubscriberTemporary, true, false},
+		{DLRHandsetBusy, true, false},
+		{DLRAbsentSubscriberPermanent, false, true},
+		{DLRIllegalNumber, false, true},
+		{DLRContentFilter, false, true},
+		{DLRInsufficientFunds, false, true},
+		{DLRGeneralError, false, true},
+		{42, false, false},
+	} {
+		if got := test.code.IsTemporary(); got != test.temporary {
+			t.Errorf("%v.IsTemporary() = %v, want %v", test.code, got, test.temporary)
+		}
+		if got := test.code.IsPermanent(); got != test.permanent {
+			t.Errorf("%v.IsPermanent() = %v, want %v", test.code, got, test.permanent)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package nexmo
+
+import "fmt"
+
+// dryRunPrefix starts the message IDs of the responses made up by a client
+// created with WithDryRun.
+const dryRunPrefix = "DRYRUN-"
+
+// WithDryRun makes the client build, validate and encode SMS messages as
+// usual, but not send them: SMS.Send returns a made up response instead,
+// reporting every part of the message as accepted, with DryRun set and the
+// message IDs starting with "DRYRUN-". Other requests are sent as usual.
+//
+// This lets integration tests and CI pipelines exercise the send path
+// without spending credit. To fake the API at the HTTP level, and get
+// delivery receipts, use nexmotest.SMSC instead.
+func WithDryRun() ClientOption {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// dryRunResponse makes up the response to m sent by a client created with
+// WithDryRun.
+func dryRunResponse(m *SMSMessage) *MessageResponse {
+	parts := 1
+	if m.Type == Text || m.Type == Unicode || m.Type == "" {
+		if n := CountSegments(m.Text).Segments; n > 1 {
+			parts = n
+		}
+	}
+
+	resp := &MessageResponse{
+		MessageCount:    parts,
+		Messages:        make([]MessageReport, parts),
+		ClientReference: m.ClientReference,
+		DryRun:          true,
+	}
+	id := newClientReference()
+	for i := range resp.Messages {
+		resp.Messages[i] = MessageReport{
+			Status:          ResponseSuccess,
+			MessageID:       fmt.Sprintf("%s%s-%d", dryRunPrefix, id, i+1),
+			To:              m.To,
+			ClientReference: m.ClientReference,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,41 @@
+package nexmo
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("request sent to %s", req.URL)
+		return nil, nil
+	})}
+
+	msg := &SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: strings.Repeat("a", 200), ClientReference: "order-42"}
+	resp, err := client.SMS().Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.DryRun || resp.MessageCount != 2 || len(resp.Messages) != 2 {
+		t.Fatalf("got response %+v", resp)
+	}
+	for _, report := range resp.Messages {
+		if report.Status != ResponseSuccess || !strings.HasPrefix(report.MessageID, "DRYRUN-") ||
+			report.To != msg.To || report.ClientReference != "order-42" {
+			t.Errorf("got report %+v", report)
+		}
+	}
+	if resp.Messages[0].MessageID == resp.Messages[1].MessageID {
+		t.Error("parts have the same message ID")
+	}
+
+	// Messages are still validated.
+	if _, err := client.SMS().Send(&SMSMessage{To: "447700900000", Type: Text, Text: "Hello"}); err == nil {
+		t.Error("invalid message accepted")
+	}
+}
@@ -0,0 +1,98 @@
+package nexmo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+// maskedSecret replaces the values of secrets in dumps, logs and errors.
+const maskedSecret = "********"
+
+// DumpRequest renders the HTTP request the client would send to Nexmo for v,
+// without sending it, so the construction of messages can be checked against
+// golden files. The api_key, api_secret and sig parameters, and the
+// Authorization header, are masked. v can be any of:
+//   - *SMSMessage
+//   - *USSDMessage
+//   - *VerifyMessageRequest
+//   - *VerifyCheckRequest
+//   - *VerifySearchRequest
+//   - *VerifyControlRequest
+//   - nil, for the account balance request
+//
+// The output is stable: headers are sorted, and the parameters of the body are
+// always in the same order.
+func (c *Client) DumpRequest(v interface{}) ([]byte, error) {
+	c.Init()
+
+	var r *http.Request
+	var err error
+	switch v := v.(type) {
+	case *SMSMessage:
+		r, err = c.SMS().newRequest(v, nil)
+	case *USSDMessage:
+		r, err = c.USSD().newRequest(v)
+	case *VerifyMessageRequest:
+		r, err = c.Verify().newSendRequest(v)
+	case *VerifyCheckRequest:
+		r, err = c.Verify().newCheckRequest(v)
+	case *VerifySearchRequest:
+		r, err = c.Verify().newSearchRequest(v)
+	case *VerifyControlRequest:
+		r, err = c.Verify().newControlRequest(v)
+	case nil:
+		r, err = c.Account().newBalanceRequest()
+	default:
+		return nil, fmt.Errorf("nexmo: can not dump a request for %T", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n", r.Method, r.URL)
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			if name == "Authorization" {
+				value = maskedSecret
+			}
+			fmt.Fprintf(&buf, "%s: %s\n", name, value)
+		}
+	}
+
+	if r.Body != nil {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		buf.Write(body)
+		buf.WriteString("\n")
+	}
+
+	return maskSecrets(buf.Bytes()), nil
+}
+
+// Values of the secretParams in query strings and form bodies, and in JSON
+// bodies.
+var (
+	secretFormParam = regexp.MustCompile(`((?:^|[?&\s"])(?:api_key|api_secret|sig)=)[^&\s"]*`)
+	secretJSONParam = regexp.MustCompile(`("(?:api_key|api_secret|sig)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// maskSecrets masks the values of the secretParams in b, a URL, a request
+// body or a text containing them. The rest of b is left untouched.
+func maskSecrets(b []byte) []byte {
+	b = secretFormParam.ReplaceAll(b, []byte("${1}"+maskedSecret))
+	return secretJSONParam.ReplaceAll(b, []byte(`${1}"`+maskedSecret+`"`))
+}
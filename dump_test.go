@@ -0,0 +1,114 @@
+package nexmo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDumpRequest(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		v    interface{}
+		want string
+	}{
+		{
+			&USSDMessage{From: "gonexmo", To: "447700900000", Text: "Hello", Prompt: true},
+			"POST https://rest.nexmo.com/ussd-prompt/json\n" +
+				"Accept: application/json\n" +
+				"Content-Type: application/x-www-form-urlencoded\n" +
+				"\n" +
+				"api_key=********&api_secret=********&from=gonexmo&text=Hello&to=447700900000\n",
+		},
+		{
+			nil,
+			"GET https://rest.nexmo.com/account/get-balance?api_key=********&api_secret=********\n" +
+				"Accept: application/json\n",
+		},
+	} {
+		got, err := client.DumpRequest(test.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("DumpRequest(%T):\ngot:\n%s\nwant:\n%s", test.v, got, test.want)
+		}
+	}
+
+	// Dumps are stable.
+	msg := &SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: "Hello"}
+	first, err := client.DumpRequest(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if again, _ := client.DumpRequest(msg); string(again) != string(first) {
+			t.Fatalf("dump changed from\n%s\nto\n%s", first, again)
+		}
+	}
+
+	if _, err := client.DumpRequest(&SMSMessage{}); err == nil {
+		t.Error("invalid message was dumped")
+	}
+}
+
+func TestDumpRequestMasksByName(t *testing.T) {
+	// The key is part of the recipient, which must not be masked.
+	client, err := NewClient("447", "s3cr3t", WithEncoding(EndpointUSSD, EncodingJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.DumpRequest(&USSDMessage{From: "gonexmo", To: "447700900000", Text: "s3cr3t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"api_key":"********","api_secret":"********","from":"gonexmo","text":"s3cr3t","to":"447700900000"}`
+	if !strings.Contains(string(got), want) {
+		t.Errorf("got dump\n%s\nwant body %s", got, want)
+	}
+
+	client, err = NewClient("", "", WithAuth(&SignatureAuth{APIKey: "447", Secret: "s1gn", Method: SignatureMD5Hash}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = client.DumpRequest(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := strings.SplitN(string(got), "\n", 2)[0]; !strings.Contains(line, "?api_key=********&sig=********&timestamp=") {
+		t.Errorf("got request line %q", line)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	client, err := NewClient("key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		v    interface{}
+		want *ValidationError
+	}{
+		{&SMSMessage{To: "447700900000"}, ErrMissingFrom},
+		{&SMSMessage{From: "gonexmo"}, ErrMissingTo},
+		{&SMSMessage{From: "gonexmo", To: "447700900000", ClientReference: strings.Repeat("x", 41)}, ErrClientRefTooLong},
+		{&SMSMessage{From: "gonexmo", To: "447700900000", Type: Binary, Body: []byte{1}}, ErrInvalidBinary},
+		{&SMSMessage{From: "gonexmo", To: "447700900000", Type: WAPPush, URL: "https://example.com", Title: "Offer", Validity: 3600}, ErrInvalidValidity},
+		{&USSDMessage{From: "gonexmo", To: "447700900000"}, ErrMissingText},
+		{&VerifyMessageRequest{Brand: "gonexmo"}, ErrMissingNumber},
+		{&VerifyCheckRequest{RequestID: "abc"}, ErrMissingCode},
+		{&VerifyControlRequest{RequestID: "abc"}, ErrMissingCommand},
+	} {
+		_, err := client.DumpRequest(test.v)
+		if err != test.want {
+			t.Errorf("%T: got error %v, want %v", test.v, err, test.want)
+		}
+		if err != nil && err.Error() == "" {
+			t.Errorf("%T: empty error message", test.v)
+		}
+	}
+}
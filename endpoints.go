@@ -0,0 +1,192 @@
+package nexmo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// Encoding is the way the parameters of a request are sent to an endpoint.
+type Encoding int
+
+// Request encodings
+const (
+	EncodingJSON Encoding = iota + 1 // JSON body
+	EncodingForm                     // application/x-www-form-urlencoded body
+)
+
+// Endpoint identifies an API endpoint accepting both encodings.
+type Endpoint string
+
+// Endpoints whose encoding can be selected with WithEncoding.
+const (
+	EndpointSMS           Endpoint = "sms"
+	EndpointUSSD          Endpoint = "ussd"
+	EndpointUSSDPrompt    Endpoint = "ussd-prompt"
+	EndpointVerify        Endpoint = "verify"
+	EndpointVerifyCheck   Endpoint = "verify-check"
+	EndpointVerifySearch  Endpoint = "verify-search"
+	EndpointVerifyControl Endpoint = "verify-control"
+
+	EndpointInsightBasic    Endpoint = "insight-basic"
+	EndpointInsightStandard Endpoint = "insight-standard"
+	EndpointInsightAdvanced Endpoint = "insight-advanced"
+)
+
+type endpointInfo struct {
+	url      string
+	encoding Encoding // Used unless overridden with WithEncoding.
+}
+
+var endpoints = map[Endpoint]endpointInfo{
+	EndpointSMS:           {apiRoot + "/sms/json", EncodingJSON},
+	EndpointUSSD:          {apiRoot + "/ussd/json", EncodingForm},
+	EndpointUSSDPrompt:    {apiRoot + "/ussd-prompt/json", EncodingForm},
+	EndpointVerify:        {apiRootv2 + "/verify/json", EncodingJSON},
+	EndpointVerifyCheck:   {apiRootv2 + "/verify/check/json", EncodingJSON},
+	EndpointVerifySearch:  {apiRootv2 + "/verify/search/json", EncodingJSON},
+	EndpointVerifyControl: {apiRootv2 + "/verify/control/json", EncodingJSON},
+
+	EndpointInsightBasic:    {apiRootv2 + "/ni/basic/json", EncodingForm},
+	EndpointInsightStandard: {apiRootv2 + "/ni/standard/json", EncodingForm},
+	EndpointInsightAdvanced: {apiRootv2 + "/ni/advanced/json", EncodingForm},
+}
+
+// WithEncoding makes the client send requests to endpoint with enc instead of
+// the default encoding of the endpoint.
+func WithEncoding(endpoint Endpoint, enc Encoding) ClientOption {
+	return func(c *Client) {
+		if c.encodings == nil {
+			c.encodings = make(map[Endpoint]Encoding)
+		}
+		c.encodings[endpoint] = enc
+	}
+}
+
+// newRequest creates a request posting v, along with the API credentials, to
+// endpoint, with the encoding configured for it. v is either url.Values or a
+// value marshaled to JSON.
+func (c *Client) newRequest(endpoint Endpoint, v interface{}) (*http.Request, error) {
+	return c.newRequestAs(endpoint, v, nil)
+}
+
+// newRequestAs is like newRequest, but sends creds instead of the credentials
+// of c if not nil.
+func (c *Client) newRequestAs(endpoint Endpoint, v interface{}, creds *Credentials) (*http.Request, error) {
+	info, ok := endpoints[endpoint]
+	if !ok {
+		return nil, fmt.Errorf("unknown endpoint %q", endpoint)
+	}
+	enc := info.encoding
+	if e, ok := c.encodings[endpoint]; ok {
+		enc = e
+	}
+	if _, ok := c.auth(creds).(*SignatureAuth); ok {
+		// The signature covers the form parameters.
+		enc = EncodingForm
+	}
+
+	if enc == EncodingForm {
+		values, err := formValues(v)
+		if err != nil {
+			return nil, err
+		}
+		return c.newFormRequest(info.url, values, creds)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	header := make(http.Header)
+	if err := c.encodeJSON(buf, v, header, creds); err != nil {
+		return nil, err
+	}
+	r, err := c.newJSONRequest(info.url, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	copyHeader(r.Header, header)
+	return r, nil
+}
+
+// encodeJSON writes v, along with the credentials added by the Auth of
+// override, as a JSON object to buf, and adds the credentials sent as headers
+// to h. v itself is left untouched.
+func (c *Client) encodeJSON(buf *bytes.Buffer, v interface{}, h http.Header, override *Credentials) error {
+	if values, ok := v.(url.Values); ok {
+		params := make(map[string]string, len(values))
+		for key := range values {
+			params[key] = values.Get(key)
+		}
+		v = params
+	}
+
+	fields := getBuffer()
+	defer putBuffer(fields)
+	if err := json.NewEncoder(fields).Encode(v); err != nil {
+		return fmt.Errorf("invalid message struct - unable to convert to JSON: %v", err)
+	}
+	obj := bytes.TrimSpace(fields.Bytes())
+	if len(obj) < 2 || obj[0] != '{' {
+		return fmt.Errorf("invalid message struct - %T is not a JSON object", v)
+	}
+	obj = obj[1 : len(obj)-1]
+
+	params := make(url.Values)
+	if err := c.auth(override).Authenticate(h, params); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for _, key := range keys {
+		k, _ := json.Marshal(key)
+		value, _ := json.Marshal(params.Get(key))
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(value)
+		buf.WriteByte(',')
+	}
+	if len(obj) == 0 && len(params) > 0 {
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.Write(obj)
+	buf.WriteByte('}')
+	return nil
+}
+
+// formValues converts v to form values. Values other than url.Values are
+// marshaled to a JSON object, the non-empty fields of which are used.
+func formValues(v interface{}) (url.Values, error) {
+	if values, ok := v.(url.Values); ok {
+		return values, nil
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message struct - unable to convert to JSON: %v", err)
+	}
+
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	values := make(url.Values, len(fields))
+	for key, field := range fields {
+		if field == nil || field == "" {
+			continue
+		}
+		values.Set(key, fmt.Sprint(field))
+	}
+	return values, nil
+}
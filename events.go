@@ -0,0 +1,296 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VerifyEvent is a status callback sent by the Verify API while a verification
+// progresses through its workflow.
+type VerifyEvent struct {
+	RequestID string `json:"request_id"`
+
+	// Either "event", for updates on a single channel, or "summary", sent when
+	// the verification is finalized.
+	Type string `json:"type"`
+
+	// Channel the event applies to, e.g. "sms" or "voice". Empty for summaries.
+	Channel string `json:"channel,omitempty"`
+
+	// e.g. "completed", "failed", "expired", "user_rejected".
+	Status string `json:"status"`
+
+	ClientReference string     `json:"client_ref,omitempty"`
+	TriggeredAt     *time.Time `json:"triggered_at,omitempty"`
+	SubmittedAt     *time.Time `json:"submitted_at,omitempty"`
+	FinalizedAt     *time.Time `json:"finalized_at,omitempty"`
+	Price           string     `json:"price,omitempty"`
+}
+
+// ParseVerifyEvent decodes a Verify API status callback, which is always sent
+// as a JSON POST body.
+func ParseVerifyEvent(req *http.Request) (*VerifyEvent, error) {
+	m := new(VerifyEvent)
+	if err := json.NewDecoder(req.Body).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VoiceEvent is a call lifecycle event sent by the Voice API to the event URL
+// of an application.
+type VoiceEvent struct {
+	UUID             string    `json:"uuid"`
+	ConversationUUID string    `json:"conversation_uuid"`
+	From             string    `json:"from"`
+	To               string    `json:"to"`
+	Direction        string    `json:"direction"`
+	Timestamp        time.Time `json:"timestamp"`
+
+	// e.g. "started", "ringing", "answered", "busy", "completed".
+	Status string `json:"status"`
+
+	// Only set once the call is completed.
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+	Rate      string `json:"rate,omitempty"`
+	Price     string `json:"price,omitempty"`
+	Network   string `json:"network,omitempty"`
+}
+
+// ParseVoiceEvent decodes a Voice API event callback. Events are sent as a
+// JSON POST body by default, or in the query string if the application is
+// configured to use GET.
+func ParseVoiceEvent(req *http.Request) (*VoiceEvent, error) {
+	m := new(VoiceEvent)
+	if err := decodeJSONOrForm(req, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AnswerRequest is sent by the Voice API to the answer URL of an application
+// when a call is answered, to retrieve the NCCO controlling the call.
+type AnswerRequest struct {
+	To               string `json:"to"`
+	From             string `json:"from"`
+	UUID             string `json:"uuid"`
+	ConversationUUID string `json:"conversation_uuid"`
+
+	ncco NCCO
+}
+
+func (a *AnswerRequest) respond(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.ncco)
+}
+
+// ParseAnswerRequest decodes a Voice API answer request. Requests are sent
+// with GET and the parameters in the query string by default, or as a JSON
+// POST body if the application is configured to use POST.
+func ParseAnswerRequest(req *http.Request) (*AnswerRequest, error) {
+	a := new(AnswerRequest)
+	if err := decodeJSONOrForm(req, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// NCCO is a Nexmo Call Control Object, the list of actions returned from an
+// answer URL to control a call.
+type NCCO []NCCOAction
+
+// NCCOAction is a single action of an NCCO, e.g.
+//
+//	nexmo.NCCOAction{"action": "talk", "text": "Hello world"}
+type NCCOAction map[string]interface{}
+
+// decodeJSONOrForm decodes the JSON body of req into v or, if the body is not
+// JSON, the form values of req, mapped onto the json tags of v.
+func decodeJSONOrForm(req *http.Request, v interface{}) error {
+	if isJSON(req) {
+		return json.NewDecoder(req.Body).Decode(v)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	values := make(map[string]string, len(req.Form))
+	for key := range req.Form {
+		values[key] = req.Form.Get(key)
+	}
+
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// MessageState is the state reported in a MessageStatus. It can be one of
+// the following:
+//   - MessageSubmitted
+//   - MessageDelivered
+//   - MessageRead
+//   - MessageRejected
+//   - MessageUndeliverable
+//   - MessageFailed
+type MessageState int
+
+// Message states
+const (
+	MessageSubmitted MessageState = iota + 1
+	MessageDelivered
+	MessageRead
+	MessageRejected
+	MessageUndeliverable
+	MessageFailed
+)
+
+var messageStateMap = map[string]MessageState{
+	"submitted":     MessageSubmitted,
+	"delivered":     MessageDelivered,
+	"read":          MessageRead,
+	"rejected":      MessageRejected,
+	"undeliverable": MessageUndeliverable,
+	"failed":        MessageFailed,
+}
+
+var messageStateIntMap = map[MessageState]string{
+	MessageSubmitted:     "submitted",
+	MessageDelivered:     "delivered",
+	MessageRead:          "read",
+	MessageRejected:      "rejected",
+	MessageUndeliverable: "undeliverable",
+	MessageFailed:        "failed",
+}
+
+func (s MessageState) String() string {
+	if str, ok := messageStateIntMap[s]; ok {
+		return str
+	}
+	return "undefined"
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (s MessageState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Unknown
+// states are decoded as 0.
+func (s *MessageState) UnmarshalText(text []byte) error {
+	*s = messageStateMap[string(text)]
+	return nil
+}
+
+// DeliveryStatus maps s onto the statuses used in SMS delivery receipts, so
+// that both kinds of callbacks can be processed the same way.
+func (s MessageState) DeliveryStatus() DeliveryStatus {
+	switch s {
+	case MessageSubmitted:
+		return DeliveryAccepted
+	case MessageDelivered, MessageRead:
+		return DeliveryDelivered
+	case MessageRejected:
+		return DeliveryRejected
+	case MessageUndeliverable, MessageFailed:
+		return DeliveryFailed
+	}
+	return DeliveryUnknown
+}
+
+// MessageError describes why a message sent through the Messages API failed.
+type MessageError struct {
+	// URL of the documentation of the error.
+	Type string `json:"type"`
+
+	// Numeric error code, sent by Nexmo as the title of the error.
+	Code int `json:"title"`
+
+	Detail   string `json:"detail"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The error code is
+// accepted both as a number and as a string.
+func (e *MessageError) UnmarshalJSON(b []byte) error {
+	type messageError MessageError
+	var v struct {
+		messageError
+		Code json.RawMessage `json:"title"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*e = MessageError(v.messageError)
+	code := strings.Trim(string(v.Code), `"`)
+	if code != "" && code != "null" {
+		n, err := strconv.Atoi(code)
+		if err != nil {
+			return err
+		}
+		e.Code = n
+	}
+	return nil
+}
+
+func (e *MessageError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Detail)
+}
+
+// MessageUsage is the cost of a message sent through the Messages API.
+type MessageUsage struct {
+	Currency string `json:"currency"`
+	Price    string `json:"price"`
+}
+
+// MessageStatus is a status callback sent by the Messages API for a message
+// sent through it.
+type MessageStatus struct {
+	MessageUUID     string        `json:"message_uuid"`
+	To              string        `json:"to"`
+	From            string        `json:"from"`
+	Timestamp       time.Time     `json:"timestamp"`
+	Status          MessageState  `json:"status"`
+	Channel         string        `json:"channel,omitempty"`
+	ClientReference string        `json:"client_ref,omitempty"`
+	Error           *MessageError `json:"error,omitempty"`
+	Usage           *MessageUsage `json:"usage,omitempty"`
+}
+
+// Receipt returns s as the delivery receipt of a message whose ID is the
+// message UUID, so that statuses can be saved and correlated along with SMS
+// delivery receipts, e.g. by a Store or a DeliveryTracker. The error code of
+// the receipt is left unset, as Messages API errors use other codes.
+func (s *MessageStatus) Receipt() *DeliveryReceipt {
+	r := &DeliveryReceipt{
+		To:              s.From,
+		MSISDN:          s.To,
+		MessageID:       s.MessageUUID,
+		Status:          s.Status.DeliveryStatus(),
+		Timestamp:       s.Timestamp,
+		ClientReference: s.ClientReference,
+	}
+	if s.Usage != nil {
+		r.Price = s.Usage.Price
+	}
+	return r
+}
+
+// ParseMessageStatus decodes a Messages API status callback, which is always
+// sent as a JSON POST body.
+func ParseMessageStatus(req *http.Request) (*MessageStatus, error) {
+	m := new(MessageStatus)
+	if err := json.NewDecoder(req.Body).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
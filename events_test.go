@@ -0,0 +1,53 @@
+package nexmo
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMessageStatusHandler(t *testing.T) {
+	out := make(chan *MessageStatus, 1)
+	h := NewMessageStatusHandler(out, false)
+
+	w := httptest.NewRecorder()
+	h(w, newJSONRequest(`{
+		"message_uuid": "aaaaaaaa-bbbb-cccc-dddd-0123456789ab",
+		"to": "447700900000",
+		"from": "447700900001",
+		"timestamp": "2018-08-06T12:00:00.000Z",
+		"status": "rejected",
+		"channel": "whatsapp",
+		"error": {
+			"type": "https://developer.nexmo.com/api-errors/messages-olympus#1000",
+			"title": 1000,
+			"detail": "Throttled",
+			"instance": "bf0ca0bf927b3b52e3cb03217e1a1ddf"
+		},
+		"usage": {"currency": "EUR", "price": "0.0333"}
+	}`))
+	if w.Code != 200 {
+		t.Fatalf("got status %d, want 200", w.Code)
+	}
+
+	s := <-out
+	if s.Status != MessageRejected || s.Status.DeliveryStatus() != DeliveryRejected {
+		t.Errorf("got status %v, want %v", s.Status, MessageRejected)
+	}
+	if s.Error == nil || s.Error.Code != 1000 || s.Error.Detail != "Throttled" {
+		t.Errorf("unexpected error %#v", s.Error)
+	}
+	if s.Usage == nil || s.Usage.Price != "0.0333" {
+		t.Errorf("unexpected usage %#v", s.Usage)
+	}
+}
+
+func TestMessageErrorStringCode(t *testing.T) {
+	s, err := ParseMessageStatus(newJSONRequest(
+		`{"message_uuid":"a","status":"undeliverable","error":{"title":"1320","detail":"Message already read"}}`))
+	if err != nil {
+		t.Fatal("failed to parse status:", err)
+	}
+	if s.Error.Code != 1320 || s.Status != MessageUndeliverable {
+		t.Errorf("unexpected status %#v", s)
+	}
+}
@@ -0,0 +1,99 @@
+package nexmo
+
+import (
+	"expvar"
+	"time"
+)
+
+// WithExpvar makes the client publish basic counters as an expvar.Map with
+// the given name, next to any MetricsCollector it already has:
+//   - sends: requests sent to the API;
+//   - errors: requests which failed, plus statuses other than ResponseSuccess
+//     reported in responses;
+//   - retries: requests sent again after being throttled;
+//   - messages, parts: SMS messages, and their parts, accepted by Nexmo;
+//   - balance: the balance last retrieved by Account.GetBalance.
+//
+// Clients given the same name share the map.
+func WithExpvar(name string) ClientOption {
+	return func(c *Client) {
+		m, ok := expvar.Get(name).(*expvar.Map)
+		if !ok {
+			m = expvar.NewMap(name)
+		}
+
+		var e MetricsCollector = &expvarMetrics{m}
+		if c.Metrics != nil {
+			e = metricsTee{c.Metrics, e}
+		}
+		c.Metrics = e
+	}
+}
+
+// expvarMetrics implements the counters published by WithExpvar.
+type expvarMetrics struct {
+	m *expvar.Map
+}
+
+func (e *expvarMetrics) RequestDone(endpoint string, statusCode int, d time.Duration) {
+	e.m.Add("sends", 1)
+	if statusCode == 0 || statusCode >= 400 {
+		e.m.Add("errors", 1)
+	}
+}
+
+func (e *expvarMetrics) RequestRetried(endpoint string) {
+	e.m.Add("retries", 1)
+}
+
+func (e *expvarMetrics) ResponseCode(endpoint string, code ResponseCode) {
+	if code != ResponseSuccess {
+		e.m.Add("errors", 1)
+	}
+}
+
+func (e *expvarMetrics) MessageSent(parts int) {
+	e.m.Add("messages", 1)
+	e.m.Add("parts", int64(parts))
+}
+
+func (e *expvarMetrics) Balance(euros float64) {
+	balance := new(expvar.Float)
+	balance.Set(euros)
+	e.m.Set("balance", balance)
+}
+
+// metricsTee reports to several MetricsCollectors.
+type metricsTee []MetricsCollector
+
+func (t metricsTee) RequestDone(endpoint string, statusCode int, d time.Duration) {
+	for _, m := range t {
+		m.RequestDone(endpoint, statusCode, d)
+	}
+}
+
+func (t metricsTee) RequestRetried(endpoint string) {
+	for _, m := range t {
+		m.RequestRetried(endpoint)
+	}
+}
+
+func (t metricsTee) ResponseCode(endpoint string, code ResponseCode) {
+	for _, m := range t {
+		m.ResponseCode(endpoint, code)
+	}
+}
+
+func (t metricsTee) MessageSent(parts int) {
+	for _, m := range t {
+		if s, ok := m.(SentMessageCollector); ok {
+			s.MessageSent(parts)
+		}
+	}
+}
+
+func (t metricsTee) Balance(euros float64) {
+	for _, m := range t {
+		m.Balance(euros)
+	}
+}
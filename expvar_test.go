@@ -0,0 +1,59 @@
+package nexmo
+
+import (
+	"expvar"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExpvar(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithExpvar("nexmo_test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.MaxRetries = 1
+	client.Clock = &instantClock{}
+
+	var requests int
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if requests == 1 {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		}
+		body := `{"message-count":"2","messages":[{"status":"0"},{"status":"1"}]}`
+		if strings.Contains(req.URL.Path, "balance") {
+			body = `{"value":3.5}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: "Hello"}
+	if _, err := client.SMS().Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Account().GetBalance(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := expvar.Get("nexmo_test").(*expvar.Map)
+	for name, want := range map[string]string{
+		"sends":    "2",
+		"errors":   "1",
+		"retries":  "1",
+		"messages": "1",
+		"parts":    "1",
+		"balance":  "3.5",
+	} {
+		if got := m.Get(name).String(); got != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+}
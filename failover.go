@@ -0,0 +1,122 @@
+package nexmo
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Number of consecutive failures after which a host is considered down, and
+// how long it is then avoided.
+const (
+	failoverThreshold = 3
+	failoverCooldown  = 30 * time.Second
+)
+
+// WithFailover makes the client send the requests meant for primary, e.g.
+// "rest.nexmo.com", to the first of primary and fallbacks, e.g.
+// "api-eu.vonage.com", which is up. A host is considered down after three
+// consecutive connection errors or 5xx responses, and is tried again 30
+// seconds later, so the client fails back to primary once it recovers.
+//
+// A request which fails on one host is resent to the next one, so a
+// message may be submitted twice if the failure occurred after Nexmo
+// received it. Use a RetryBudget to limit the extra requests.
+func WithFailover(primary string, fallbacks ...string) ClientOption {
+	return func(c *Client) {
+		if c.failovers == nil {
+			c.failovers = make(map[string]*failover)
+		}
+		hosts := append([]string{primary}, fallbacks...)
+		c.failovers[primary] = &failover{
+			hosts:     hosts,
+			failures:  make([]int, len(hosts)),
+			downUntil: make([]time.Time, len(hosts)),
+		}
+	}
+}
+
+// failover tracks the health of the hosts requests for a host can be sent to.
+type failover struct {
+	hosts []string
+
+	mu        sync.Mutex
+	failures  []int       // Consecutive failures of each host.
+	downUntil []time.Time // When to try each host again.
+}
+
+// pick returns the index of the first host not in tried which is up, or of
+// the first one not in tried if they are all down. It returns -1 once all
+// hosts have been tried.
+func (f *failover) pick(now time.Time, tried []bool) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	next := -1
+	for i := range f.hosts {
+		if tried[i] {
+			continue
+		}
+		if !now.Before(f.downUntil[i]) {
+			return i
+		}
+		if next < 0 {
+			next = i
+		}
+	}
+	return next
+}
+
+// report records the outcome of a request sent to host i.
+func (f *failover) report(i int, ok bool, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ok {
+		f.failures[i] = 0
+		f.downUntil[i] = time.Time{}
+		return
+	}
+	f.failures[i]++
+	if f.failures[i] >= failoverThreshold {
+		f.downUntil[i] = now.Add(failoverCooldown)
+	}
+}
+
+// sendFailover sends r like sendHTTP, to a healthy host if failover is
+// configured for the host of r.
+func (c *Client) sendFailover(r *http.Request, endpoint string) (*http.Response, error) {
+	f := c.failovers[r.URL.Host]
+	if f == nil {
+		return c.sendHTTP(r, endpoint)
+	}
+
+	clock := clockOrSystem(c.Clock)
+	tried := make([]bool, len(f.hosts))
+	for {
+		i := f.pick(clock.Now(), tried)
+		tried[i] = true
+
+		req := r.Clone(r.Context())
+		req.URL.Host = f.hosts[i]
+		req.Host = ""
+		resp, err := c.sendHTTP(req, endpoint)
+		ok := err == nil && resp.StatusCode < 500
+		f.report(i, ok, clock.Now())
+
+		if ok || f.pick(clock.Now(), tried) < 0 || (r.Body != nil && r.GetBody == nil) ||
+			r.Context().Err() != nil || !c.allowExtraRequest() {
+			return resp, err
+		}
+		if r.GetBody != nil {
+			body, bodyErr := r.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			r.Body = body
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package nexmo
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithFailover("rest.nexmo.com", "api-eu.vonage.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	client.Clock = clock
+
+	primaryUp := false
+	var hosts []string
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		if req.URL.Host == "rest.nexmo.com" && !primaryUp {
+			return nil, errors.New("connection refused")
+		}
+		b, _ := ioutil.ReadAll(req.Body)
+		if !strings.Contains(string(b), `"text":"Hello"`) {
+			t.Errorf("got request body %s", b)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0","message-id":"0A0000000123ABCD1"}]}`)),
+		}, nil
+	})}
+
+	send := func() []string {
+		t.Helper()
+		hosts = nil
+		if _, err := client.SMS().Send(&SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}); err != nil {
+			t.Fatal(err)
+		}
+		return hosts
+	}
+
+	for i := 0; i < failoverThreshold; i++ {
+		if got := send(); len(got) != 2 || got[1] != "api-eu.vonage.com" {
+			t.Fatalf("request %d went to %q", i, got)
+		}
+	}
+	if got := send(); len(got) != 1 || got[0] != "api-eu.vonage.com" {
+		t.Errorf("request with the primary host down went to %q", got)
+	}
+
+	primaryUp = true
+	clock.now = clock.now.Add(failoverCooldown)
+	if got := send(); len(got) != 1 || got[0] != "rest.nexmo.com" {
+		t.Errorf("request after the cooldown went to %q", got)
+	}
+}
+
+func TestFailoverWithoutBody(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithFailover("rest.nexmo.com", "api-eu.vonage.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hosts []string
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		if req.URL.Host == "rest.nexmo.com" {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"value":10.0}`)),
+		}, nil
+	})}
+
+	if _, err := client.Account().GetBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 || hosts[1] != "api-eu.vonage.com" {
+		t.Errorf("balance request went to %q", hosts)
+	}
+}
@@ -3,10 +3,14 @@ Package nexmo implements a simple client library for accessing the Nexmo API.
 
 Usage is simple. Create a nexmo.Client instance with NewClientFromAPI(),
 provide your API key and API secret. Compose a new Message and then call
-Client.SMS.Send(Message). The API will return a MessageResponse which you can
+Client.SMS().Send(Message). The API will return a MessageResponse which you can
 use to see if your message went through, how much it cost, etc.
+
+This is version 2 of the package. Version 1, imported as
+gopkg.in/njern/gonexmo.v1, is frozen and only receives fixes; breaking
+changes are made here.
 */
-package nexmo
+package nexmo // import "gopkg.in/njern/gonexmo.v2"
 
 const (
 	apiRoot   = "https://rest.nexmo.com"
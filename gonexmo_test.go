@@ -1,45 +1,75 @@
 package nexmo
 
 import (
-	"fmt"
+	"net/http"
 	"os"
 	"testing"
 )
 
+// The live API tests talk to Nexmo with the credentials in the NEXMO_KEY and
+// NEXMO_SECRET environment variables, and are skipped if they are not set.
+// Tests sending messages also need a recipient in NEXMO_NUM.
+//
+// Set a custom from value in NEXMO_FROM, or the default is used. If you get
+// error 15 when sending a message ("Illegal Sender Address - rejected") try
+// setting this to your nexmo phone number.
 var (
-	testAPIKey      string
-	testAPISecret   string
-	testPhoneNumber string
-	testFrom        string
+	testAPIKey      = os.Getenv("NEXMO_KEY")
+	testAPISecret   = os.Getenv("NEXMO_SECRET")
+	testPhoneNumber = os.Getenv("NEXMO_NUM")
+	testFrom        = os.Getenv("NEXMO_FROM")
 )
 
 func init() {
-	testAPIKey = os.Getenv("NEXMO_KEY")
-	if testAPIKey == "" {
-		fmt.Println("No API key specified. Please set NEXMO_KEY")
-		os.Exit(1)
+	if testFrom == "" {
+		testFrom = "gonexmo/test"
 	}
+}
+
+// liveLimiter paces the requests of the live API tests, which are limited to
+// one request per second.
+var liveLimiter = newRateLimiter(SystemClock, 1, 1)
+
+type pacedTransport struct{}
+
+func (pacedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	liveLimiter.wait()
+	return http.DefaultTransport.RoundTrip(req)
+}
 
-	testAPISecret = os.Getenv("NEXMO_SECRET")
-	if testAPISecret == "" {
-		fmt.Println("No API secret specified. Please set NEXMO_SECRET")
-		os.Exit(1)
+// liveClient returns a Client for the live API, skipping the test if no
+// credentials are configured.
+func liveClient(t *testing.T) *Client {
+	t.Helper()
+	if testAPIKey == "" || testAPISecret == "" {
+		t.Skip("live API test: set NEXMO_KEY and NEXMO_SECRET to run it")
 	}
 
-	testPhoneNumber = os.Getenv("NEXMO_NUM")
+	client, err := NewClient(testAPIKey, testAPISecret)
+	if err != nil {
+		t.Fatal("failed to create Client with error:", err)
+	}
+	client.HTTPClient = &http.Client{Transport: pacedTransport{}}
+	return client
+}
 
-	// Set a custom from value, or use the default. If you get error 15 when
-	// sending a message ("Illegal Sender Address - rejected") try setting this
-	// to your nexmo phone number.
-	testFrom = os.Getenv("NEXMO_FROM")
-	if testFrom == "" {
-		testFrom = "gonexmo/test"
+// liveRecipient returns the number live API tests send messages to, skipping
+// the test if none is configured.
+func liveRecipient(t *testing.T) string {
+	t.Helper()
+	if testPhoneNumber == "" {
+		t.Skip("live API test: set NEXMO_NUM to run it")
 	}
+	return testPhoneNumber
 }
 
 func TestNexmoCreation(t *testing.T) {
-	_, err := NewClient(testAPIKey, testAPISecret)
+	_, err := NewClient("key", "secret")
 	if err != nil {
 		t.Error("failed to create Client with error:", err)
 	}
+
+	if _, err := NewClient("", "secret"); err == nil {
+		t.Error("created a Client without an API key")
+	}
 }
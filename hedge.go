@@ -0,0 +1,235 @@
+package nexmo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RetryBudget bounds the extra requests a Client sends, retries of throttled
+// requests and hedged requests alike, to a fraction of the requests it sends
+// in the first place. This keeps a struggling API from being hit with
+// several times the normal load. Pass it to NewClient with WithRetryBudget.
+type RetryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	max    float64
+	tokens float64
+}
+
+// NewRetryBudget creates a RetryBudget allowing ratio extra requests per
+// request, e.g. 0.1 for one in ten, saved up to max extra requests. The
+// budget starts full.
+func NewRetryBudget(ratio, max float64) *RetryBudget {
+	return &RetryBudget{ratio: ratio, max: max, tokens: max}
+}
+
+// deposit records a request.
+func (b *RetryBudget) deposit() {
+	b.mu.Lock()
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+	b.mu.Unlock()
+}
+
+// withdraw takes one extra request from the budget, if there is one.
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// WithRetryBudget limits the extra requests sent by the client to b.
+func WithRetryBudget(b *RetryBudget) ClientOption {
+	return func(c *Client) {
+		c.retryBudget = b
+	}
+}
+
+// allowExtraRequest returns true if the retry budget of c, if any, allows
+// another retry or hedged request.
+func (c *Client) allowExtraRequest() bool {
+	return c.retryBudget == nil || c.retryBudget.withdraw()
+}
+
+// Minimum number of latencies measured for an endpoint before its requests
+// are hedged.
+const hedgeMinSamples = 20
+
+// WithHedging makes the client send a second, identical request when the
+// response to a request takes longer than the given percentile (e.g. 0.95)
+// of the latencies recently measured for its endpoint. The first response
+// wins and the other request is canceled.
+//
+// Hedging trades duplicate submissions for lower tail latency, so it should
+// only be used for messages which can be told apart by their client
+// reference, and preferably with a RetryBudget.
+func WithHedging(percentile float64) ClientOption {
+	return func(c *Client) {
+		c.hedging = &hedger{percentile: percentile, latencies: make(map[string]*latencyWindow)}
+	}
+}
+
+// hedger keeps track of the latencies of each endpoint.
+type hedger struct {
+	percentile float64
+
+	mu        sync.Mutex
+	latencies map[string]*latencyWindow
+}
+
+// latencyWindow holds the most recent latencies of an endpoint.
+type latencyWindow struct {
+	samples [128]time.Duration
+	n       int
+}
+
+func (h *hedger) observe(endpoint string, d time.Duration) {
+	h.mu.Lock()
+	w, ok := h.latencies[endpoint]
+	if !ok {
+		w = new(latencyWindow)
+		h.latencies[endpoint] = w
+	}
+	w.samples[w.n%len(w.samples)] = d
+	w.n++
+	h.mu.Unlock()
+}
+
+// delay returns how long to wait for a response from endpoint before sending
+// a hedged request, or false if too few latencies are known.
+func (h *hedger) delay(endpoint string) (time.Duration, bool) {
+	h.mu.Lock()
+	w, ok := h.latencies[endpoint]
+	if !ok || w.n < hedgeMinSamples {
+		h.mu.Unlock()
+		return 0, false
+	}
+	n := w.n
+	if n > len(w.samples) {
+		n = len(w.samples)
+	}
+	samples := append([]time.Duration(nil), w.samples[:n]...)
+	h.mu.Unlock()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	i := int(h.percentile * float64(n))
+	if i >= n {
+		i = n - 1
+	}
+	return samples[i], true
+}
+
+// sendHTTP sends r with the HTTPClient of c, hedging it if enabled.
+func (c *Client) sendHTTP(r *http.Request, endpoint string) (*http.Response, error) {
+	h := c.hedging
+	if h == nil {
+		return c.HTTPClient.Do(r)
+	}
+
+	clock := clockOrSystem(c.Clock)
+	delay, ok := h.delay(endpoint)
+	if !ok || (r.Body != nil && r.GetBody == nil) {
+		start := clock.Now()
+		resp, err := c.HTTPClient.Do(r)
+		if err == nil {
+			h.observe(endpoint, clock.Now().Sub(start))
+		}
+		return resp, err
+	}
+
+	type result struct {
+		resp *http.Response
+		err  error
+		i    int // Index of the request in cancels.
+	}
+	results := make(chan result, 2)
+	var cancels []context.CancelFunc
+	launch := func(req *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			start := clock.Now()
+			resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+			if err == nil {
+				h.observe(endpoint, clock.Now().Sub(start))
+			}
+			results <- result{resp, err, i}
+		}()
+	}
+
+	launch(r)
+	pending := 1
+	timer := clock.After(delay)
+	for {
+		select {
+		case <-timer:
+			timer = nil
+			if !c.allowExtraRequest() {
+				continue
+			}
+			hedge := r.Clone(r.Context())
+			if r.GetBody != nil {
+				body, err := r.GetBody()
+				if err != nil {
+					continue
+				}
+				hedge.Body = body
+			}
+			launch(hedge)
+			pending++
+
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				cancels[res.i]()
+				if pending > 0 {
+					continue
+				}
+				return nil, res.err
+			}
+
+			// The first response wins; the other request is canceled and its
+			// response, if any, discarded.
+			for i, cancel := range cancels {
+				if i != res.i {
+					cancel()
+				}
+			}
+			go func(pending int) {
+				for ; pending > 0; pending-- {
+					if other := <-results; other.err == nil {
+						other.resp.Body.Close()
+					}
+				}
+			}(pending)
+
+			res.resp.Body = &cancelBody{ReadCloser: res.resp.Body, cancel: cancels[res.i]}
+			return res.resp, nil
+		}
+	}
+}
+
+// cancelBody cancels the context of a request once its response body is
+// closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,88 @@
+package nexmo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const hedgeTestResponse = `{"message-count":"1","messages":[{"status":"0","message-id":"0A0000000123ABCD1"}]}`
+
+func TestHedging(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithHedging(0.95))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Clock = &instantClock{}
+
+	var mu sync.Mutex
+	calls := 0
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+
+		// The first request once enough latencies are known hangs until
+		// canceled, so the hedged request has to win.
+		if n == hedgeMinSamples+1 {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(hedgeTestResponse)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	for i := 0; i <= hedgeMinSamples; i++ {
+		if _, err := client.SMS().Send(msg); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != hedgeMinSamples+2 {
+		t.Errorf("got %d requests, want %d", calls, hedgeMinSamples+2)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithRetryBudget(NewRetryBudget(0.5, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Clock = &instantClock{}
+	client.MaxRetries = 5
+
+	calls := 0
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	client.SMS().Send(msg)
+	if calls != 2 {
+		t.Errorf("got %d requests with a full budget, want 2", calls)
+	}
+
+	// The budget is empty now and refilled by half a request per send.
+	calls = 0
+	client.SMS().Send(msg)
+	if calls != 1 {
+		t.Errorf("got %d requests with an empty budget, want 1", calls)
+	}
+	calls = 0
+	client.SMS().Send(msg)
+	if calls != 2 {
+		t.Errorf("got %d requests with a refilled budget, want 2", calls)
+	}
+}
@@ -0,0 +1,100 @@
+package nexmo
+
+import (
+	"context"
+	"fmt"
+)
+
+// Insight wraps a client to look up numbers with the Number Insight API.
+type Insight struct {
+	client *Client
+}
+
+// InsightLevel is the level of detail of a Number Insight lookup. Higher
+// levels cost more.
+type InsightLevel int
+
+// Insight levels
+const (
+	InsightBasic    InsightLevel = iota + 1 // Number formats and country.
+	InsightStandard                         // Adds the carrier and the network type.
+	InsightAdvanced                         // Adds validity and reachability.
+)
+
+var insightEndpoints = map[InsightLevel]Endpoint{
+	InsightBasic:    EndpointInsightBasic,
+	InsightStandard: EndpointInsightStandard,
+	InsightAdvanced: EndpointInsightAdvanced,
+}
+
+// InsightRequest is the request for a Number Insight lookup.
+type InsightRequest struct {
+	Number  string `json:"number"`
+	Country string `json:"country,omitempty"` // Needed for national numbers.
+}
+
+// InsightCarrier is a carrier reported by a Standard or Advanced lookup.
+type InsightCarrier struct {
+	NetworkCode string `json:"network_code"`
+	Name        string `json:"name"`
+	Country     string `json:"country"`
+
+	// One of "mobile", "landline", "landline_premium", "landline_tollfree",
+	// "virtual", "pager" or "unknown".
+	NetworkType string `json:"network_type"`
+}
+
+// InsightResponse is the response to a Number Insight lookup. Fields beyond
+// the level of the lookup are left empty.
+type InsightResponse struct {
+	Status        ResponseCode `json:"status"`
+	StatusMessage string       `json:"status_message"`
+	RequestID     string       `json:"request_id"`
+
+	InternationalFormatNumber string `json:"international_format_number"`
+	NationalFormatNumber      string `json:"national_format_number"`
+	CountryCode               string `json:"country_code"`
+	CountryCodeISO3           string `json:"country_code_iso3"`
+	CountryName               string `json:"country_name"`
+	CountryPrefix             string `json:"country_prefix"`
+
+	// Standard and Advanced.
+	RequestPrice     string          `json:"request_price"`
+	RemainingBalance string          `json:"remaining_balance"`
+	CurrentCarrier   *InsightCarrier `json:"current_carrier"`
+	OriginalCarrier  *InsightCarrier `json:"original_carrier"`
+	Ported           string          `json:"ported"`
+
+	// Advanced: "valid", "invalid" or "unknown", and "reachable",
+	// "undeliverable", "absent", "bad_number", "blacklisted" or "unknown".
+	ValidNumber string `json:"valid_number"`
+	Reachable   string `json:"reachable"`
+
+	ResponseMeta
+}
+
+func (r *InsightResponse) responseCodes() []ResponseCode {
+	return []ResponseCode{r.Status}
+}
+
+// Lookup looks up the number of req with the given level of detail.
+func (c *Insight) Lookup(ctx context.Context, level InsightLevel, req *InsightRequest) (*InsightResponse, error) {
+	endpoint, ok := insightEndpoints[level]
+	if !ok {
+		return nil, fmt.Errorf("unknown insight level %d", level)
+	}
+	if len(req.Number) == 0 {
+		return nil, ErrMissingNumber
+	}
+
+	r, err := c.client.newRequest(endpoint, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(InsightResponse)
+	if err := c.client.do(ctx, r, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,67 @@
+package nexmo
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNumberFilter(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := map[string]string{
+		"447700900000": `{"status":0,"international_format_number":"447700900000","current_carrier":{"network_code":"23410","network_type":"mobile"}}`,
+		"442079460000": `{"status":0,"international_format_number":"442079460000","current_carrier":{"network_type":"landline"}}`,
+		"123":          `{"status":3,"status_message":"Invalid request"}`,
+	}
+	var lookups []string
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/ni/standard/json" {
+			t.Errorf("request sent to %s", req.URL.Path)
+		}
+		req.ParseForm()
+		number := req.PostForm.Get("number")
+		lookups = append(lookups, number)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(responses[number])),
+		}, nil
+	})}
+
+	filter := &NumberFilter{Insight: client.Insight()}
+	numbers := []string{"447700900000", "442079460000", "123", "447700900000"}
+	valid, rejected, err := filter.Filter(context.Background(), numbers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(valid) != 2 || valid[0] != "447700900000" || valid[1] != "447700900000" {
+		t.Errorf("got valid numbers %q", valid)
+	}
+	if len(rejected) != 2 || rejected[0].Reason != NumberRejectLandline || rejected[1].Reason != NumberRejectInvalid {
+		t.Errorf("got rejected numbers %+v", rejected)
+	}
+	if len(lookups) != 3 {
+		t.Errorf("got lookups %q", lookups)
+	}
+
+	// Partial lookups are filtered on the fields they have.
+	responses["447700900002"] = `{"status":43,"status_message":"Lookup Handler unable to handle request",` +
+		`"current_carrier":{"network_type":"mobile"}}`
+	responses["442079460001"] = `{"status":44,"current_carrier":{"network_type":"landline"}}`
+	valid, rejected, err = filter.Filter(context.Background(), []string{"447700900002", "442079460001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(valid) != 1 || valid[0] != "447700900002" || len(rejected) != 1 || rejected[0].Reason != NumberRejectLandline {
+		t.Errorf("got valid numbers %q and rejected numbers %+v", valid, rejected)
+	}
+
+	responses["447700900001"] = `{"status":1,"status_message":"Throttled"}`
+	if _, _, err := filter.Filter(context.Background(), []string{"447700900001"}); err == nil {
+		t.Error("throttled lookup did not fail")
+	}
+}
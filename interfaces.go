@@ -0,0 +1,42 @@
+package nexmo
+
+// The following interfaces are implemented by the modules of a Client. Code
+// using the Nexmo API can depend on them instead of on a *Client, so that
+// fakes can be substituted in unit tests which should not hit the network.
+
+// SMSSender sends SMS messages. It is implemented by *SMS.
+type SMSSender interface {
+	Send(msg *SMSMessage, opts ...SendOption) (*MessageResponse, error)
+}
+
+// USSDSender sends USSD messages. It is implemented by *USSD.
+type USSDSender interface {
+	Send(msg *USSDMessage) (*MessageResponse, error)
+}
+
+// Verifier drives the Verify API. It is implemented by *Verification.
+type Verifier interface {
+	Send(m *VerifyMessageRequest) (*VerifyMessageResponse, error)
+	Check(m *VerifyCheckRequest) (*VerifyCheckResponse, error)
+	Search(m *VerifySearchRequest) (*VerifySearchResponse, error)
+	Control(m *VerifyControlRequest) (*VerifyControlResponse, error)
+}
+
+// MessagesSender sends messages through the Messages API. It is implemented
+// by *Messages.
+type MessagesSender interface {
+	Send(msg *OutboundMessage) (*OutboundMessageResponse, error)
+}
+
+// AccountService gives access to the account. It is implemented by *Account.
+type AccountService interface {
+	GetBalance() (float64, error)
+}
+
+var (
+	_ SMSSender      = (*SMS)(nil)
+	_ USSDSender     = (*USSD)(nil)
+	_ Verifier       = (*Verification)(nil)
+	_ MessagesSender = (*Messages)(nil)
+	_ AccountService = (*Account)(nil)
+)
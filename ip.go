@@ -1,34 +1,139 @@
 package nexmo
 
-import "net"
+import (
+	"net"
+	"sync"
+)
 
-// IP's sourced from https://help.nexmo.com/entries/23181071-Source-IP-subnet-for-incoming-traffic-in-REST-API
+// IP's sourced from https://help.nexmo.com/hc/en-us/articles/204015053 and the
+// Vonage API developer documentation.
 var masks = []string{
+	// Legacy Nexmo ranges.
 	"174.37.245.32/29",
 	"174.36.197.192/28",
 	"173.193.199.16/28",
 	"119.81.44.0/28",
+
+	// Current Vonage ranges.
+	"5.10.112.112/28",
+	"169.50.200.64/29",
+	"169.60.141.16/28",
+	"169.63.86.160/28",
+	"168.100.64.0/18",
+	"216.147.0.0/18",
+}
+
+// TrustedIPs is a set of subnets callbacks are accepted from. It is safe for
+// concurrent use, so ranges can be updated while handlers are serving
+// requests.
+type TrustedIPs struct {
+	mu      sync.RWMutex
+	subnets []*net.IPNet
 }
 
-var subnets []net.IPNet
+// DefaultTrustedIPs holds the Nexmo ranges used by IsTrustedIP and by handlers
+// which have not been given a TrustedIPs of their own.
+var DefaultTrustedIPs = mustTrustedIPs(masks...)
 
-func init() {
-	subnets = make([]net.IPNet, len(masks))
-	for i, mask := range masks {
-		_, net, _ := net.ParseCIDR(mask)
-		subnets[i] = *net
+// NewTrustedIPs creates a TrustedIPs containing the provided CIDR ranges.
+func NewTrustedIPs(cidrs ...string) (*TrustedIPs, error) {
+	t := new(TrustedIPs)
+	if err := t.Set(cidrs...); err != nil {
+		return nil, err
 	}
+	return t, nil
 }
 
-// IsTrustedIP returns true if the provided IP address came from
-// a trusted Nexmo server.
-func IsTrustedIP(ipStr string) bool {
+func mustTrustedIPs(cidrs ...string) *TrustedIPs {
+	t, err := NewTrustedIPs(cidrs...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	subnets := make([]*net.IPNet, len(cidrs))
+	for i, mask := range cidrs {
+		_, net, err := net.ParseCIDR(mask)
+		if err != nil {
+			return nil, err
+		}
+		subnets[i] = net
+	}
+	return subnets, nil
+}
+
+// Set replaces all ranges in t with cidrs. If any of the ranges is invalid, t
+// is left unchanged.
+func (t *TrustedIPs) Set(cidrs ...string) error {
+	subnets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.subnets = subnets
+	t.mu.Unlock()
+	return nil
+}
+
+// Add adds the provided CIDR range to t.
+func (t *TrustedIPs) Add(cidr string) error {
+	subnets, err := parseCIDRs([]string{cidr})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.subnets = append(t.subnets, subnets...)
+	t.mu.Unlock()
+	return nil
+}
+
+// CIDRs returns the ranges currently in t.
+func (t *TrustedIPs) CIDRs() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	cidrs := make([]string, len(t.subnets))
+	for i, net := range t.subnets {
+		cidrs[i] = net.String()
+	}
+	return cidrs
+}
+
+// IsTrustedIP returns true if the provided IP address is in any of the
+// ranges in t.
+func (t *TrustedIPs) IsTrustedIP(ipStr string) bool {
 	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
-	for _, net := range subnets {
+	for _, net := range t.subnets {
 		if net.Contains(ip) {
 			return true
 		}
 	}
 	return false
 }
+
+// SetTrustedCIDRs replaces the ranges in DefaultTrustedIPs.
+func SetTrustedCIDRs(cidrs ...string) error {
+	return DefaultTrustedIPs.Set(cidrs...)
+}
+
+// AddTrustedCIDR adds a range to DefaultTrustedIPs.
+func AddTrustedCIDR(cidr string) error {
+	return DefaultTrustedIPs.Add(cidr)
+}
+
+// IsTrustedIP returns true if the provided IP address came from
+// a trusted Nexmo server.
+func IsTrustedIP(ipStr string) bool {
+	return DefaultTrustedIPs.IsTrustedIP(ipStr)
+}
@@ -0,0 +1,127 @@
+package nexmo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IPRangeSource returns the current CIDR ranges callbacks are sent from.
+type IPRangeSource func(ctx context.Context) ([]string, error)
+
+// IPRangesFromURL returns an IPRangeSource fetching the ranges from url with
+// client, or http.DefaultClient if nil. The document is either a JSON array
+// of strings or plain text with one range per line, in which blank lines and
+// lines starting with # are ignored.
+func IPRangesFromURL(client *http.Client, url string) IPRangeSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) ([]string, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching IP ranges from %s: %s", url, resp.Status)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return parseIPRanges(body)
+	}
+}
+
+func parseIPRanges(body []byte) ([]string, error) {
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("[")) {
+		var cidrs []string
+		err := json.Unmarshal(body, &cidrs)
+		return cidrs, err
+	}
+
+	var cidrs []string
+	s := bufio.NewScanner(bytes.NewReader(body))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			cidrs = append(cidrs, line)
+		}
+	}
+	return cidrs, s.Err()
+}
+
+// ErrNoIPRanges is returned when an IPRangeSource returns no ranges, which
+// would make the handlers reject every callback.
+var ErrNoIPRanges = errors.New("no trusted IP ranges")
+
+// TrustedIPRefresher keeps a TrustedIPs up to date with the ranges returned
+// by Source, so changes to the Vonage ranges do not require a release. The
+// ranges are replaced all at once; if they can not be fetched or any of them
+// is invalid, the previous ones are kept.
+type TrustedIPRefresher struct {
+	// Defaults to DefaultTrustedIPs.
+	IPs    *TrustedIPs
+	Source IPRangeSource
+
+	// Defaults to one hour.
+	Interval time.Duration
+
+	// Called with the errors of the refreshes done by Run.
+	OnError func(error)
+
+	Clock Clock
+}
+
+// Refresh replaces the ranges with the ones returned by Source.
+func (r *TrustedIPRefresher) Refresh(ctx context.Context) error {
+	cidrs, err := r.Source(ctx)
+	if err != nil {
+		return err
+	}
+	if len(cidrs) == 0 {
+		return ErrNoIPRanges
+	}
+
+	ips := r.IPs
+	if ips == nil {
+		ips = DefaultTrustedIPs
+	}
+	return ips.Set(cidrs...)
+}
+
+// Run refreshes the ranges every Interval until ctx is done.
+func (r *TrustedIPRefresher) Run(ctx context.Context) error {
+	clock := clockOrSystem(r.Clock)
+	for {
+		if err := r.Refresh(ctx); err != nil && r.OnError != nil && ctx.Err() == nil {
+			r.OnError(err)
+		}
+
+		select {
+		case <-clock.After(r.interval()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *TrustedIPRefresher) interval() time.Duration {
+	if r.Interval <= 0 {
+		return time.Hour
+	}
+	return r.Interval
+}
@@ -0,0 +1,73 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIPRangesFromURL(t *testing.T) {
+	bodies := map[string]string{
+		"/ranges.json": `["5.10.112.112/28", "168.100.64.0/18"]`,
+		"/ranges.txt":  "# Vonage\n5.10.112.112/28\n\n168.100.64.0/18\n",
+	}
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := bodies[req.URL.Path]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	want := []string{"5.10.112.112/28", "168.100.64.0/18"}
+	for path := range bodies {
+		got, err := IPRangesFromURL(client, "https://example.com"+path)(context.Background())
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, %v", path, got, err)
+		}
+	}
+	if _, err := IPRangesFromURL(client, "https://example.com/missing")(context.Background()); err == nil {
+		t.Error("missing document gave no error")
+	}
+}
+
+func TestTrustedIPRefresher(t *testing.T) {
+	ips, err := NewTrustedIPs("174.37.245.32/29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cidrs []string
+	var fetchErr error
+	r := &TrustedIPRefresher{IPs: ips, Source: func(context.Context) ([]string, error) {
+		return cidrs, fetchErr
+	}}
+
+	cidrs = []string{"5.10.112.112/28"}
+	if err := r.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !ips.IsTrustedIP("5.10.112.113") || ips.IsTrustedIP("174.37.245.33") {
+		t.Errorf("got ranges %q after refresh", ips.CIDRs())
+	}
+
+	for _, tc := range []struct {
+		cidrs []string
+		err   error
+	}{
+		{nil, nil},
+		{[]string{"168.100.64.0/18", "not a range"}, nil},
+		{[]string{"168.100.64.0/18"}, errors.New("unavailable")},
+	} {
+		cidrs, fetchErr = tc.cidrs, tc.err
+		if err := r.Refresh(context.Background()); err == nil {
+			t.Errorf("refresh with %q, %v gave no error", tc.cidrs, tc.err)
+		}
+		if got := ips.CIDRs(); !reflect.DeepEqual(got, []string{"5.10.112.112/28"}) {
+			t.Errorf("failed refresh changed the ranges to %q", got)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package nexmo
 
-import "testing"
+import (
+	"net/http/httptest"
+	"testing"
+)
 
 var isTrustedIPTests = []struct {
 	ip   string
@@ -23,3 +26,48 @@ func TestIsTrustedIP(t *testing.T) {
 		}
 	}
 }
+
+func TestTrustedIPs(t *testing.T) {
+	trusted, err := NewTrustedIPs("192.0.2.0/24")
+	if err != nil {
+		t.Fatal("failed to create TrustedIPs:", err)
+	}
+
+	if !trusted.IsTrustedIP("192.0.2.10") || trusted.IsTrustedIP("174.37.245.33") {
+		t.Error("TrustedIPs does not contain exactly the configured range")
+	}
+
+	if err := trusted.Add("198.51.100.0/24"); err != nil {
+		t.Fatal("failed to add range:", err)
+	}
+	if !trusted.IsTrustedIP("198.51.100.7") {
+		t.Error("added range is not trusted")
+	}
+
+	if err := trusted.Set("203.0.113.0/24", "not a cidr"); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+	if len(trusted.CIDRs()) != 2 {
+		t.Errorf("failed Set modified the ranges: %v", trusted.CIDRs())
+	}
+
+	if err := trusted.Set("203.0.113.0/24"); err != nil {
+		t.Fatal("failed to set ranges:", err)
+	}
+	if trusted.IsTrustedIP("192.0.2.10") || !trusted.IsTrustedIP("203.0.113.1") {
+		t.Error("Set did not replace the ranges")
+	}
+}
+
+func TestHandlerTrustedIPs(t *testing.T) {
+	trusted, _ := NewTrustedIPs("192.0.2.0/24")
+	out := make(chan *ReceivedMessage, 1)
+	h := NewMessageHandler(out, true, WithTrustedIPs(trusted))
+
+	req := newFormRequest("GET", testInboundValues)
+	req.RemoteAddr = "192.0.2.1:1234"
+	h(httptest.NewRecorder(), req)
+	if len(out) != 1 {
+		t.Error("message from a configured range was rejected")
+	}
+}
@@ -0,0 +1,115 @@
+package nexmo
+
+import (
+	"net/http"
+	"time"
+)
+
+// LogLevel is the severity of a log record.
+type LogLevel int
+
+// Log levels
+const (
+	LogDebug LogLevel = iota + 1
+	LogInfo
+	LogWarn
+	LogError
+)
+
+var logLevelMap = map[LogLevel]string{
+	LogDebug: "debug",
+	LogInfo:  "info",
+	LogWarn:  "warn",
+	LogError: "error",
+}
+
+func (l LogLevel) String() string {
+	if str, ok := logLevelMap[l]; ok {
+		return str
+	}
+	return "undefined"
+}
+
+// LogField is a key/value pair attached to a log record.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives log records from a Client, see WithLogger. The
+// nexmoslog package adapts log/slog to it; other structured logging libraries
+// are as easily adapted. Implementations must be safe for concurrent use.
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+// WithLogger makes the client log the requests it sends to l. Credentials are
+// never logged.
+func WithLogger(l Logger) ClientOption {
+	return func(c *Client) {
+		c.Logger = l
+	}
+}
+
+// LogLevels are the levels requests are logged at.
+type LogLevels struct {
+	// Requests which succeeded. Defaults to LogDebug.
+	Success LogLevel
+
+	// Requests answered with a Nexmo status other than ResponseSuccess, e.g.
+	// a message rejected for lack of credit. Defaults to LogInfo.
+	Rejected LogLevel
+
+	// Requests which failed. Defaults to LogWarn.
+	Failure LogLevel
+}
+
+// DefaultLogLevels are the levels used unless changed with WithLogLevels.
+var DefaultLogLevels = LogLevels{Success: LogDebug, Rejected: LogInfo, Failure: LogWarn}
+
+// WithLogLevels changes the levels the client logs requests at. Levels left
+// zero keep their default.
+func WithLogLevels(levels LogLevels) ClientOption {
+	return func(c *Client) {
+		c.logLevels = levels
+	}
+}
+
+// logRequest logs a request sent by do, which decoded the response into v.
+func (c *Client) logRequest(r *http.Request, endpoint string, v interface{}, statusCode int, d time.Duration, err error) {
+	levels := c.logLevels
+	if levels.Success == 0 {
+		levels.Success = DefaultLogLevels.Success
+	}
+	if levels.Rejected == 0 {
+		levels.Rejected = DefaultLogLevels.Rejected
+	}
+	if levels.Failure == 0 {
+		levels.Failure = DefaultLogLevels.Failure
+	}
+
+	// The path of some endpoints, and so the errors, contain the credentials.
+	fields := []LogField{
+		{"method", r.Method},
+		{"url", string(maskSecrets([]byte(r.URL.String())))},
+		{"endpoint", endpoint},
+		{"status", statusCode},
+		{"duration", d},
+	}
+	if err != nil {
+		c.Logger.Log(levels.Failure, "nexmo request failed", append(fields, LogField{"error", string(maskSecrets([]byte(err.Error())))})...)
+		return
+	}
+
+	level := levels.Success
+	if rc, ok := v.(responseCoder); ok {
+		codes := rc.responseCodes()
+		for _, code := range codes {
+			if code != ResponseSuccess {
+				level = levels.Rejected
+			}
+		}
+		fields = append(fields, LogField{"nexmo_status", codes})
+	}
+	c.Logger.Log(level, "nexmo request", fields...)
+}
@@ -0,0 +1,74 @@
+package nexmo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logRecord is a record received by a recordingLogger.
+type logRecord struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rec := logRecord{level: level, msg: msg, fields: make(map[string]interface{})}
+	for _, f := range fields {
+		rec.fields[f.Key] = f.Value
+	}
+	l.records = append(l.records, rec)
+}
+
+func TestLogRequest(t *testing.T) {
+	l := new(recordingLogger)
+	client, err := NewClient("k3y", "s3cr3t", WithLogger(l), WithLogLevels(LogLevels{Rejected: LogError}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := "0"
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"` + status + `"}]}`)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "12345", To: "447700900001", Type: Text, Text: "Hello"}
+	if _, err := client.SMS().Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	status = "9"
+	if _, err := client.SMS().Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("connection refused")
+	})}
+	client.Account().GetBalance()
+
+	if len(l.records) != 3 {
+		t.Fatalf("got %d records", len(l.records))
+	}
+	if rec := l.records[0]; rec.level != LogDebug || rec.fields["url"] != "https://rest.nexmo.com/sms/json" ||
+		fmt.Sprint(rec.fields["nexmo_status"]) != "[Success]" {
+		t.Errorf("got record %+v for an accepted message", rec)
+	}
+	if rec := l.records[1]; rec.level != LogError || fmt.Sprint(rec.fields["nexmo_status"]) != "[Partner quota exceeded]" {
+		t.Errorf("got record %+v for a rejected message", rec)
+	}
+	if rec := l.records[2]; rec.level != LogWarn || strings.Contains(fmt.Sprint(rec.fields), "s3cr3t") {
+		t.Errorf("got record %+v for a failed request", rec)
+	}
+}
@@ -0,0 +1,113 @@
+package nexmo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	messagesAPIRoot     = apiRootv2 + "/v0.1/messages"
+	messagesSandboxRoot = "https://messages-sandbox.nexmo.com/v0.1/messages"
+)
+
+// Messages represents the Messages API functions, for sending messages over
+// channels like WhatsApp, Viber and Facebook Messenger.
+type Messages struct {
+	client *Client
+
+	// Messages are sent to the Messages API sandbox, see WithMessagesSandbox.
+	sandbox bool
+}
+
+// MessageAddress is the sender or recipient of a Messages API message.
+// Depending on the channel in Type (e.g. "sms", "whatsapp", "viber_service_msg"
+// or "messenger"), either Number or ID is set.
+type MessageAddress struct {
+	Type   string `json:"type"`
+	Number string `json:"number,omitempty"`
+	ID     string `json:"id,omitempty"`
+}
+
+// MessageContent is the content of a Messages API message. Type is "text"
+// for text messages; other content types are not supported yet.
+type MessageContent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// OutboundMessage is a message to send through the Messages API.
+type OutboundMessage struct {
+	From    MessageAddress `json:"from"`
+	To      MessageAddress `json:"to"`
+	Message struct {
+		Content MessageContent `json:"content"`
+	} `json:"message"`
+	ClientReference string `json:"client_ref,omitempty"`
+}
+
+// OutboundMessageResponse is returned by the Messages API when a message was
+// accepted.
+type OutboundMessageResponse struct {
+	MessageUUID string `json:"message_uuid"`
+
+	// Set if the message was sent to the sandbox rather than the live API.
+	Sandbox bool `json:"-"`
+
+	ResponseMeta
+}
+
+// Send the message through the Messages API, or its sandbox if the client was
+// created with WithMessagesSandbox. Errors reported by the API are returned
+// as a *MessageError.
+func (c *Messages) Send(msg *OutboundMessage) (*OutboundMessageResponse, error) {
+	if msg.From.Type == "" || msg.To.Type == "" {
+		return nil, ErrMissingChannel
+	}
+
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return nil, errors.New("invalid message struct - unable to convert to JSON")
+	}
+
+	url := messagesAPIRoot
+	if c.sandbox {
+		url = messagesSandboxRoot
+	}
+	r, err := http.NewRequest("POST", url, bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	if a, ok := c.client.Auth.(*JWTAuth); ok {
+		if err := a.Authenticate(r.Header, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		creds, err := c.client.credentials()
+		if err != nil {
+			return nil, err
+		}
+		r.SetBasicAuth(creds.APIKey, creds.APISecret)
+	}
+	r.Header.Add("Accept", "application/json")
+	r.Header.Add("Content-Type", "application/json")
+
+	messageResponse := &OutboundMessageResponse{Sandbox: c.sandbox}
+	if err := c.client.do(context.Background(), r, messageResponse); err != nil {
+		return nil, err
+	}
+	return messageResponse, nil
+}
+
+// parseError implements errorParser.
+func (r *OutboundMessageResponse) parseError(resp *http.Response, body []byte) error {
+	e := new(MessageError)
+	if err := json.Unmarshal(body, e); err != nil || e.Detail == "" {
+		return fmt.Errorf("messages API returned %s", resp.Status)
+	}
+	return e
+}
@@ -0,0 +1,103 @@
+package nexmo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestMessagesSandbox(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithMessagesSandbox())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *http.Request
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{
+			StatusCode: http.StatusAccepted,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message_uuid":"aaaaaaaa-bbbb-cccc-dddd-0123456789ab"}`)),
+		}, nil
+	})}
+
+	msg := &OutboundMessage{
+		From: MessageAddress{Type: "whatsapp", Number: "14157386170"},
+		To:   MessageAddress{Type: "whatsapp", Number: "447700900000"},
+	}
+	msg.Message.Content = MessageContent{Type: "text", Text: "Hello"}
+
+	resp, err := client.Messages().Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.Host != "messages-sandbox.nexmo.com" {
+		t.Errorf("message was sent to %s", got.URL)
+	}
+	if user, pass, _ := got.BasicAuth(); user != "k3y" || pass != "s3cr3t" {
+		t.Errorf("got credentials %q, %q", user, pass)
+	}
+	if !resp.Sandbox || resp.MessageUUID == "" {
+		t.Errorf("unexpected response %#v", resp)
+	}
+}
+
+func TestMessagesSharedTransport(t *testing.T) {
+	l := new(recordingLogger)
+	client, err := NewClient("k3y", "s3cr3t", WithLogger(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.MaxRetries = 1
+	client.Clock = &instantClock{}
+
+	var requests int
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		switch requests {
+		case 1:
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		case 2:
+			return &http.Response{
+				StatusCode: http.StatusAccepted,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"message_uuid":"aaaaaaaa-bbbb-cccc-dddd-0123456789ab"}`)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusUnprocessableEntity,
+			Status:     "422 Unprocessable Entity",
+			Body:       ioutil.NopCloser(strings.NewReader(`{"type":"https://developer.nexmo.com/api-errors/messages-olympus#1120","title":"1120","detail":"Invalid sender"}`)),
+		}, nil
+	})}
+
+	msg := &OutboundMessage{
+		From: MessageAddress{Type: "whatsapp", Number: "14157386170"},
+		To:   MessageAddress{Type: "whatsapp", Number: "447700900000"},
+	}
+	msg.Message.Content = MessageContent{Type: "text", Text: "Hello"}
+
+	// Throttled requests are retried like those to the other APIs.
+	resp, err := client.Messages().Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.MessageUUID != "aaaaaaaa-bbbb-cccc-dddd-0123456789ab" || requests != 2 {
+		t.Errorf("got response %+v after %d requests", resp, requests)
+	}
+
+	_, err = client.Messages().Send(msg)
+	if e, ok := err.(*MessageError); !ok || e.Code != 1120 {
+		t.Errorf("got error %v", err)
+	}
+	if len(l.records) != 2 {
+		t.Errorf("logged %d requests, want 2", len(l.records))
+	}
+}
@@ -0,0 +1,80 @@
+package nexmo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultRequestIDHeader is the header the webhook handlers take the request
+// ID of a callback from, unless configured otherwise with
+// WithRequestIDHeader.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// RequestMeta describes the HTTP request a callback was received in, so that
+// log lines written while processing it can be correlated.
+type RequestMeta struct {
+	// Taken from the request ID header if present, generated otherwise.
+	RequestID string `json:"request_id"`
+
+	// When the handler received the request.
+	ReceivedAt time.Time `json:"received_at"`
+
+	// IP address the request came from.
+	RemoteIP string `json:"remote_ip"`
+}
+
+func (m *ReceivedMessage) setMeta(meta RequestMeta) { m.Meta = meta }
+func (m *DeliveryReceipt) setMeta(meta RequestMeta) { m.Meta = meta }
+
+func (e *Event) setMeta(meta RequestMeta) {
+	switch {
+	case e.Message != nil:
+		e.Message.setMeta(meta)
+	case e.Receipt != nil:
+		e.Receipt.setMeta(meta)
+	}
+}
+
+// newRequestMeta creates the RequestMeta for req, received at t.
+func newRequestMeta(req *http.Request, header string, t time.Time) RequestMeta {
+	meta := RequestMeta{
+		RequestID:  req.Header.Get(header),
+		ReceivedAt: t,
+		RemoteIP:   req.RemoteAddr,
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		meta.RemoteIP = host
+	}
+	if meta.RequestID == "" {
+		meta.RequestID = newRequestID()
+	}
+	return meta
+}
+
+// newRequestID generates a random request ID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// ResponseMeta describes the HTTP response an API call was answered with, so
+// that it can be debugged and correlated with Nexmo support tickets. It is
+// embedded in the responses of the API calls.
+type ResponseMeta struct {
+	StatusCode int         `json:"-"`
+	Header     http.Header `json:"-"` // E.g. X-Request-Id and rate limit headers.
+	Body       []byte      `json:"-"` // As received.
+}
+
+func (m *ResponseMeta) setResponseMeta(meta ResponseMeta) { *m = meta }
+
+// responseMetaSetter is implemented by the responses embedding a ResponseMeta.
+type responseMetaSetter interface {
+	setResponseMeta(ResponseMeta)
+}
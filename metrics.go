@@ -0,0 +1,123 @@
+package nexmo
+
+import "time"
+
+// Reasons reported to HandlerMetrics.CallbackRejected.
+const (
+	RejectUntrustedIP   = "untrusted_ip"
+	RejectParseError    = "parse_error"
+	RejectConsumerError = "consumer_error"
+	RejectReplay        = "replay"
+	RejectStoreError    = "store_error"
+	RejectRateLimited   = "rate_limited"
+	RejectOverloaded    = "overloaded"
+	RejectBadSignature  = "bad_signature"
+)
+
+// Outcomes reported to HandlerMetrics.Backpressure.
+const (
+	BackpressureTimeout = "timeout"
+	BackpressureDropped = "dropped"
+)
+
+// HandlerMetrics receives measurements from the webhook handlers, see
+// WithMetrics. Every call is labelled with the name of the handler. The
+// nexmoprom package provides an implementation exporting them to Prometheus.
+// Implementations must be safe for concurrent use.
+type HandlerMetrics interface {
+	// CallbackReceived is called for every request to a handler.
+	CallbackReceived(handler string)
+
+	// CallbackRejected is called when a callback is rejected, with one of the
+	// Reject* constants as the reason.
+	CallbackRejected(handler, reason string)
+
+	// CallbackHandled is called once a handler is done with a request.
+	CallbackHandled(handler string, d time.Duration)
+
+	// Backpressure is called when the consumer of a channel handler could not
+	// keep up, with one of the Backpressure* constants as the outcome.
+	Backpressure(handler, outcome string)
+}
+
+// MetricsCollector receives measurements of the requests a Client sends to
+// the Nexmo API, see WithMetricsCollector. Endpoints are identified by the
+// path of their URL, with any credentials masked. The nexmoprom package
+// provides an implementation exporting them to Prometheus. Implementations
+// must be safe for concurrent use.
+type MetricsCollector interface {
+	// RequestDone is called once a request has completed, with the HTTP
+	// status code of the response, or 0 if none was received.
+	RequestDone(endpoint string, statusCode int, d time.Duration)
+
+	// RequestRetried is called when a request is about to be sent again
+	// after being answered with 429 Too Many Requests.
+	RequestRetried(endpoint string)
+
+	// ResponseCode is called for every status reported in a successfully
+	// decoded response, e.g. once per part of an SMS.
+	ResponseCode(endpoint string, code ResponseCode)
+
+	// Balance is called with the balance, in euros, retrieved by
+	// Account.GetBalance.
+	Balance(euros float64)
+}
+
+// SentMessageCollector can be implemented by a MetricsCollector to count the
+// SMS messages sent.
+type SentMessageCollector interface {
+	// MessageSent is called for every SMS message accepted by Nexmo, with
+	// the number of parts which were accepted.
+	MessageSent(parts int)
+}
+
+// WithMetricsCollector makes the client report measurements of its requests
+// to m.
+func WithMetricsCollector(m MetricsCollector) ClientOption {
+	return func(c *Client) {
+		c.Metrics = m
+	}
+}
+
+// messageSent reports a message sent with resp to the SentMessageCollector of
+// c, if any.
+func (c *Client) messageSent(resp *MessageResponse) {
+	m, ok := c.Metrics.(SentMessageCollector)
+	if !ok {
+		return
+	}
+	parts := 0
+	for _, report := range resp.Messages {
+		if report.Status == ResponseSuccess {
+			parts++
+		}
+	}
+	if parts > 0 {
+		m.MessageSent(parts)
+	}
+}
+
+// responseCoder is implemented by the responses reporting ResponseCodes.
+type responseCoder interface {
+	responseCodes() []ResponseCode
+}
+
+func (r *MessageResponse) responseCodes() []ResponseCode {
+	codes := make([]ResponseCode, len(r.Messages))
+	for i, report := range r.Messages {
+		codes[i] = report.Status
+	}
+	return codes
+}
+
+func (r *VerifyMessageResponse) responseCodes() []ResponseCode {
+	return []ResponseCode{r.Status}
+}
+
+func (r *VerifyCheckResponse) responseCodes() []ResponseCode {
+	return []ResponseCode{r.Status}
+}
+
+func (r *VerifyControlResponse) responseCodes() []ResponseCode {
+	return []ResponseCode{r.Status}
+}
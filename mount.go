@@ -0,0 +1,71 @@
+package nexmo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Mux is implemented by *http.ServeMux and most third party routers.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Webhooks configures the handlers mounted by Client.MountWebhooks. A handler
+// is only mounted if its consumer is set.
+type Webhooks struct {
+	Messages     chan *ReceivedMessage // Mounted on <prefix>/inbound.
+	Receipts     chan *DeliveryReceipt // Mounted on <prefix>/delivery-receipt.
+	Statuses     chan *MessageStatus   // Mounted on <prefix>/status.
+	VerifyEvents chan *VerifyEvent     // Mounted on <prefix>/verify.
+	VoiceEvents  chan *VoiceEvent      // Mounted on <prefix>/voice/event.
+
+	// Mounted on <prefix>/voice/answer.
+	Answer func(*AnswerRequest) (NCCO, error)
+
+	// Only accept callbacks from trusted Nexmo IPs, as configured by
+	// Client.TrustedIPs.
+	VerifyIPs bool
+
+	// Options applied to all handlers.
+	Options []HandlerOption
+}
+
+// MountWebhooks registers the handlers configured in w on mux, under prefix.
+// If w.VerifyIPs is set, all of them are wrapped in the same VerifyIPs
+// middleware.
+func (c *Client) MountWebhooks(mux Mux, prefix string, w *Webhooks) {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	opts := w.Options
+	if c.TrustedIPs != nil {
+		opts = append([]HandlerOption{WithTrustedIPs(c.TrustedIPs)}, opts...)
+	}
+
+	verify := func(h http.Handler) http.Handler { return h }
+	if w.VerifyIPs {
+		verify = VerifyIPs(opts...)
+	}
+
+	handle := func(path string, h http.Handler) {
+		mux.Handle(prefix+path, verify(h))
+	}
+
+	if w.Messages != nil {
+		handle("/inbound", NewMessageHandler(w.Messages, false, opts...))
+	}
+	if w.Receipts != nil {
+		handle("/delivery-receipt", NewDeliveryHandler(w.Receipts, false, opts...))
+	}
+	if w.Statuses != nil {
+		handle("/status", NewMessageStatusHandler(w.Statuses, false, opts...))
+	}
+	if w.VerifyEvents != nil {
+		handle("/verify", NewVerifyEventHandler(w.VerifyEvents, false, opts...))
+	}
+	if w.VoiceEvents != nil {
+		handle("/voice/event", NewVoiceEventHandler(w.VoiceEvents, false, opts...))
+	}
+	if w.Answer != nil {
+		handle("/voice/answer", NewAnswerHandlerFunc(w.Answer, false, opts...))
+	}
+}
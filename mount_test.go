@@ -0,0 +1,67 @@
+package nexmo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountWebhooks(t *testing.T) {
+	trusted, err := NewTrustedIPs("192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{TrustedIPs: trusted}
+	messages := make(chan *ReceivedMessage, 1)
+	verifyEvents := make(chan *VerifyEvent, 1)
+
+	mux := http.NewServeMux()
+	client.MountWebhooks(mux, "/nexmo/", &Webhooks{
+		Messages:     messages,
+		VerifyEvents: verifyEvents,
+		Answer:       func(*AnswerRequest) (NCCO, error) { return NCCO{{"action": "talk"}}, nil },
+		VerifyIPs:    true,
+	})
+
+	req := newFormRequest("POST", testInboundValues)
+	req.URL.Path = "/nexmo/inbound"
+	req.RemoteAddr = "192.0.2.10:1234"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("inbound: got status %d", rec.Code)
+	}
+	if m := <-messages; m.ID != testInboundValues.Get("messageId") {
+		t.Errorf("got message %q", m.ID)
+	}
+
+	req = newJSONRequest(`{"request_id":"abcdef0123456789abcdef0123456789","type":"summary","status":"completed"}`)
+	req.URL.Path = "/nexmo/verify"
+	req.RemoteAddr = "192.0.2.10:1234"
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("verify: got status %d", rec.Code)
+	}
+	if e := <-verifyEvents; e.Status != "completed" {
+		t.Errorf("got verify event %+v", e)
+	}
+
+	// Requests from other addresses are rejected by the shared middleware.
+	req = httptest.NewRequest("GET", "/nexmo/voice/answer?uuid=abc", nil)
+	req.RemoteAddr = "198.51.100.1:1234"
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK {
+		t.Errorf("answer: untrusted request was accepted")
+	}
+
+	// Unconfigured handlers are not mounted.
+	req = httptest.NewRequest("POST", "/nexmo/status", nil)
+	req.RemoteAddr = "192.0.2.10:1234"
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status: got %d, want 404", rec.Code)
+	}
+}
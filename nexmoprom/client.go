@@ -0,0 +1,121 @@
+package nexmoprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// ClientMetrics implements nexmo.MetricsCollector on top of Prometheus
+// counters, histograms and gauges.
+type ClientMetrics struct {
+	requests      *prometheus.CounterVec
+	retries       *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	responseCodes *prometheus.CounterVec
+	messages      prometheus.Counter
+	parts         prometheus.Counter
+	balance       prometheus.Gauge
+}
+
+var (
+	_ nexmo.MetricsCollector     = (*ClientMetrics)(nil)
+	_ nexmo.SentMessageCollector = (*ClientMetrics)(nil)
+)
+
+// NewClientMetrics creates the API client metrics and registers them with
+// reg. Pass them to a client with nexmo.WithMetricsCollector.
+func NewClientMetrics(reg prometheus.Registerer) (*ClientMetrics, error) {
+	m := &ClientMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "api",
+			Name:      "requests_total",
+			Help:      "Number of requests sent to the Nexmo API, by HTTP status code (0 if no response was received).",
+		}, []string{"endpoint", "status"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "api",
+			Name:      "retries_total",
+			Help:      "Number of requests sent again after being throttled.",
+		}, []string{"endpoint"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "nexmo",
+			Subsystem: "api",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken by requests to the Nexmo API.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		responseCodes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "api",
+			Name:      "response_codes_total",
+			Help:      "Number of statuses reported in Nexmo API responses, by response code.",
+		}, []string{"endpoint", "code"}),
+		messages: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "sms",
+			Name:      "messages_sent_total",
+			Help:      "Number of SMS messages accepted by Nexmo.",
+		}),
+		parts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "sms",
+			Name:      "parts_sent_total",
+			Help:      "Number of SMS message parts accepted by Nexmo.",
+		}),
+		balance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "nexmo",
+			Subsystem: "account",
+			Name:      "balance_euros",
+			Help:      "Balance of the Nexmo account, as of the last balance request.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.retries, m.latency, m.responseCodes, m.messages, m.parts, m.balance} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// RegisterMetrics creates the API client metrics, registers them with reg and
+// makes client report to them.
+func RegisterMetrics(reg prometheus.Registerer, client *nexmo.Client) (*ClientMetrics, error) {
+	m, err := NewClientMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+	client.Metrics = m
+	return m, nil
+}
+
+// RequestDone implements nexmo.MetricsCollector.
+func (m *ClientMetrics) RequestDone(endpoint string, statusCode int, d time.Duration) {
+	m.requests.WithLabelValues(endpoint, strconv.Itoa(statusCode)).Inc()
+	m.latency.WithLabelValues(endpoint).Observe(d.Seconds())
+}
+
+// RequestRetried implements nexmo.MetricsCollector.
+func (m *ClientMetrics) RequestRetried(endpoint string) {
+	m.retries.WithLabelValues(endpoint).Inc()
+}
+
+// ResponseCode implements nexmo.MetricsCollector.
+func (m *ClientMetrics) ResponseCode(endpoint string, code nexmo.ResponseCode) {
+	m.responseCodes.WithLabelValues(endpoint, strconv.Itoa(int(code))).Inc()
+}
+
+// MessageSent implements nexmo.SentMessageCollector.
+func (m *ClientMetrics) MessageSent(parts int) {
+	m.messages.Inc()
+	m.parts.Add(float64(parts))
+}
+
+// Balance implements nexmo.MetricsCollector.
+func (m *ClientMetrics) Balance(euros float64) {
+	m.balance.Set(euros)
+}
@@ -0,0 +1,63 @@
+package nexmoprom
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestClientMetrics(t *testing.T) {
+	client, err := nexmo.NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := `{"message-count":"2","messages":[{"status":"0"},{"status":"1"}]}`
+		if strings.Contains(req.URL.Path, "balance") {
+			body = `{"value":3.5}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+
+	reg := prometheus.NewRegistry()
+	m, err := RegisterMetrics(reg, client)
+	if err != nil {
+		t.Fatal("failed to register metrics:", err)
+	}
+
+	msg := &nexmo.SMSMessage{From: "gonexmo", To: "447700900000", Type: nexmo.Text, Text: "Hello"}
+	if _, err := client.SMS().Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Account().GetBalance(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testutil.ToFloat64(m.requests.WithLabelValues("/sms/json", "200")); n != 1 {
+		t.Errorf("got %v requests, want 1", n)
+	}
+	if n := testutil.ToFloat64(m.responseCodes.WithLabelValues("/sms/json", "1")); n != 1 {
+		t.Errorf("got %v throttled parts, want 1", n)
+	}
+	if n := testutil.ToFloat64(m.messages); n != 1 {
+		t.Errorf("got %v messages sent, want 1", n)
+	}
+	if n := testutil.ToFloat64(m.parts); n != 1 {
+		t.Errorf("got %v parts sent, want 1", n)
+	}
+	if n := testutil.ToFloat64(m.balance); n != 3.5 {
+		t.Errorf("got balance %v, want 3.5", n)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Package nexmoprom exports the metrics of the nexmo package to Prometheus.
+*/
+package nexmoprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// HandlerMetrics implements nexmo.HandlerMetrics on top of Prometheus
+// counters and histograms. Pass it to the webhook handlers with
+// nexmo.WithMetrics.
+type HandlerMetrics struct {
+	received     *prometheus.CounterVec
+	rejected     *prometheus.CounterVec
+	latency      *prometheus.HistogramVec
+	backpressure *prometheus.CounterVec
+}
+
+var _ nexmo.HandlerMetrics = (*HandlerMetrics)(nil)
+
+// NewHandlerMetrics creates the webhook handler metrics and registers them
+// with reg.
+func NewHandlerMetrics(reg prometheus.Registerer) (*HandlerMetrics, error) {
+	m := &HandlerMetrics{
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "webhook",
+			Name:      "callbacks_received_total",
+			Help:      "Number of callbacks received from Nexmo.",
+		}, []string{"handler"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "webhook",
+			Name:      "callbacks_rejected_total",
+			Help:      "Number of callbacks rejected, by reason.",
+		}, []string{"handler", "reason"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "nexmo",
+			Subsystem: "webhook",
+			Name:      "callback_duration_seconds",
+			Help:      "Time taken to handle a callback.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"handler"}),
+		backpressure: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "nexmo",
+			Subsystem: "webhook",
+			Name:      "backpressure_total",
+			Help:      "Number of callbacks the consumer did not keep up with, by outcome.",
+		}, []string{"handler", "outcome"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.received, m.rejected, m.latency, m.backpressure} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// CallbackReceived implements nexmo.HandlerMetrics.
+func (m *HandlerMetrics) CallbackReceived(handler string) {
+	m.received.WithLabelValues(handler).Inc()
+}
+
+// CallbackRejected implements nexmo.HandlerMetrics.
+func (m *HandlerMetrics) CallbackRejected(handler, reason string) {
+	m.rejected.WithLabelValues(handler, reason).Inc()
+}
+
+// CallbackHandled implements nexmo.HandlerMetrics.
+func (m *HandlerMetrics) CallbackHandled(handler string, d time.Duration) {
+	m.latency.WithLabelValues(handler).Observe(d.Seconds())
+}
+
+// Backpressure implements nexmo.HandlerMetrics.
+func (m *HandlerMetrics) Backpressure(handler, outcome string) {
+	m.backpressure.WithLabelValues(handler, outcome).Inc()
+}
@@ -0,0 +1,35 @@
+package nexmoprom
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+func TestHandlerMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewHandlerMetrics(reg)
+	if err != nil {
+		t.Fatal("failed to register metrics:", err)
+	}
+
+	out := make(chan *nexmo.ReceivedMessage, 1)
+	h := nexmo.NewMessageHandler(out, false, nexmo.WithMetrics(m))
+
+	body := url.Values{"type": {"bogus"}}.Encode()
+	req := httptest.NewRequest("POST", "/inbound", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h(httptest.NewRecorder(), req)
+
+	if n := testutil.ToFloat64(m.received.WithLabelValues("message")); n != 1 {
+		t.Errorf("got %v received callbacks, want 1", n)
+	}
+	if n := testutil.ToFloat64(m.rejected.WithLabelValues("message", nexmo.RejectParseError)); n != 1 {
+		t.Errorf("got %v rejected callbacks, want 1", n)
+	}
+}
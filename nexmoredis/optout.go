@@ -0,0 +1,59 @@
+package nexmoredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// OptOutStore implements nexmo.OptOutStore, so numbers which opted out stay
+// opted out across all instances of an application and its restarts:
+//
+//	ar := nexmo.NewAutoResponder(client.SMS())
+//	ar.OptOuts = &nexmoredis.OptOutStore{Client: rdb}
+//
+// Numbers which opted out are saved in a hash, along with the time they did,
+// as JSON.
+type OptOutStore struct {
+	Client redis.UniversalClient
+
+	// Defaults to nexmo:opt_outs.
+	Key string
+
+	// Defaults to nexmo.SystemClock.
+	Clock nexmo.Clock
+}
+
+// optOut is the payload of a number in the hash of an OptOutStore.
+type optOut struct {
+	Time time.Time `json:"time"`
+}
+
+// OptOut implements nexmo.OptOutStore.
+func (s *OptOutStore) OptOut(number string) error {
+	now := nexmo.SystemClock.Now()
+	if s.Clock != nil {
+		now = s.Clock.Now()
+	}
+	return save(s.Client, s.key(), number, optOut{Time: now})
+}
+
+// OptIn implements nexmo.OptOutStore.
+func (s *OptOutStore) OptIn(number string) error {
+	return s.Client.HDel(context.Background(), s.key(), number).Err()
+}
+
+// IsOptedOut implements nexmo.OptOutStore.
+func (s *OptOutStore) IsOptedOut(number string) (bool, error) {
+	var o optOut
+	return get(s.Client, s.key(), number, &o)
+}
+
+func (s *OptOutStore) key() string {
+	if s.Key == "" {
+		return "nexmo:opt_outs"
+	}
+	return s.Key
+}
@@ -0,0 +1,73 @@
+package nexmoredis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// SentCache implements nexmo.SentCache, so messages are deduplicated across
+// all instances of an application and its restarts:
+//
+//	cache := &nexmoredis.SentCache{Client: rdb}
+//	client, err := nexmo.NewClient(key, secret, nexmo.WithDeduplication(cache, 24*time.Hour))
+//
+// Responses are saved as JSON, under a key made of KeyPrefix and a hash of
+// the client reference and recipient, expiring along with the response.
+type SentCache struct {
+	Client redis.UniversalClient
+
+	// Defaults to nexmo:sent:.
+	KeyPrefix string
+}
+
+// sentEntry is the payload of a response in a SentCache.
+type sentEntry struct {
+	Response        *nexmo.MessageResponse `json:"response"`
+	ClientReference string                 `json:"client_ref"`
+}
+
+// Load implements nexmo.SentCache.
+func (c *SentCache) Load(key string) (*nexmo.MessageResponse, error) {
+	payload, err := c.Client.Get(context.Background(), c.key(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var e sentEntry
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+	if e.Response != nil {
+		e.Response.ClientReference = e.ClientReference
+	}
+	return e.Response, nil
+}
+
+// Store implements nexmo.SentCache.
+func (c *SentCache) Store(key string, resp *nexmo.MessageResponse, ttl time.Duration) error {
+	payload, err := json.Marshal(sentEntry{Response: resp, ClientReference: resp.ClientReference})
+	if err != nil {
+		return err
+	}
+	return c.Client.Set(context.Background(), c.key(key), payload, ttl).Err()
+}
+
+// key returns the Redis key of the response stored for key, which may contain
+// bytes awkward to handle in Redis tools.
+func (c *SentCache) key(key string) string {
+	prefix := c.KeyPrefix
+	if prefix == "" {
+		prefix = "nexmo:sent:"
+	}
+	sum := sha256.Sum256([]byte(key))
+	return prefix + hex.EncodeToString(sum[:])
+}
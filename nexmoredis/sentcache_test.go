@@ -0,0 +1,41 @@
+package nexmoredis
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+func TestSentCache(t *testing.T) {
+	c, srv := newRedis(t)
+	cache := &SentCache{Client: c}
+	var _ nexmo.SentCache = cache
+
+	key := "order-42\x00447700900000"
+	resp := &nexmo.MessageResponse{
+		MessageCount:    1,
+		Messages:        []nexmo.MessageReport{{Status: nexmo.ResponseSuccess, MessageID: "0A0000000123ABCD1"}},
+		ClientReference: "order-42",
+	}
+	if err := cache.Store(key, resp, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cache.Load(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ClientReference != "order-42" || len(got.Messages) != 1 ||
+		got.Messages[0].MessageID != "0A0000000123ABCD1" || got.Messages[0].Status != nexmo.ResponseSuccess {
+		t.Errorf("loaded %+v", got)
+	}
+	if got, _ := cache.Load("order-43\x00447700900000"); got != nil {
+		t.Errorf("loaded %+v for another key", got)
+	}
+
+	srv.FastForward(time.Hour)
+	if got, _ := cache.Load(key); got != nil {
+		t.Errorf("loaded expired response %+v", got)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Package nexmoredis implements the storage interfaces of the nexmo package on
+top of Redis, so their state survives restarts and can be shared by several
+instances of an application: Store for inbound messages and receipts,
+SentCache for deduplication and OptOutStore for opt-outs.
+
+	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	store := &nexmoredis.Store{Client: rdb}
+	handler := nexmo.NewMessageHandler(out, true, nexmo.WithStore(store))
+
+Entries are saved as JSON, in hashes or keys prefixed with "nexmo:" by
+default.
+*/
+package nexmoredis
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// Store implements nexmo.Store. Entries are saved as JSON in two hashes,
+// keyed by the message ID, and by the message ID and status for receipts, so
+// callbacks retried by Nexmo are saved once.
+type Store struct {
+	Client redis.UniversalClient
+
+	// Default to nexmo:messages and nexmo:receipts.
+	MessagesKey string
+	ReceiptsKey string
+}
+
+// SaveMessage implements nexmo.Store.
+func (s *Store) SaveMessage(m *nexmo.ReceivedMessage) error {
+	return save(s.Client, s.messagesKey(), m.ID, m)
+}
+
+// SaveReceipt implements nexmo.Store.
+func (s *Store) SaveReceipt(r *nexmo.DeliveryReceipt) error {
+	return save(s.Client, s.receiptsKey(), receiptID(r), r)
+}
+
+// Messages returns the saved messages.
+func (s *Store) Messages() ([]*nexmo.ReceivedMessage, error) {
+	var msgs []*nexmo.ReceivedMessage
+	err := s.load(s.messagesKey(), func(payload []byte) error {
+		m := new(nexmo.ReceivedMessage)
+		msgs = append(msgs, m)
+		return json.Unmarshal(payload, m)
+	})
+	return msgs, err
+}
+
+// Receipts returns the saved receipts.
+func (s *Store) Receipts() ([]*nexmo.DeliveryReceipt, error) {
+	var receipts []*nexmo.DeliveryReceipt
+	err := s.load(s.receiptsKey(), func(payload []byte) error {
+		r := new(nexmo.DeliveryReceipt)
+		receipts = append(receipts, r)
+		return json.Unmarshal(payload, r)
+	})
+	return receipts, err
+}
+
+// DeleteMessage removes m, once it has been processed.
+func (s *Store) DeleteMessage(m *nexmo.ReceivedMessage) error {
+	return s.Client.HDel(context.Background(), s.messagesKey(), m.ID).Err()
+}
+
+// DeleteReceipt removes r, once it has been processed.
+func (s *Store) DeleteReceipt(r *nexmo.DeliveryReceipt) error {
+	return s.Client.HDel(context.Background(), s.receiptsKey(), receiptID(r)).Err()
+}
+
+// save replaces the field id of the hash key with v, encoded as JSON.
+func save(c redis.UniversalClient, key, id string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.HSet(context.Background(), key, id, payload).Err()
+}
+
+// get decodes the field id of the hash key into v. It returns false, and no
+// error, if there is none.
+func get(c redis.UniversalClient, key, id string, v interface{}) (bool, error) {
+	payload, err := c.HGet(context.Background(), key, id).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(payload, v)
+}
+
+// load calls fn with the payload of each field of the hash key, in the order
+// of their IDs.
+func (s *Store) load(key string, fn func(payload []byte) error) error {
+	entries, err := s.Client.HGetAll(context.Background(), key).Result()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := fn([]byte(entries[id])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) messagesKey() string {
+	if s.MessagesKey == "" {
+		return "nexmo:messages"
+	}
+	return s.MessagesKey
+}
+
+func (s *Store) receiptsKey() string {
+	if s.ReceiptsKey == "" {
+		return "nexmo:receipts"
+	}
+	return s.ReceiptsKey
+}
+
+// receiptID returns the field of r.
+func receiptID(r *nexmo.DeliveryReceipt) string {
+	return r.MessageID + ":" + r.Status.String()
+}
@@ -0,0 +1,61 @@
+package nexmoredis
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// newRedis returns a client of an in-memory Redis server, closed at the end
+// of the test.
+func newRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	srv := miniredis.RunT(t)
+	c := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { c.Close() })
+	return c, srv
+}
+
+func TestStore(t *testing.T) {
+	c, srv := newRedis(t)
+	store := &Store{Client: c}
+	var _ nexmo.Store = store
+
+	msg := &nexmo.ReceivedMessage{ID: "0A0000000123ABCD1", To: "447700900000", Text: "Hello"}
+	// Nexmo retrying the callback must not duplicate the message.
+	for i := 0; i < 2; i++ {
+		if err := store.SaveMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receipt := &nexmo.DeliveryReceipt{MessageID: "0A0000000123ABCD1", Status: nexmo.DeliveryDelivered}
+	if err := store.SaveReceipt(receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := store.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != msg.ID || msgs[0].Text != "Hello" {
+		t.Errorf("got messages %+v", msgs)
+	}
+	receipts, err := store.Receipts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 1 || receipts[0].Status != nexmo.DeliveryDelivered {
+		t.Errorf("got receipts %+v", receipts)
+	}
+	if !srv.Exists("nexmo:receipts") {
+		t.Errorf("receipts saved in keys %q", srv.Keys())
+	}
+
+	if err := store.DeleteMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	if msgs, _ := store.Messages(); len(msgs) != 0 {
+		t.Errorf("got messages %+v after deleting", msgs)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Package nexmoslog adapts log/slog to the Logger interface of the nexmo
+package:
+
+	client, err := nexmo.NewClient(key, secret,
+		nexmo.WithLogger(nexmoslog.New(slog.Default())))
+*/
+package nexmoslog
+
+import (
+	"context"
+	"log/slog"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// Logger implements nexmo.Logger on top of a slog.Logger.
+type Logger struct {
+	Logger *slog.Logger
+
+	// Records below this level are dropped. Defaults to nexmo.LogDebug,
+	// leaving the decision to the handler of Logger.
+	MinLevel nexmo.LogLevel
+
+	// Prefixed to the keys of the fields, e.g. "nexmo." Defaults to none.
+	KeyPrefix string
+}
+
+var _ nexmo.Logger = (*Logger)(nil)
+
+// New creates a Logger writing to l.
+func New(l *slog.Logger) *Logger {
+	return &Logger{Logger: l}
+}
+
+// Log implements nexmo.Logger.
+func (l *Logger) Log(level nexmo.LogLevel, msg string, fields ...nexmo.LogField) {
+	if level < l.MinLevel {
+		return
+	}
+
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(l.KeyPrefix+f.Key, f.Value)
+	}
+	l.Logger.LogAttrs(context.Background(), Level(level), msg, attrs...)
+}
+
+// Level maps a nexmo.LogLevel to the corresponding slog.Level.
+func Level(level nexmo.LogLevel) slog.Level {
+	switch level {
+	case nexmo.LogDebug:
+		return slog.LevelDebug
+	case nexmo.LogWarn:
+		return slog.LevelWarn
+	case nexmo.LogError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,54 @@
+package nexmoslog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	l.KeyPrefix = "nexmo."
+
+	client, err := nexmo.NewClient("k3y", "s3cr3t", nexmo.WithLogger(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"value":3.5}`)),
+		}, nil
+	})}
+
+	if _, err := client.Account().GetBalance(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"level=DEBUG", `msg="nexmo request"`, "nexmo.method=GET", "nexmo.status=200"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not logged in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("secret logged in %q", out)
+	}
+
+	buf.Reset()
+	l.MinLevel = nexmo.LogInfo
+	client.Account().GetBalance()
+	if buf.Len() != 0 {
+		t.Errorf("debug record logged with MinLevel info: %q", buf.String())
+	}
+}
@@ -0,0 +1,66 @@
+package nexmosql
+
+import (
+	"database/sql"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// OptOutSchema creates the table used by an OptOutStore with the default
+// table name.
+const OptOutSchema = `
+CREATE TABLE nexmo_opt_outs (
+	id      VARCHAR(32) PRIMARY KEY,
+	payload TEXT NOT NULL
+);`
+
+// OptOutStore implements nexmo.OptOutStore, so numbers which opted out stay
+// opted out across all instances of an application and its restarts:
+//
+//	ar := nexmo.NewAutoResponder(client.SMS())
+//	ar.OptOuts = &nexmosql.OptOutStore{DB: db, Placeholder: nexmosql.Dollar}
+//
+// Numbers which opted out are saved along with the time they did, as JSON.
+type OptOutStore struct {
+	DB          *sql.DB
+	Placeholder Placeholder
+
+	// Defaults to nexmo_opt_outs.
+	Table string
+
+	// Defaults to nexmo.SystemClock.
+	Clock nexmo.Clock
+}
+
+// optOut is the payload of a number in the table of an OptOutStore.
+type optOut struct {
+	Time time.Time `json:"time"`
+}
+
+// OptOut implements nexmo.OptOutStore.
+func (s *OptOutStore) OptOut(number string) error {
+	now := nexmo.SystemClock.Now()
+	if s.Clock != nil {
+		now = s.Clock.Now()
+	}
+	return save(s.DB, s.Placeholder, s.table(), number, optOut{Time: now})
+}
+
+// OptIn implements nexmo.OptOutStore.
+func (s *OptOutStore) OptIn(number string) error {
+	return remove(s.DB, s.Placeholder, s.table(), number)
+}
+
+// IsOptedOut implements nexmo.OptOutStore.
+func (s *OptOutStore) IsOptedOut(number string) (bool, error) {
+	var o optOut
+	return get(s.DB, s.Placeholder, s.table(), number, &o)
+}
+
+func (s *OptOutStore) table() string {
+	if s.Table == "" {
+		return "nexmo_opt_outs"
+	}
+	return s.Table
+}
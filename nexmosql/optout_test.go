@@ -0,0 +1,33 @@
+package nexmosql
+
+import (
+	"testing"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+func TestOptOutStore(t *testing.T) {
+	db, _ := newMemDB()
+	store := &OptOutStore{DB: db, Placeholder: QuestionMark}
+	var _ nexmo.OptOutStore = store
+
+	const number = "447700900001"
+	for i := 0; i < 2; i++ {
+		if err := store.OptOut(number); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, err := store.IsOptedOut(number); err != nil || !out {
+		t.Errorf("opted out number: got %v, %v", out, err)
+	}
+	if out, err := store.IsOptedOut("447700900002"); err != nil || out {
+		t.Errorf("other number: got %v, %v", out, err)
+	}
+
+	if err := store.OptIn(number); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := store.IsOptedOut(number); out {
+		t.Error("number is still opted out after opting in")
+	}
+}
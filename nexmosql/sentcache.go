@@ -0,0 +1,119 @@
+package nexmosql
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// SentCacheSchema creates the table used by a SentCache with the default
+// table name.
+const SentCacheSchema = `
+CREATE TABLE nexmo_sent (
+	id      VARCHAR(64) PRIMARY KEY,
+	payload TEXT NOT NULL,
+	expires BIGINT NOT NULL
+);`
+
+// SentCache implements nexmo.SentCache, so messages are deduplicated across
+// all instances of an application and its restarts:
+//
+//	cache := &nexmosql.SentCache{DB: db, Placeholder: nexmosql.Dollar}
+//	client, err := nexmo.NewClient(key, secret, nexmo.WithDeduplication(cache, 24*time.Hour))
+//
+// Responses are saved as JSON, keyed by a hash of the client reference and
+// recipient, along with the Unix time they expire at. Expired responses are
+// ignored; call DeleteExpired every now and then to get rid of them.
+type SentCache struct {
+	DB          *sql.DB
+	Placeholder Placeholder
+
+	// Defaults to nexmo_sent.
+	Table string
+
+	// Defaults to nexmo.SystemClock.
+	Clock nexmo.Clock
+}
+
+// sentEntry is the payload of a response in the table of a SentCache.
+type sentEntry struct {
+	Response        *nexmo.MessageResponse `json:"response"`
+	ClientReference string                 `json:"client_ref"`
+}
+
+// Load implements nexmo.SentCache.
+func (c *SentCache) Load(key string) (*nexmo.MessageResponse, error) {
+	var payload []byte
+	err := c.DB.QueryRow(c.Placeholder.query("SELECT payload FROM %s WHERE id = %s AND expires > %s", c.table(), 2),
+		sentID(key), c.now().Unix()).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var e sentEntry
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+	if e.Response != nil {
+		e.Response.ClientReference = e.ClientReference
+	}
+	return e.Response, nil
+}
+
+// Store implements nexmo.SentCache.
+func (c *SentCache) Store(key string, resp *nexmo.MessageResponse, ttl time.Duration) error {
+	payload, err := json.Marshal(sentEntry{Response: resp, ClientReference: resp.ClientReference})
+	if err != nil {
+		return err
+	}
+	id := sentID(key)
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(c.Placeholder.query("DELETE FROM %s WHERE id = %s", c.table(), 1), id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(c.Placeholder.query("INSERT INTO %s (id, payload, expires) VALUES (%s, %s, %s)", c.table(), 3),
+		id, string(payload), c.now().Add(ttl).Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteExpired removes the expired responses.
+func (c *SentCache) DeleteExpired() error {
+	_, err := c.DB.Exec(c.Placeholder.query("DELETE FROM %s WHERE expires <= %s", c.table(), 1), c.now().Unix())
+	return err
+}
+
+func (c *SentCache) table() string {
+	if c.Table == "" {
+		return "nexmo_sent"
+	}
+	return c.Table
+}
+
+func (c *SentCache) now() time.Time {
+	if c.Clock == nil {
+		return nexmo.SystemClock.Now()
+	}
+	return c.Clock.Now()
+}
+
+// sentID returns the key of the response stored for key, which may contain
+// bytes not allowed in text columns.
+func sentID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package nexmosql
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+	"gopkg.in/njern/gonexmo.v2/nexmotest"
+)
+
+func TestSentCache(t *testing.T) {
+	db, d := newMemDB()
+	clock := nexmotest.NewClock(time.Unix(1500000000, 0))
+	cache := &SentCache{DB: db, Placeholder: Dollar, Clock: clock}
+	var _ nexmo.SentCache = cache
+
+	key := "order-42\x00447700900000"
+	resp := &nexmo.MessageResponse{
+		MessageCount:    1,
+		Messages:        []nexmo.MessageReport{{Status: nexmo.ResponseSuccess, MessageID: "0A0000000123ABCD1"}},
+		ClientReference: "order-42",
+	}
+	if err := cache.Store(key, resp, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cache.Load(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ClientReference != "order-42" || len(got.Messages) != 1 ||
+		got.Messages[0].MessageID != "0A0000000123ABCD1" || got.Messages[0].Status != nexmo.ResponseSuccess {
+		t.Errorf("loaded %+v", got)
+	}
+	if got, _ := cache.Load("order-43\x00447700900000"); got != nil {
+		t.Errorf("loaded %+v for another key", got)
+	}
+
+	clock.Advance(time.Hour)
+	if got, _ := cache.Load(key); got != nil {
+		t.Errorf("loaded expired response %+v", got)
+	}
+	if err := cache.DeleteExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(d.tables["nexmo_sent"]); n != 0 {
+		t.Errorf("%d responses left after deleting the expired ones", n)
+	}
+}
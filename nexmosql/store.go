@@ -0,0 +1,194 @@
+/*
+Package nexmosql implements the storage interfaces of the nexmo package on top
+of database/sql, so their state survives restarts and can be shared by several
+instances of an application: Store for inbound messages and receipts,
+SentCache for deduplication and OptOutStore for opt-outs.
+
+	db, err := sql.Open("postgres", dsn)
+	...
+	store := &nexmosql.Store{DB: db, Placeholder: nexmosql.Dollar}
+	handler := nexmo.NewMessageHandler(out, true, nexmo.WithStore(store))
+
+The tables are created with the statements in Schema, SentCacheSchema and
+OptOutSchema. The nexmoredis package implements the same interfaces on top
+of Redis.
+*/
+package nexmosql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// Schema creates the tables used by a Store with the default table names. It
+// is plain SQL accepted by PostgreSQL, MySQL and SQLite.
+const Schema = `
+CREATE TABLE nexmo_messages (
+	id      VARCHAR(64) PRIMARY KEY,
+	payload TEXT NOT NULL
+);
+CREATE TABLE nexmo_receipts (
+	id      VARCHAR(128) PRIMARY KEY,
+	payload TEXT NOT NULL
+);`
+
+// Placeholder is the way parameters are written in the statements of a
+// database.
+type Placeholder int
+
+// Placeholder styles
+const (
+	QuestionMark Placeholder = iota // ?, used by MySQL and SQLite
+	Dollar                          // $1, used by PostgreSQL
+)
+
+// Store implements nexmo.Store. Entries are saved as JSON, keyed by the
+// message ID, and by the message ID and status for receipts, so callbacks
+// retried by Nexmo are saved once.
+type Store struct {
+	DB          *sql.DB
+	Placeholder Placeholder
+
+	// Default to nexmo_messages and nexmo_receipts.
+	MessagesTable string
+	ReceiptsTable string
+}
+
+// SaveMessage implements nexmo.Store.
+func (s *Store) SaveMessage(m *nexmo.ReceivedMessage) error {
+	return save(s.DB, s.Placeholder, s.messagesTable(), m.ID, m)
+}
+
+// SaveReceipt implements nexmo.Store.
+func (s *Store) SaveReceipt(r *nexmo.DeliveryReceipt) error {
+	return save(s.DB, s.Placeholder, s.receiptsTable(), receiptID(r), r)
+}
+
+// Messages returns the saved messages.
+func (s *Store) Messages() ([]*nexmo.ReceivedMessage, error) {
+	var msgs []*nexmo.ReceivedMessage
+	err := s.load(s.messagesTable(), func(payload []byte) error {
+		m := new(nexmo.ReceivedMessage)
+		msgs = append(msgs, m)
+		return json.Unmarshal(payload, m)
+	})
+	return msgs, err
+}
+
+// Receipts returns the saved receipts.
+func (s *Store) Receipts() ([]*nexmo.DeliveryReceipt, error) {
+	var receipts []*nexmo.DeliveryReceipt
+	err := s.load(s.receiptsTable(), func(payload []byte) error {
+		r := new(nexmo.DeliveryReceipt)
+		receipts = append(receipts, r)
+		return json.Unmarshal(payload, r)
+	})
+	return receipts, err
+}
+
+// DeleteMessage removes m, once it has been processed.
+func (s *Store) DeleteMessage(m *nexmo.ReceivedMessage) error {
+	return remove(s.DB, s.Placeholder, s.messagesTable(), m.ID)
+}
+
+// DeleteReceipt removes r, once it has been processed.
+func (s *Store) DeleteReceipt(r *nexmo.DeliveryReceipt) error {
+	return remove(s.DB, s.Placeholder, s.receiptsTable(), receiptID(r))
+}
+
+// save replaces the entry of table keyed by id with v, encoded as JSON.
+func save(db *sql.DB, p Placeholder, table, id string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(p.query("DELETE FROM %s WHERE id = %s", table, 1), id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(p.query("INSERT INTO %s (id, payload) VALUES (%s, %s)", table, 2), id, string(payload)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// get decodes the entry of table keyed by id into v. It returns false, and
+// no error, if there is none.
+func get(db *sql.DB, p Placeholder, table, id string, v interface{}) (bool, error) {
+	var payload []byte
+	err := db.QueryRow(p.query("SELECT payload FROM %s WHERE id = %s", table, 1), id).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(payload, v)
+}
+
+// remove deletes the entry of table keyed by id, if any.
+func remove(db *sql.DB, p Placeholder, table, id string) error {
+	_, err := db.Exec(p.query("DELETE FROM %s WHERE id = %s", table, 1), id)
+	return err
+}
+
+// load calls fn with the payload of each entry of table.
+func (s *Store) load(table string, fn func(payload []byte) error) error {
+	rows, err := s.DB.Query(fmt.Sprintf("SELECT payload FROM %s ORDER BY id", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return err
+		}
+		if err := fn(payload); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// query formats a statement on table taking n parameters.
+func (p Placeholder) query(format, table string, n int) string {
+	args := []interface{}{table}
+	for i := 1; i <= n; i++ {
+		if p == Dollar {
+			args = append(args, fmt.Sprintf("$%d", i))
+		} else {
+			args = append(args, "?")
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func (s *Store) messagesTable() string {
+	if s.MessagesTable == "" {
+		return "nexmo_messages"
+	}
+	return s.MessagesTable
+}
+
+func (s *Store) receiptsTable() string {
+	if s.ReceiptsTable == "" {
+		return "nexmo_receipts"
+	}
+	return s.ReceiptsTable
+}
+
+// receiptID returns the key of r.
+func receiptID(r *nexmo.DeliveryReceipt) string {
+	return r.MessageID + ":" + r.Status.String()
+}
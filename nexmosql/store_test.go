@@ -0,0 +1,170 @@
+package nexmosql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// memDriver is a database/sql driver understanding the statements of the
+// stores of this package, keeping tables in memory.
+type memDriver struct {
+	mu      sync.Mutex
+	tables  map[string]map[string]memRow
+	queries []string
+}
+
+type memRow struct {
+	payload string
+	expires int64
+}
+
+// newMemDB returns a database backed by a new memDriver.
+func newMemDB() (*sql.DB, *memDriver) {
+	d := &memDriver{tables: make(map[string]map[string]memRow)}
+	return sql.OpenDB(d), d
+}
+
+func (d *memDriver) Open(name string) (driver.Conn, error)        { return &memConn{d}, nil }
+func (d *memDriver) Connect(context.Context) (driver.Conn, error) { return &memConn{d}, nil }
+func (d *memDriver) Driver() driver.Driver                        { return d }
+
+type memConn struct{ d *memDriver }
+
+func (c *memConn) Prepare(query string) (driver.Stmt, error) { return &memStmt{c.d, query}, nil }
+func (c *memConn) Close() error                              { return nil }
+func (c *memConn) Begin() (driver.Tx, error)                 { return memTx{}, nil }
+
+type memTx struct{}
+
+func (memTx) Commit() error   { return nil }
+func (memTx) Rollback() error { return nil }
+
+type memStmt struct {
+	d     *memDriver
+	query string
+}
+
+func (s *memStmt) Close() error  { return nil }
+func (s *memStmt) NumInput() int { return -1 }
+
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.queries = append(s.d.queries, s.query)
+	fields := strings.Fields(s.query)
+	switch {
+	case fields[0] == "DELETE" && fields[4] == "expires":
+		for id, row := range s.d.tables[fields[2]] {
+			if row.expires <= args[0].(int64) {
+				delete(s.d.tables[fields[2]], id)
+			}
+		}
+	case fields[0] == "DELETE":
+		delete(s.d.tables[fields[2]], args[0].(string))
+	case fields[0] == "INSERT":
+		table := s.d.tables[fields[2]]
+		if table == nil {
+			table = make(map[string]memRow)
+			s.d.tables[fields[2]] = table
+		}
+		row := memRow{payload: args[1].(string)}
+		if len(args) > 2 {
+			row.expires = args[2].(int64)
+		}
+		table[args[0].(string)] = row
+	default:
+		return nil, errors.New("unexpected statement " + s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	fields := strings.Fields(s.query)
+	table := s.d.tables[fields[3]]
+	var ids []string
+	for id, row := range table {
+		if len(args) > 0 && id != args[0].(string) {
+			continue
+		}
+		if len(args) > 1 && row.expires <= args[1].(int64) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rows := &memRows{}
+	for _, id := range ids {
+		rows.payloads = append(rows.payloads, table[id].payload)
+	}
+	return rows, nil
+}
+
+type memRows struct{ payloads []string }
+
+func (r *memRows) Columns() []string { return []string{"payload"} }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.payloads) == 0 {
+		return io.EOF
+	}
+	dest[0] = []byte(r.payloads[0])
+	r.payloads = r.payloads[1:]
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	db, d := newMemDB()
+	store := &Store{DB: db, Placeholder: Dollar}
+	var _ nexmo.Store = store
+
+	msg := &nexmo.ReceivedMessage{ID: "0A0000000123ABCD1", To: "447700900000", Text: "Hello"}
+	// Nexmo retrying the callback must not duplicate the message.
+	for i := 0; i < 2; i++ {
+		if err := store.SaveMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receipt := &nexmo.DeliveryReceipt{MessageID: "0A0000000123ABCD1", Status: nexmo.DeliveryDelivered}
+	if err := store.SaveReceipt(receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := store.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != msg.ID || msgs[0].Text != "Hello" {
+		t.Errorf("got messages %+v", msgs)
+	}
+	receipts, err := store.Receipts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 1 || receipts[0].Status != nexmo.DeliveryDelivered {
+		t.Errorf("got receipts %+v", receipts)
+	}
+
+	if err := store.DeleteMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	if msgs, _ := store.Messages(); len(msgs) != 0 {
+		t.Errorf("got messages %+v after deleting", msgs)
+	}
+	if want := "INSERT INTO nexmo_messages (id, payload) VALUES ($1, $2)"; d.queries[1] != want {
+		t.Errorf("got statement %q, want %q", d.queries[1], want)
+	}
+}
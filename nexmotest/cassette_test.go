@@ -0,0 +1,67 @@
+package nexmotest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"value": 12.5}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	url := srv.URL + "/account/get-balance?api_key=k3y&api_secret=s3cr3t"
+
+	recorder, err := NewCassette(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: recorder}).Post(url, "application/json",
+		strings.NewReader(`{"api_key":"k3y","api_secret":"s3cr3t","to":"447700900000"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "s3cr3t") || strings.Contains(string(buf), "k3y") {
+		t.Errorf("secrets were not scrubbed from the cassette:\n%s", buf)
+	}
+
+	// Replay once the server is gone.
+	srv.Close()
+	player, err := NewCassette(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: player}
+	resp, err = client.Post(url, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != `{"value": 12.5}` {
+		t.Errorf("unexpected replayed response %d %s", resp.StatusCode, body)
+	}
+	if err := player.Done(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := client.Get(url); err == nil {
+		t.Error("request beyond the end of the cassette succeeded")
+	}
+}
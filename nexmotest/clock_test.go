@@ -0,0 +1,52 @@
+package nexmotest
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+var _ nexmo.Clock = (*Clock)(nil)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2018, 8, 6, 12, 0, 0, 0, time.UTC)
+	c := NewClock(start)
+
+	ch := c.After(time.Minute)
+	c.Advance(30 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("fired early")
+	default:
+	}
+
+	c.Advance(30 * time.Second)
+	select {
+	case now := <-ch:
+		if !now.Equal(start.Add(time.Minute)) {
+			t.Errorf("fired at %v", now)
+		}
+	default:
+		t.Fatal("did not fire")
+	}
+	if c.Waiters() != 0 {
+		t.Errorf("got %d waiters, want 0", c.Waiters())
+	}
+}
+
+func TestClockReplayProtection(t *testing.T) {
+	c := NewClock(time.Unix(1533556800, 0))
+	r := &nexmo.ReplayProtection{Clock: c}
+
+	fields := map[string][]string{"timestamp": {"1533556800"}, "nonce": {"abc"}}
+	if _, err := r.Check(fields); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Advance(time.Hour)
+	fields["nonce"] = []string{"def"}
+	if _, err := r.Check(fields); err != nexmo.ErrStaleTimestamp {
+		t.Errorf("got %v, want %v", err, nexmo.ErrStaleTimestamp)
+	}
+}
@@ -0,0 +1,76 @@
+package nexmotest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+func TestInboundMessageRequest(t *testing.T) {
+	for _, enc := range []Encoding{Form, Query, JSON} {
+		out := make(chan *nexmo.ReceivedMessage, 1)
+		h := nexmo.NewMessageHandler(out, true)
+
+		w := httptest.NewRecorder()
+		h(w, NewInboundMessageRequest(&nexmo.ReceivedMessage{
+			Type: nexmo.TextMessage,
+			Text: "Stop it & go away",
+		}, WithEncoding(enc)))
+
+		if w.Code != 200 || len(out) != 1 {
+			t.Errorf("encoding %d: message was rejected with status %d", enc, w.Code)
+			continue
+		}
+		if m := <-out; m.Text != "Stop it & go away" || m.Keyword != "STOP" {
+			t.Errorf("encoding %d: unexpected message %#v", enc, m)
+		}
+	}
+}
+
+func TestDeliveryReceiptRequest(t *testing.T) {
+	for _, enc := range []Encoding{Form, Query, JSON} {
+		r, err := nexmo.ParseDeliveryReceipt(NewDeliveryReceiptRequest(&nexmo.DeliveryReceipt{
+			MessageID: "0A0000000123ABCD1",
+			Status:    nexmo.DeliveryFailed,
+			ErrorCode: 2,
+		}, WithEncoding(enc)))
+		if err != nil {
+			t.Errorf("encoding %d: failed to parse receipt: %v", enc, err)
+			continue
+		}
+		if r.MessageID != "0A0000000123ABCD1" || r.Status != nexmo.DeliveryFailed || r.ErrorCode != 2 {
+			t.Errorf("encoding %d: unexpected receipt %#v", enc, r)
+		}
+	}
+}
+
+func TestSignedRequest(t *testing.T) {
+	req := NewDeliveryReceiptRequest(&nexmo.DeliveryReceipt{},
+		Signed("secret", nexmo.SignatureSHA256), WithTimestamp(time.Unix(1533556800, 0)))
+	req.ParseForm()
+
+	if req.Form.Get("timestamp") != "1533556800" || req.Form.Get("nonce") == "" {
+		t.Errorf("missing timestamp or nonce in %v", req.Form)
+	}
+
+	want, _ := nexmo.Sign(req.Form, "secret", nexmo.SignatureSHA256)
+	if sig := req.Form.Get("sig"); sig != want {
+		t.Errorf("got signature %q, want %q", sig, want)
+	}
+}
+
+func TestVerifyEventRequest(t *testing.T) {
+	e, err := nexmo.ParseVerifyEvent(NewVerifyEventRequest(&nexmo.VerifyEvent{
+		RequestID: "abcdef0123456789abcdef0123456789",
+		Type:      "summary",
+		Status:    "completed",
+	}))
+	if err != nil {
+		t.Fatal("failed to parse verify event:", err)
+	}
+	if e.Status != "completed" {
+		t.Errorf("unexpected verify event %#v", e)
+	}
+}
@@ -0,0 +1,177 @@
+package nexmotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// SentMessage is a message sent through an SMSC.
+type SentMessage struct {
+	MessageID       string
+	From            string
+	To              string
+	Text            string
+	ClientReference string
+}
+
+// SMSC fakes the SMS API for a Client and delivers receipts for the messages
+// sent through it to the delivery receipt handler under test, so the whole
+// path from sending a message to processing its receipt can be tested:
+//
+//	smsc := nexmotest.NewSMSC(nexmo.NewDeliveryHandler(receipts, false))
+//	client.HTTPClient = &http.Client{Transport: smsc}
+//	resp, _ := client.SMS().Send(msg)
+//	smsc.Wait()
+//	// receipts now holds the receipt for resp.Messages[0].MessageID
+//
+// Receipts are POSTed as form encoded callbacks from NexmoRemoteAddr.
+type SMSC struct {
+	// The delivery receipt handler under test.
+	Handler http.Handler
+
+	// Status reported in receipts. Defaults to nexmo.DeliveryDelivered.
+	Status nexmo.DeliveryStatus
+
+	// Error code reported in receipts.
+	ErrorCode nexmo.DLRErrorCode
+
+	// Time between sending a message and its receipt. Receipts are delivered
+	// synchronously if zero.
+	Latency time.Duration
+
+	// Clock used to wait for Latency. Defaults to nexmo.SystemClock.
+	Clock nexmo.Clock
+
+	mu       sync.Mutex
+	sent     []SentMessage
+	pending  sync.WaitGroup
+	statuses []int
+}
+
+// NewSMSC creates an SMSC delivering receipts to h.
+func NewSMSC(h http.Handler) *SMSC {
+	return &SMSC{Handler: h}
+}
+
+// RoundTrip implements http.RoundTripper, answering requests to the SMS API.
+func (s *SMSC) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, "/sms/json") {
+		return nil, fmt.Errorf("nexmotest: SMSC does not handle %s", req.URL)
+	}
+
+	var msg struct {
+		From            string `json:"from"`
+		To              string `json:"to"`
+		Text            string `json:"text"`
+		ClientReference string `json:"client-ref"`
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+
+	sent := SentMessage{
+		MessageID:       randomID(),
+		From:            msg.From,
+		To:              msg.To,
+		Text:            msg.Text,
+		ClientReference: msg.ClientReference,
+	}
+	s.mu.Lock()
+	s.sent = append(s.sent, sent)
+	s.mu.Unlock()
+
+	s.pending.Add(1)
+	if s.Latency > 0 {
+		after := s.clock().After(s.Latency)
+		go func() {
+			<-after
+			s.deliver(sent)
+		}()
+	} else {
+		s.deliver(sent)
+	}
+
+	resp, err := json.Marshal(&nexmo.MessageResponse{
+		MessageCount: 1,
+		Messages: []nexmo.MessageReport{{
+			Status:          nexmo.ResponseSuccess,
+			MessageID:       sent.MessageID,
+			To:              sent.To,
+			ClientReference: sent.ClientReference,
+			MessagePrice:    "0.03330000",
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	rec.Write(resp)
+	return rec.Result(), nil
+}
+
+func (s *SMSC) clock() nexmo.Clock {
+	if s.Clock == nil {
+		return nexmo.SystemClock
+	}
+	return s.Clock
+}
+
+// deliver POSTs the receipt for m to the handler.
+func (s *SMSC) deliver(m SentMessage) {
+	defer s.pending.Done()
+
+	now := s.clock().Now()
+	req := NewDeliveryReceiptRequest(&nexmo.DeliveryReceipt{
+		To:              m.From,
+		MSISDN:          m.To,
+		MessageID:       m.MessageID,
+		Status:          s.Status,
+		ErrorCode:       s.ErrorCode,
+		SCTS:            now,
+		Timestamp:       now,
+		ClientReference: m.ClientReference,
+	})
+
+	rec := httptest.NewRecorder()
+	s.Handler.ServeHTTP(rec, req)
+
+	s.mu.Lock()
+	s.statuses = append(s.statuses, rec.Code)
+	s.mu.Unlock()
+}
+
+// Wait blocks until the receipts of all messages sent so far have been
+// delivered.
+func (s *SMSC) Wait() {
+	s.pending.Wait()
+}
+
+// Sent returns the messages sent so far.
+func (s *SMSC) Sent() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.sent...)
+}
+
+// ReceiptStatuses returns the status codes the handler answered the receipts
+// delivered so far with.
+func (s *SMSC) ReceiptStatuses() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.statuses...)
+}
@@ -0,0 +1,59 @@
+package nexmotest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+func TestSMSC(t *testing.T) {
+	receipts := make(chan *nexmo.DeliveryReceipt, 1)
+	smsc := NewSMSC(nexmo.NewDeliveryHandler(receipts, true))
+	smsc.Status = nexmo.DeliveryFailed
+	smsc.ErrorCode = 1
+	smsc.Latency = time.Minute
+	clock := NewClock(time.Date(2018, 8, 6, 12, 0, 0, 0, time.UTC))
+	smsc.Clock = clock
+
+	client, err := nexmo.NewClient("key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: smsc}
+
+	resp, err := client.SMS().Send(&nexmo.SMSMessage{
+		From:            "gonexmo",
+		To:              "447700900000",
+		Type:            nexmo.Text,
+		Text:            "Hello",
+		ClientReference: "ref-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-receipts:
+		t.Fatal("receipt was delivered before the latency elapsed")
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	smsc.Wait()
+
+	r := <-receipts
+	if r.MessageID != resp.Messages[0].MessageID || r.ClientReference != "ref-1" {
+		t.Errorf("receipt %#v does not match the sent message %#v", r, resp.Messages[0])
+	}
+	if r.Status != nexmo.DeliveryFailed || r.ErrorCode != 1 {
+		t.Errorf("got status %v, error code %v", r.Status, r.ErrorCode)
+	}
+	if codes := smsc.ReceiptStatuses(); len(codes) != 1 || codes[0] != http.StatusOK {
+		t.Errorf("handler answered receipts with %v", codes)
+	}
+	if sent := smsc.Sent(); len(sent) != 1 || sent[0].To != "447700900000" {
+		t.Errorf("unexpected sent messages %#v", sent)
+	}
+}
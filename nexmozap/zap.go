@@ -0,0 +1,63 @@
+/*
+Package nexmozap adapts go.uber.org/zap to the Logger interface of the nexmo
+package:
+
+	client, err := nexmo.NewClient(key, secret,
+		nexmo.WithLogger(nexmozap.New(zapLogger)))
+*/
+package nexmozap
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+// Logger implements nexmo.Logger on top of a zap.Logger.
+type Logger struct {
+	Logger *zap.Logger
+
+	// Records below this level are dropped. Defaults to nexmo.LogDebug,
+	// leaving the decision to the core of Logger.
+	MinLevel nexmo.LogLevel
+
+	// Prefixed to the keys of the fields, e.g. "nexmo." Defaults to none.
+	KeyPrefix string
+}
+
+var _ nexmo.Logger = (*Logger)(nil)
+
+// New creates a Logger writing to l.
+func New(l *zap.Logger) *Logger {
+	return &Logger{Logger: l}
+}
+
+// Log implements nexmo.Logger.
+func (l *Logger) Log(level nexmo.LogLevel, msg string, fields ...nexmo.LogField) {
+	if level < l.MinLevel {
+		return
+	}
+
+	ce := l.Logger.Check(Level(level), msg)
+	if ce == nil {
+		return
+	}
+	zfields := make([]zap.Field, len(fields))
+	for i, f := range fields {
+		zfields[i] = zap.Any(l.KeyPrefix+f.Key, f.Value)
+	}
+	ce.Write(zfields...)
+}
+
+// Level maps a nexmo.LogLevel to the corresponding zapcore.Level.
+func Level(level nexmo.LogLevel) zapcore.Level {
+	switch level {
+	case nexmo.LogDebug:
+		return zapcore.DebugLevel
+	case nexmo.LogWarn:
+		return zapcore.WarnLevel
+	case nexmo.LogError:
+		return zapcore.ErrorLevel
+	}
+	return zapcore.InfoLevel
+}
@@ -0,0 +1,61 @@
+package nexmozap
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/njern/gonexmo.v2"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := New(zap.New(core))
+	l.KeyPrefix = "nexmo."
+
+	client, err := nexmo.NewClient("k3y", "s3cr3t", nexmo.WithLogger(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"value":3.5}`)),
+		}, nil
+	})}
+
+	if _, err := client.Account().GetBalance(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.TakeAll()
+	if len(entries) == 0 {
+		t.Fatal("nothing logged")
+	}
+	e := entries[0]
+	fields := e.ContextMap()
+	if e.Level != zapcore.DebugLevel || e.Message != "nexmo request" || fields["nexmo.method"] != "GET" {
+		t.Errorf("logged %v %q %v", e.Level, e.Message, fields)
+	}
+	for _, e := range entries {
+		for k, v := range e.ContextMap() {
+			if s, ok := v.(string); ok && strings.Contains(s, "s3cr3t") {
+				t.Errorf("secret logged in %s: %q", k, s)
+			}
+		}
+	}
+
+	l.MinLevel = nexmo.LogInfo
+	client.Account().GetBalance()
+	if n := logs.Len(); n != 0 {
+		t.Errorf("%d debug records logged with MinLevel info", n)
+	}
+}
@@ -0,0 +1,59 @@
+package nexmo
+
+import "strings"
+
+// Replaced by NormalizeText: typographic punctuation with its ASCII
+// equivalent, unusual spaces with a plain space, and invisible characters
+// with nothing.
+var normalizeReplacer = strings.NewReplacer(
+	"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u201b", "'", "\u2032", "'",
+	"\u201c", `"`, "\u201d", `"`, "\u201e", `"`, "\u201f", `"`, "\u2033", `"`,
+	"\u00ab", `"`, "\u00bb", `"`,
+	"\u2010", "-", "\u2011", "-", "\u2012", "-", "\u2013", "-", "\u2014", "-", "\u2015", "-", "\u2212", "-",
+	"\u00a0", " ", "\u2002", " ", "\u2003", " ", "\u2009", " ", "\u202f", " ", "\u3000", " ",
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "",
+)
+
+// Combining diacritics following a base letter which compose to a letter of
+// the GSM-7 alphabet. Other letters are left decomposed.
+var gsm7Compositions = map[[2]rune]rune{
+	{'a', '\u0300'}: 'à', {'e', '\u0300'}: 'è', {'i', '\u0300'}: 'ì', {'o', '\u0300'}: 'ò', {'u', '\u0300'}: 'ù',
+	{'e', '\u0301'}: 'é', {'E', '\u0301'}: 'É',
+	{'a', '\u0308'}: 'ä', {'o', '\u0308'}: 'ö', {'u', '\u0308'}: 'ü',
+	{'A', '\u0308'}: 'Ä', {'O', '\u0308'}: 'Ö', {'U', '\u0308'}: 'Ü',
+	{'n', '\u0303'}: 'ñ', {'N', '\u0303'}: 'Ñ',
+	{'a', '\u030a'}: 'å', {'A', '\u030a'}: 'Å',
+	{'C', '\u0327'}: 'Ç',
+	{'o', '\u0338'}: 'ø', {'O', '\u0338'}: 'Ø',
+}
+
+// NormalizeText rewrites text to keep it within the GSM-7 alphabet where this
+// does not change its meaning:
+//   - letters with decomposed accents, e.g. as typed on macOS, are composed
+//     if the composed letter is in the alphabet;
+//   - smart quotes and dashes are replaced with their ASCII equivalents;
+//   - non-breaking and other special spaces are replaced with plain spaces;
+//   - zero-width characters and soft hyphens are removed.
+//
+// It returns the normalized text along with its segments, whose NonGSM field
+// lists the characters still forcing UCS-2. Accented letters outside the
+// alphabet are kept.
+func NormalizeText(text string) (string, SegmentInfo) {
+	text = normalizeReplacer.Replace(text)
+
+	runes := []rune(text)
+	out := runes[:0]
+	for i := 0; i < len(runes); i++ {
+		if i+1 < len(runes) {
+			if r, ok := gsm7Compositions[[2]rune{runes[i], runes[i+1]}]; ok {
+				out = append(out, r)
+				i++
+				continue
+			}
+		}
+		out = append(out, runes[i])
+	}
+	text = string(out)
+
+	return text, CountSegments(text)
+}
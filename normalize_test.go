@@ -0,0 +1,24 @@
+package nexmo
+
+import "testing"
+
+func TestNormalizeText(t *testing.T) {
+	for _, tc := range []struct {
+		text, want string
+		encoding   SMSEncoding
+	}{
+		{"It’s “great” — really", `It's "great" - really`, GSM7},
+		{"Café at 10 am", "Café at 10 am", GSM7},
+		{"zero\u200bwidth co\u00adop", "zerowidth coop", GSM7},
+		{"Franc\u0327ais", "Franc\u0327ais", UCS2},
+	} {
+		got, info := NormalizeText(tc.text)
+		if got != tc.want || info.Encoding != tc.encoding {
+			t.Errorf("NormalizeText(%q) = %q, %v, want %q, %v", tc.text, got, info.Encoding, tc.want, tc.encoding)
+		}
+	}
+
+	if _, info := NormalizeText("Français"); string(info.NonGSM) != "ç" {
+		t.Errorf("NonGSM = %q, want %q", string(info.NonGSM), "ç")
+	}
+}
@@ -0,0 +1,146 @@
+package nexmo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Reasons a NumberFilter rejects a number.
+const (
+	NumberRejectInvalid     = "invalid"     // Nexmo rejected the number, or it does not exist.
+	NumberRejectLandline    = "landline"    // The number cannot receive text messages.
+	NumberRejectUnreachable = "unreachable" // The handset cannot be reached, Advanced only.
+)
+
+// RejectedNumber is a number removed by a NumberFilter.
+type RejectedNumber struct {
+	Number string
+	Reason string // One of the NumberReject constants.
+
+	// The lookup the decision is based on.
+	Insight *InsightResponse
+}
+
+// NumberFilter removes the numbers which cannot receive text messages from a
+// list of recipients before it is sent to, using Number Insight. Lookups are
+// cached, as they are charged for.
+type NumberFilter struct {
+	Insight *Insight
+
+	// Defaults to InsightStandard, the lowest level reporting landlines.
+	// Reachability is only checked by InsightAdvanced.
+	Level InsightLevel
+
+	// Used for numbers in national format.
+	Country string
+
+	// Keep landline numbers, e.g. for text-to-speech fallbacks.
+	AllowLandlines bool
+
+	// How long lookups are cached. Defaults to 24 hours.
+	CacheTTL time.Duration
+
+	Clock Clock
+
+	mu    sync.Mutex
+	cache map[string]cachedInsight
+}
+
+type cachedInsight struct {
+	resp    *InsightResponse
+	expires time.Time
+}
+
+// Filter looks up numbers and returns those which can receive messages, in
+// their order, along with the rejected ones and why. It stops at the first
+// lookup which fails, returning its error.
+func (f *NumberFilter) Filter(ctx context.Context, numbers []string) ([]string, []RejectedNumber, error) {
+	var valid []string
+	var rejected []RejectedNumber
+	for _, number := range numbers {
+		resp, err := f.lookup(ctx, number)
+		if err != nil {
+			return valid, rejected, err
+		}
+		if reason := f.reject(resp); reason != "" {
+			rejected = append(rejected, RejectedNumber{Number: number, Reason: reason, Insight: resp})
+		} else {
+			valid = append(valid, number)
+		}
+	}
+	return valid, rejected, nil
+}
+
+// reject returns why resp disqualifies its number, or "". Partial lookups are
+// judged on the fields they have.
+func (f *NumberFilter) reject(resp *InsightResponse) string {
+	switch {
+	case (resp.Status != ResponseSuccess && !isPartialInsight(resp.Status)) || resp.ValidNumber == "invalid":
+		return NumberRejectInvalid
+	case !f.AllowLandlines && resp.CurrentCarrier != nil && isLandline(resp.CurrentCarrier.NetworkType):
+		return NumberRejectLandline
+	case resp.Reachable == "undeliverable" || resp.Reachable == "absent" ||
+		resp.Reachable == "bad_number" || resp.Reachable == "blacklisted":
+		return NumberRejectUnreachable
+	}
+	return ""
+}
+
+// isPartialInsight returns true if status reports a lookup which succeeded in
+// part: Number Insight answers 43, 44 or 45 when the live mobile lookup did
+// not return, leaving some fields unset.
+func isPartialInsight(status ResponseCode) bool {
+	switch status {
+	case 43, 44, 45:
+		return true
+	}
+	return false
+}
+
+func isLandline(networkType string) bool {
+	switch networkType {
+	case "landline", "landline_premium", "landline_tollfree":
+		return true
+	}
+	return false
+}
+
+// lookup returns the cached lookup of number, or looks it up.
+func (f *NumberFilter) lookup(ctx context.Context, number string) (*InsightResponse, error) {
+	now := clockOrSystem(f.Clock).Now()
+
+	f.mu.Lock()
+	cached, ok := f.cache[number]
+	f.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.resp, nil
+	}
+
+	level := f.Level
+	if level == 0 {
+		level = InsightStandard
+	}
+	resp, err := f.Insight.Lookup(ctx, level, &InsightRequest{Number: number, Country: f.Country})
+	if err != nil {
+		return nil, err
+	}
+	// Only an invalid request or a partial lookup says something about the
+	// number; throttling or invalid credentials do not.
+	if resp.Status != ResponseSuccess && resp.Status != ResponseInvalidParams && !isPartialInsight(resp.Status) {
+		return nil, fmt.Errorf("looking up %s: %v: %s", number, resp.Status, resp.StatusMessage)
+	}
+
+	ttl := f.CacheTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	f.mu.Lock()
+	if f.cache == nil {
+		f.cache = make(map[string]cachedInsight)
+	}
+	f.cache[number] = cachedInsight{resp: resp, expires: now.Add(ttl)}
+	f.mu.Unlock()
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Errors reported to the ErrorHandler when a handler sheds a callback, see
+// WithRateLimit and WithMaxConcurrent.
+var (
+	ErrRateLimited = errors.New("callback rate limit exceeded")
+	ErrOverloaded  = errors.New("too many callbacks in progress")
+)
+
+// rateLimiter is a token bucket allowing rate events per second on average,
+// with bursts of up to burst events. A rate of 0 or less allows any number of
+// events.
+type rateLimiter struct {
+	clock  Clock
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(clock Clock, rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		clock:  clock,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// allow takes a token from the bucket, if there is one.
+func (l *rateLimiter) allow() bool {
+	return l.reserve() == 0
+}
+
+// wait takes a token from the bucket, waiting for one if necessary.
+func (l *rateLimiter) wait() {
+	for {
+		d := l.reserve()
+		if d == 0 {
+			return
+		}
+		<-l.clock.After(d)
+	}
+}
+
+// waitContext is like wait, but gives up when ctx is done.
+func (l *rateLimiter) waitContext(ctx context.Context) error {
+	for {
+		d := l.reserve()
+		if d == 0 {
+			return nil
+		}
+		select {
+		case <-l.clock.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token from the bucket and returns 0 if there is one, or
+// returns how long it takes until there is one.
+func (l *rateLimiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	now := l.clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+	l.tokens--
+	return 0
+}
@@ -0,0 +1,234 @@
+package nexmo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Parameters always masked by a Recorder.
+var secretParams = []string{"api_key", "api_secret", "sig"}
+
+// DefaultMaskedFields are the fields holding personal data which a Recorder
+// masks unless configured otherwise.
+var DefaultMaskedFields = []string{"to", "from", "msisdn", "number", "text", "body"}
+
+// Exchange is a request to Nexmo and its response, as kept by a Recorder.
+// Secrets and masked fields are replaced by asterisks.
+type Exchange struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	RequestBody string `json:"request_body,omitempty"`
+
+	StatusCode   int    `json:"status_code,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+
+	// Set if no response was received.
+	Error string `json:"error,omitempty"`
+}
+
+// Recorder keeps the last requests made by a Client and their responses, so
+// intermittent errors can be diagnosed at runtime without debug logging.
+// Install it with WithRecorder. A Recorder is safe for concurrent use.
+type Recorder struct {
+	// Fields masked in the bodies and query strings, in addition to the API
+	// credentials. Defaults to DefaultMaskedFields; set it to an empty slice
+	// to keep everything but the credentials.
+	MaskedFields []string
+
+	// Defaults to SystemClock.
+	Clock Clock
+
+	mu        sync.Mutex
+	exchanges []Exchange
+	next      int
+	full      bool
+}
+
+// NewRecorder creates a Recorder keeping the last size exchanges.
+func NewRecorder(size int) *Recorder {
+	if size < 1 {
+		size = 1
+	}
+	return &Recorder{exchanges: make([]Exchange, size)}
+}
+
+// WithRecorder makes the client record its requests in r. It wraps the
+// transport of the HTTPClient the client has at that point, so it should be
+// given after any option replacing the HTTPClient.
+func WithRecorder(r *Recorder) ClientOption {
+	return func(c *Client) {
+		base := c.HTTPClient
+		if base == nil {
+			base = DefaultHTTPClient
+		}
+		hc := *base
+		hc.Transport = &recordingTransport{
+			recorder: r,
+			next:     base.Transport,
+		}
+		c.HTTPClient = &hc
+	}
+}
+
+// Exchanges returns the recorded exchanges, oldest first.
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]Exchange(nil), r.exchanges[:r.next]...)
+	}
+	return append(append([]Exchange(nil), r.exchanges[r.next:]...), r.exchanges[:r.next]...)
+}
+
+// ServeHTTP writes the recorded exchanges as JSON, so the Recorder can be
+// mounted on a debug endpoint.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(r.Exchanges())
+}
+
+func (r *Recorder) add(e Exchange) {
+	r.mu.Lock()
+	r.exchanges[r.next] = e
+	r.next++
+	if r.next == len(r.exchanges) {
+		r.next = 0
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+func (r *Recorder) maskedFields() []string {
+	if r.MaskedFields == nil {
+		return DefaultMaskedFields
+	}
+	return r.MaskedFields
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	clock := clockOrSystem(t.recorder.Clock)
+	e := Exchange{
+		Time:   clock.Now(),
+		Method: req.Method,
+	}
+	fields := append(append([]string(nil), secretParams...), t.recorder.maskedFields()...)
+
+	resp, err := next.RoundTrip(req)
+	e.Duration = clock.Now().Sub(e.Time)
+	e.URL = maskURL(req.URL, fields)
+	e.RequestBody = string(maskBody(reqBody, fields))
+
+	if err != nil {
+		e.Error = string(maskSecrets([]byte(err.Error())))
+		t.recorder.add(e)
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	e.StatusCode = resp.StatusCode
+	e.ResponseBody = string(maskBody(respBody, fields))
+	t.recorder.add(e)
+	return resp, nil
+}
+
+// maskURL masks the named query parameters of u.
+func maskURL(u *url.URL, fields []string) string {
+	masked := *u
+	if u.RawQuery != "" {
+		masked.RawQuery = maskValues(u.Query(), fields).Encode()
+	}
+	return masked.String()
+}
+
+// maskBody masks the named fields of a JSON or URL-encoded body. Nested JSON
+// objects and arrays, like the messages of an SMS API response, are masked as
+// well.
+func maskBody(body []byte, fields []string) []byte {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if buf, err := json.Marshal(maskJSON(v, fields)); err == nil {
+			return buf
+		}
+	}
+
+	if values, err := url.ParseQuery(string(body)); err == nil {
+		return []byte(maskValues(values, fields).Encode())
+	}
+	return body
+}
+
+func maskJSON(v interface{}, fields []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if contains(fields, key) {
+				v[key] = maskedSecret
+			} else {
+				v[key] = maskJSON(value, fields)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = maskJSON(value, fields)
+		}
+	}
+	return v
+}
+
+func maskValues(values url.Values, fields []string) url.Values {
+	for _, name := range fields {
+		if _, ok := values[name]; ok {
+			values.Set(name, maskedSecret)
+		}
+	}
+	return values
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
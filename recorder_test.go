@@ -0,0 +1,55 @@
+package nexmo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder(2)
+	client, err := NewClient("k3y", "s3cr3t", WithRecorder(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Install a fake transport underneath the recorder.
+	client.HTTPClient.Transport.(*recordingTransport).next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(
+				`{"message-count":"1","messages":[{"status":"0","to":"447700900000","message-id":"0A01"}]}`)),
+		}, nil
+	})
+
+	for _, text := range []string{"one", "two", "three"} {
+		_, err := client.SMS().Send(&SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exchanges := recorder.Exchanges()
+	if len(exchanges) != 2 {
+		t.Fatalf("got %d exchanges, want 2", len(exchanges))
+	}
+	for _, e := range exchanges {
+		all := e.URL + e.RequestBody + e.ResponseBody
+		for _, secret := range []string{"k3y", "s3cr3t", "447700900000", "three"} {
+			if strings.Contains(all, secret) {
+				t.Errorf("%q was not masked in %#v", secret, e)
+			}
+		}
+		if e.StatusCode != http.StatusOK || !strings.Contains(e.ResponseBody, "0A01") {
+			t.Errorf("unexpected exchange %#v", e)
+		}
+	}
+
+	// Only the credentials are masked without masked fields.
+	recorder.MaskedFields = []string{}
+	client.SMS().Send(&SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: "four"})
+	if e := recorder.Exchanges()[1]; !strings.Contains(e.RequestBody, "four") || strings.Contains(e.RequestBody, "s3cr3t") {
+		t.Errorf("unexpected request body %s", e.RequestBody)
+	}
+}
@@ -0,0 +1,182 @@
+package nexmo
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Errors reported when ReplayProtection rejects a callback.
+var (
+	ErrMissingTimestamp = errors.New("callback has no timestamp")
+	ErrStaleTimestamp   = errors.New("callback timestamp is outside the allowed skew")
+	ErrReplayedCallback = errors.New("callback has already been processed")
+)
+
+// NonceStore remembers which callbacks have already been processed. The
+// MemoryNonceStore is used by default; implementations sharing state between
+// several instances of an application can be plugged in instead.
+type NonceStore interface {
+	// Reserve records key as seen for ttl. It returns false if key was
+	// already recorded and has not expired yet.
+	Reserve(key string, ttl time.Duration) (bool, error)
+
+	// Release forgets key, so a callback which could not be processed is
+	// accepted again when Nexmo retries it.
+	Release(key string) error
+}
+
+// ReplayProtection rejects callbacks which are too old or have been seen
+// before, reducing the blast radius of captured webhook payloads. Pass it to
+// the handlers with WithReplayProtection.
+//
+// The age of a callback is determined from its signed "timestamp" field (Unix
+// seconds), so it should be used with signed callbacks. Callbacks are
+// identified by their "nonce" field, or by their message ID and status if they
+// have no nonce.
+type ReplayProtection struct {
+	// Callbacks with a timestamp further than this from the current time are
+	// rejected. Defaults to 5 minutes.
+	MaxSkew time.Duration
+
+	// How long callbacks are remembered. Defaults to twice MaxSkew.
+	Window time.Duration
+
+	// If true, callbacks without a timestamp are rejected.
+	RequireTimestamp bool
+
+	// Defaults to a MemoryNonceStore using Clock.
+	Store NonceStore
+
+	// Defaults to SystemClock.
+	Clock Clock
+
+	once sync.Once
+}
+
+func (r *ReplayProtection) init() {
+	r.once.Do(func() {
+		if r.MaxSkew == 0 {
+			r.MaxSkew = 5 * time.Minute
+		}
+		if r.Window == 0 {
+			r.Window = 2 * r.MaxSkew
+		}
+		r.Clock = clockOrSystem(r.Clock)
+		if r.Store == nil {
+			r.Store = &MemoryNonceStore{Clock: r.Clock}
+		}
+	})
+}
+
+// Check verifies the timestamp of the callback with the given fields and
+// reserves its key. It returns the key, to be passed to Release if the
+// callback could not be processed.
+func (r *ReplayProtection) Check(fields url.Values) (string, error) {
+	r.init()
+
+	if ts := fields.Get("timestamp"); ts != "" {
+		secs, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return "", err
+		}
+
+		skew := r.Clock.Now().Sub(time.Unix(secs, 0))
+		if skew > r.MaxSkew || skew < -r.MaxSkew {
+			return "", ErrStaleTimestamp
+		}
+	} else if r.RequireTimestamp {
+		return "", ErrMissingTimestamp
+	}
+
+	key := replayKey(fields)
+	if key == "" {
+		return "", nil
+	}
+
+	ok, err := r.Store.Reserve(key, r.Window)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrReplayedCallback
+	}
+	return key, nil
+}
+
+// Release forgets a key returned by Check.
+func (r *ReplayProtection) Release(key string) error {
+	if key == "" {
+		return nil
+	}
+	r.init()
+	return r.Store.Release(key)
+}
+
+// replayKey returns the key identifying a callback.
+func replayKey(fields url.Values) string {
+	if nonce := fields.Get("nonce"); nonce != "" {
+		return "nonce:" + nonce
+	}
+
+	for _, name := range []string{"messageId", "message_uuid", "uuid", "request_id"} {
+		if id := fields.Get(name); id != "" {
+			return name + ":" + id + ":" + fields.Get("status")
+		}
+	}
+	return ""
+}
+
+// MemoryNonceStore is a NonceStore keeping the keys in memory.
+type MemoryNonceStore struct {
+	// Defaults to SystemClock.
+	Clock Clock
+
+	mu      sync.Mutex
+	expires map[string]time.Time
+	pruned  time.Time
+}
+
+// NewMemoryNonceStore creates an empty MemoryNonceStore. The zero value is
+// ready to use as well.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{expires: make(map[string]time.Time)}
+}
+
+// Reserve implements NonceStore.
+func (s *MemoryNonceStore) Reserve(key string, ttl time.Duration) (bool, error) {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expires == nil {
+		s.expires = make(map[string]time.Time)
+	}
+
+	// Get rid of expired keys every now and then.
+	if now.Sub(s.pruned) > ttl {
+		for k, expires := range s.expires {
+			if now.After(expires) {
+				delete(s.expires, k)
+			}
+		}
+		s.pruned = now
+	}
+
+	if expires, ok := s.expires[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+	s.expires[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements NonceStore.
+func (s *MemoryNonceStore) Release(key string) error {
+	s.mu.Lock()
+	delete(s.expires, key)
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,85 @@
+package nexmo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signedValues(values url.Values, timestamp time.Time, nonce string) url.Values {
+	v := make(url.Values)
+	for key, value := range values {
+		v[key] = value
+	}
+	v.Set("timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+	if nonce != "" {
+		v.Set("nonce", nonce)
+	}
+	return v
+}
+
+func TestReplayProtection(t *testing.T) {
+	r := &ReplayProtection{MaxSkew: time.Minute, RequireTimestamp: true}
+
+	if _, err := r.Check(testInboundValues); err != ErrMissingTimestamp {
+		t.Errorf("got error %v, want %v", err, ErrMissingTimestamp)
+	}
+
+	stale := signedValues(testInboundValues, time.Now().Add(-2*time.Minute), "a")
+	if _, err := r.Check(stale); err != ErrStaleTimestamp {
+		t.Errorf("got error %v, want %v", err, ErrStaleTimestamp)
+	}
+
+	fresh := signedValues(testInboundValues, time.Now(), "b")
+	key, err := r.Check(fresh)
+	if err != nil {
+		t.Fatal("fresh callback was rejected:", err)
+	}
+	if _, err := r.Check(fresh); err != ErrReplayedCallback {
+		t.Errorf("got error %v, want %v", err, ErrReplayedCallback)
+	}
+
+	r.Release(key)
+	if _, err := r.Check(fresh); err != nil {
+		t.Error("released callback was rejected:", err)
+	}
+}
+
+func TestHandlerReplayProtection(t *testing.T) {
+	fail := true
+	h := NewMessageHandlerFunc(func(m *ReceivedMessage) error {
+		if fail {
+			return errors.New("try again later")
+		}
+		return nil
+	}, false, WithReplayProtection(&ReplayProtection{}))
+
+	values := signedValues(testInboundValues, time.Now(), "")
+	tests := []struct {
+		fail bool
+		want int
+	}{
+		{true, http.StatusInternalServerError},
+		{false, http.StatusOK},
+		{false, http.StatusOK},
+	}
+
+	for i, test := range tests {
+		fail = test.fail
+		w := httptest.NewRecorder()
+		h(w, newFormRequest("POST", values))
+		if w.Code != test.want {
+			t.Errorf("attempt %d: got status %d, want %d", i, w.Code, test.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", signedValues(testInboundValues, time.Now().Add(-time.Hour), "")))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("stale callback: got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
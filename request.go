@@ -0,0 +1,359 @@
+package nexmo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrThrottled matches, with errors.Is, the errors of requests Nexmo answered
+// with 429 Too Many Requests once the client gave up retrying them.
+var ErrThrottled = errors.New("nexmo: request throttled")
+
+// SendConnectionError is returned when a request could not be sent to Nexmo,
+// or no response was received.
+type SendConnectionError struct {
+	Endpoint string // Path of the API endpoint, e.g. "/sms/json".
+	Err      error
+
+	// Status of the response, if one was received before Err occurred,
+	// e.g. while reading its body. Nexmo has then handled the request.
+	StatusCode int
+
+	// Trace of the request, if the client was created with WithTracing.
+	Trace *Trace
+}
+
+func (e *SendConnectionError) Error() string {
+	return fmt.Sprintf("nexmo: sending request to %s: %v", e.Endpoint, e.Err)
+}
+
+// Unwrap returns the underlying error, e.g. context.DeadlineExceeded.
+func (e *SendConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON implements the json.Marshaler interface, so the error can be
+// logged along with its trace.
+func (e *SendConnectionError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Endpoint string `json:"endpoint"`
+		Err      string `json:"error"`
+		Trace    *Trace `json:"trace,omitempty"`
+	}{e.Endpoint, e.Err.Error(), e.Trace})
+}
+
+// HTTPError is returned when Nexmo, or a proxy in front of it, answers a
+// request with a status other than 2xx which does not describe an APIError,
+// e.g. with the HTML page of a 502 Bad Gateway.
+type HTTPError struct {
+	Endpoint   string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("nexmo: %s answered with %d %s",
+		e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Is returns true for ErrThrottled if the request was throttled.
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrThrottled && e.StatusCode == http.StatusTooManyRequests
+}
+
+// InvalidResponseError is returned when a successful response from Nexmo
+// could not be decoded.
+type InvalidResponseError struct {
+	Endpoint   string
+	StatusCode int
+	Body       []byte
+	Err        error
+}
+
+func (e *InvalidResponseError) Error() string {
+	return fmt.Sprintf("nexmo: invalid response from %s (%d %s): %v",
+		e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode), e.Err)
+}
+
+// Unwrap returns the error decoding the response.
+func (e *InvalidResponseError) Unwrap() error {
+	return e.Err
+}
+
+// APIError is returned when Nexmo answers a request with a 4xx status and a
+// description of the error. Both the error bodies of the legacy APIs and the
+// problem details of the newer APIs are decoded into it.
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+
+	// "error-code" of legacy errors.
+	Code string `json:"error-code"`
+
+	// "error-code-label" of legacy errors, or "title" of problem details.
+	Label string `json:"error-code-label"`
+
+	// Only set for problem details.
+	Type     string `json:"type"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("nexmo: %s returned %d: %s", e.Endpoint, e.StatusCode, e.Label)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Code != "" {
+		msg += " (error code " + e.Code + ")"
+	}
+	return msg
+}
+
+// Is returns true for ErrThrottled if the request was throttled.
+func (e *APIError) Is(target error) bool {
+	return target == ErrThrottled && e.StatusCode == http.StatusTooManyRequests
+}
+
+// parseAPIError decodes the error described in body, if any.
+func parseAPIError(endpoint string, statusCode int, body []byte) *APIError {
+	var v struct {
+		APIError
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+
+	e := v.APIError
+	if e.Label == "" {
+		e.Label = v.Title
+	}
+	if e.Code == "" && e.Label == "" && e.Detail == "" {
+		return nil
+	}
+	e.Endpoint = endpoint
+	e.StatusCode = statusCode
+	return &e
+}
+
+// bufferPool holds the buffers requests are encoded into and responses are
+// read into, which would otherwise dominate the allocations of the send path.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	// Don't hold on to the occasional huge response.
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// newJSONRequest creates a request posting body, a JSON document, to url.
+// body is copied, so it may be reused once newJSONRequest returns.
+func (c *Client) newJSONRequest(url string, body []byte) (*http.Request, error) {
+	buf := make([]byte, len(body))
+	copy(buf, body)
+
+	r, err := http.NewRequest("POST", url, bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Content-Type", "application/json")
+	return r, nil
+}
+
+// newFormRequest creates a request posting values, along with the
+// credentials added by the Auth of override, form encoded to url.
+func (c *Client) newFormRequest(url string, values url.Values, override *Credentials) (*http.Request, error) {
+	header := make(http.Header)
+	if err := c.auth(override).Authenticate(header, values); err != nil {
+		return nil, err
+	}
+
+	r, err := http.NewRequest("POST", url, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	copyHeader(r.Header, header)
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r, nil
+}
+
+// copyHeader adds the values of src to dst.
+func copyHeader(dst, src http.Header) {
+	for name, values := range src {
+		for _, value := range values {
+			dst.Add(name, value)
+		}
+	}
+}
+
+// do sends r and decodes the JSON response into v. Requests answered with a
+// 429 Too Many Requests are retried up to MaxRetries times. 4xx responses
+// describing an error are returned as an *APIError, other responses with a
+// status other than 2xx as an *HTTPError.
+func (c *Client) do(ctx context.Context, r *http.Request, v interface{}) error {
+	c.Init()
+	clock := clockOrSystem(c.Clock)
+	endpoint := r.URL.Path
+
+	if c.breaker != nil {
+		if err := c.breaker.allow(clock.Now()); err != nil {
+			return err
+		}
+	}
+	if c.retryBudget != nil {
+		c.retryBudget.deposit()
+	}
+
+	var rec *traceRecorder
+	if c.tracing {
+		rec = newTraceRecorder(ctx, clock)
+		ctx = rec.withTrace(ctx)
+	}
+
+	start := clock.Now()
+	statusCode, err := c.roundTrip(ctx, r, v, endpoint)
+	d := clock.Now().Sub(start)
+	if c.breaker != nil {
+		if ctx.Err() != nil {
+			c.breaker.release()
+		} else {
+			c.breaker.report(statusCode > 0 && statusCode < 500, clock.Now())
+		}
+	}
+	if rec != nil {
+		t := rec.finish(ctx)
+		if e, ok := err.(*SendConnectionError); ok {
+			e.Trace = t
+		} else if ts, ok := v.(traceSetter); ok && err == nil && c.traceSuccesses {
+			ts.setTrace(t)
+		}
+	}
+	if c.Logger != nil {
+		c.logRequest(r, endpoint, v, statusCode, d, err)
+	}
+	if c.Metrics != nil {
+		c.Metrics.RequestDone(endpoint, statusCode, d)
+		if rc, ok := v.(responseCoder); ok && err == nil {
+			for _, code := range rc.responseCodes() {
+				c.Metrics.ResponseCode(endpoint, code)
+			}
+		}
+	}
+	return err
+}
+
+// roundTrip implements do. It returns the status code of the response, or 0
+// if none was received.
+func (c *Client) roundTrip(ctx context.Context, r *http.Request, v interface{}, endpoint string) (int, error) {
+	r = r.WithContext(ctx)
+	canRetry := r.Body == nil || r.GetBody != nil
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		var err error
+		resp, err = c.sendFailover(r, endpoint)
+		if err != nil {
+			return 0, &SendConnectionError{Endpoint: endpoint, Err: err}
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= c.MaxRetries || !canRetry ||
+			!c.allowExtraRequest() {
+			break
+		}
+		resp.Body.Close()
+		if c.Metrics != nil {
+			c.Metrics.RequestRetried(endpoint)
+		}
+
+		if r.GetBody != nil {
+			if r.Body, err = r.GetBody(); err != nil {
+				return resp.StatusCode, &SendConnectionError{Endpoint: endpoint, Err: err, StatusCode: resp.StatusCode}
+			}
+		}
+		select {
+		case <-clockOrSystem(c.Clock).After(retryDelay(resp, attempt)):
+		case <-ctx.Done():
+			return resp.StatusCode, &SendConnectionError{Endpoint: endpoint, Err: ctx.Err(), StatusCode: resp.StatusCode}
+		}
+	}
+	defer resp.Body.Close()
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return resp.StatusCode, &SendConnectionError{Endpoint: endpoint, Err: err, StatusCode: resp.StatusCode}
+	}
+	body := buf.Bytes()
+
+	if p, ok := v.(errorParser); ok && resp.StatusCode >= 300 {
+		return resp.StatusCode, p.parseError(resp, body)
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		if e := parseAPIError(endpoint, resp.StatusCode, body); e != nil {
+			return resp.StatusCode, e
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &HTTPError{
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			Body:       append([]byte(nil), body...),
+		}
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return resp.StatusCode, &InvalidResponseError{
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			Body:       append([]byte(nil), body...),
+			Err:        err,
+		}
+	}
+	if ms, ok := v.(responseMetaSetter); ok {
+		ms.setResponseMeta(ResponseMeta{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       append([]byte(nil), body...),
+		})
+	}
+	return resp.StatusCode, nil
+}
+
+// errorParser is implemented by the responses of APIs which report errors
+// in their own format, e.g. the Messages API. do calls parseError instead of
+// decoding responses with a status code of 300 or more.
+type errorParser interface {
+	parseError(resp *http.Response, body []byte) error
+}
+
+// retryDelay returns how long to wait before retrying a request answered with
+// resp: as long as Nexmo asked for in the Retry-After header, or an
+// exponential backoff starting at one second.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return time.Second << uint(attempt)
+}
@@ -0,0 +1,289 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// instantClock fires timers immediately and records the requested delays.
+type instantClock struct {
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (c *instantClock) Now() time.Time { return time.Time{} }
+
+func (c *instantClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.delays = append(c.delays, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func TestRequestRetry(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &instantClock{}
+	client.Clock = clock
+	client.MaxRetries = 2
+
+	var bodies []string
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) < 3 {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": {"3"}},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0","message-id":"0A0000000123ABCD1"}]}`)),
+		}, nil
+	})}
+
+	resp, err := client.SMS().Send(&SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.MessageCount != 1 {
+		t.Errorf("unexpected response %#v", resp)
+	}
+	if len(bodies) != 3 || bodies[2] != bodies[0] {
+		t.Errorf("got request bodies %q", bodies)
+	}
+	if len(clock.delays) != 2 || clock.delays[0] != 3*time.Second {
+		t.Errorf("got delays %v", clock.delays)
+	}
+}
+
+func TestRequestInvalidResponse(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, body := http.StatusBadGateway, "<html>Bad Gateway</html>"
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	_, err = client.SMS().Send(msg)
+	he, ok := err.(*HTTPError)
+	if !ok {
+		t.Fatalf("got error %#v", err)
+	}
+	if he.StatusCode != http.StatusBadGateway || he.Endpoint != "/sms/json" || string(he.Body) != body {
+		t.Errorf("unexpected error %v", he)
+	}
+
+	// Responses with an error status are not decoded, even if they could be.
+	status, body = http.StatusServiceUnavailable, `{"message-count":"1","messages":[{"status":"0"}]}`
+	if _, err := client.Account().GetBalance(); err == nil {
+		t.Error("got no error for a 503")
+	} else if he, ok := err.(*HTTPError); !ok || strings.Contains(he.Error(), "s3cr3t") {
+		t.Errorf("got error %v", err)
+	}
+
+	status, body = http.StatusOK, "<html>OK</html>"
+	_, err = client.SMS().Send(msg)
+	e, ok := err.(*InvalidResponseError)
+	if !ok {
+		t.Fatalf("got error %#v", err)
+	}
+	if e.StatusCode != http.StatusOK || e.Endpoint != "/sms/json" {
+		t.Errorf("unexpected error %v", e)
+	}
+	if strings.Contains(e.Error(), "s3cr3t") {
+		t.Errorf("error %q contains the API secret", e)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t",
+		WithEncoding(EndpointSMS, EncodingForm),
+		WithEncoding(EndpointUSSD, EncodingJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		v    interface{}
+		want string
+	}{
+		{
+			&SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: "Hello", TTL: 60000},
+			"POST https://rest.nexmo.com/sms/json\n" +
+				"Accept: application/json\n" +
+				"Content-Type: application/x-www-form-urlencoded\n" +
+				"\n" +
+				"api_key=********&api_secret=********&from=gonexmo&text=Hello&to=447700900000&ttl=60000&type=text\n",
+		},
+		{
+			&SMSMessage{From: "gonexmo", To: "447700900000", Type: Binary, Class: SIMData,
+				Body: []byte{0x01, 0xab}, UDH: []byte{0x06, 0x05, 0x04}},
+			"POST https://rest.nexmo.com/sms/json\n" +
+				"Accept: application/json\n" +
+				"Content-Type: application/x-www-form-urlencoded\n" +
+				"\n" +
+				"api_key=********&api_secret=********&body=01ab&from=gonexmo&message-class=2&to=447700900000&type=binary&udh=060504\n",
+		},
+		{
+			&USSDMessage{From: "gonexmo", To: "447700900000", Text: "Hello"},
+			"POST https://rest.nexmo.com/ussd/json\n" +
+				"Accept: application/json\n" +
+				"Content-Type: application/json\n" +
+				"\n" +
+				`{"api_key":"********","api_secret":"********","from":"gonexmo","text":"Hello","to":"447700900000"}` + "\n",
+		},
+	} {
+		got, err := client.DumpRequest(test.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("DumpRequest(%T):\ngot:\n%s\nwant:\n%s", test.v, got, test.want)
+		}
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	for _, test := range []struct {
+		status int
+		body   string
+		want   string
+	}{
+		{
+			http.StatusUnauthorized,
+			`{"error-code":"401","error-code-label":"authentication failed"}`,
+			"nexmo: /account/get-balance returned 401: authentication failed (error code 401)",
+		},
+		{
+			http.StatusUnauthorized,
+			`{"type":"https://developer.nexmo.com/api-errors#unauthorized","title":"Unauthorized","detail":"You did not provide correct credentials."}`,
+			"nexmo: /account/get-balance returned 401: Unauthorized: You did not provide correct credentials.",
+		},
+	} {
+		client, err := NewClient("k3y", "s3cr3t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: test.status,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}, nil
+		})}
+
+		_, err = client.Account().GetBalance()
+		e, ok := err.(*APIError)
+		if !ok {
+			t.Fatalf("got error %#v", err)
+		}
+		if e.Error() != test.want {
+			t.Errorf("got %q, want %q", e, test.want)
+		}
+	}
+}
+
+func BenchmarkSMSSend(b *testing.B) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		b.Fatal(err)
+	}
+	const response = `{"message-count":"1","messages":[{"to":"447700900000","message-id":"0A0000000123ABCD1","status":"0","remaining-balance":"3.14159265","message-price":"0.03330000","network":"23410"}]}`
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		ioutil.ReadAll(req.Body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(response)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "gonexmo", To: "447700900000", Type: Text, Text: "Your code is 1234"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.SMS().Send(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestResponseMeta(t *testing.T) {
+	const body = `{"status":"0","request_id":"abcdef0123456789"}`
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"X-Request-Id": {"req-1"}, "X-Ratelimit-Remaining": {"29"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+
+	resp, err := client.Verify().Send(&VerifyMessageRequest{Number: "447700900000", Brand: "gonexmo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != "abcdef0123456789" {
+		t.Errorf("got request ID %q", resp.RequestID)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Request-Id") != "req-1" ||
+		resp.Header.Get("X-Ratelimit-Remaining") != "29" || string(resp.Body) != body {
+		t.Errorf("got response meta %+v", resp.ResponseMeta)
+	}
+}
+
+func TestErrorsIs(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	if _, err := client.SMS().Send(msg); !errors.Is(err, ErrThrottled) {
+		t.Errorf("got error %v for a throttled request", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.SMS().Send(msg, WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v for a canceled request", err)
+	}
+
+	_, err = client.SMS().Send(&SMSMessage{From: "Test", To: "447700900000", Type: Binary, Body: []byte{1}, UDH: []byte{0}, Text: "Hello"})
+	var ve *ValidationError
+	if !errors.Is(err, ErrFieldNotAllowed) || !errors.As(err, &ve) || ve.Field != "Text" {
+		t.Errorf("got error %v for a binary message with a text", err)
+	}
+	if _, err := client.SMS().Send(&SMSMessage{From: "Test", Type: Text, Text: "Hello"}); !errors.Is(err, ErrMissingTo) {
+		t.Errorf("got error %v for a message without recipient", err)
+	}
+}
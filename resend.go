@@ -0,0 +1,87 @@
+package nexmo
+
+import (
+	"strconv"
+	"time"
+)
+
+// ResendPolicy makes a DeliveryTracker send a message again when its delivery
+// failed for a temporary reason, e.g. because the handset was switched off or
+// busy. The whole message is resent, even if only one of its parts failed.
+//
+// Resent messages get the client reference of the original one with "#r1",
+// "#r2", etc. appended, shortened to fit if needed, so they are not
+// suppressed as duplicates by a client created with WithDeduplication.
+type ResendPolicy struct {
+	// Sends the messages again.
+	SMS *SMS
+
+	// How many times a message is resent at most, and how long after the
+	// failure.
+	MaxResends int
+	Delay      time.Duration
+
+	// Errors never resent in addition to the permanent ones, e.g.
+	// DLRGatewayQuotaExceeded.
+	Exclude []DLRErrorCode
+
+	// Permanent errors resent nonetheless, e.g. DLRInsufficientFunds for
+	// accounts topped up automatically.
+	Include []DLRErrorCode
+
+	// Called when a message could not be resent.
+	OnError func(msg *SMSMessage, err error)
+}
+
+// allows returns true if a message resent resends times before, which moved
+// to state because of code, should be resent.
+func (p *ResendPolicy) allows(state DeliveryState, code DLRErrorCode, resends int) bool {
+	if state != StateFailed && state != StateExpired {
+		return false
+	}
+	if resends >= p.MaxResends || hasCode(p.Exclude, code) {
+		return false
+	}
+	return code.IsTemporary() || hasCode(p.Include, code)
+}
+
+// hasCode returns true if codes contains code.
+func hasCode(codes []DLRErrorCode, code DLRErrorCode) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// resend sends sent again after the delay of the policy of t, tracking it
+// anew.
+func (t *DeliveryTracker) resend(sent *sentMessage) {
+	p := t.Resend
+	<-clockOrSystem(t.Clock).After(p.Delay)
+
+	msg := *sent.msg
+	msg.ClientReference = resendReference(msg.ClientReference, sent.resends+1)
+	resp, err := p.SMS.Send(&msg)
+	if err != nil {
+		if p.OnError != nil {
+			p.OnError(sent.msg, err)
+		}
+		return
+	}
+	t.track(&sentMessage{msg: sent.msg, resends: sent.resends + 1}, resp)
+}
+
+// resendReference returns the client reference of the nth resend of a message
+// sent with ref, which is left empty if ref is.
+func resendReference(ref string, n int) string {
+	if ref == "" {
+		return ""
+	}
+	suffix := "#r" + strconv.Itoa(n)
+	if len(ref)+len(suffix) > maxClientRefLength {
+		ref = ref[:maxClientRefLength-len(suffix)]
+	}
+	return ref + suffix
+}
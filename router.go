@@ -0,0 +1,119 @@
+package nexmo
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrNoRoute is returned, wrapped in a HandlerError with a 200 OK status, by
+// KeywordRouter.Route when no route matches a message and there is no
+// default route. Nexmo will not retry such messages.
+var ErrNoRoute = errors.New("no route for keyword")
+
+// KeywordRouter dispatches received messages to handlers based on their
+// keyword, as is common for short code campaigns (STOP, HELP, INFO...).
+// Keywords are matched case-insensitively.
+//
+// Routes are tried in the following order: exact keyword matches, then prefix
+// and regexp routes in the order they were added, and finally the default
+// route. A KeywordRouter is safe for concurrent use.
+//
+// Use it with NewMessageHandlerFunc:
+//
+//	router := nexmo.NewKeywordRouter()
+//	router.Handle("STOP", unsubscribe)
+//	http.Handle("/inbound", nexmo.NewMessageHandlerFunc(router.Route, true))
+type KeywordRouter struct {
+	mu       sync.RWMutex
+	exact    map[string]func(*ReceivedMessage) error
+	patterns []keywordRoute
+	fallback func(*ReceivedMessage) error
+}
+
+type keywordRoute struct {
+	prefix string
+	re     *regexp.Regexp
+	fn     func(*ReceivedMessage) error
+}
+
+func (r keywordRoute) match(keyword string) bool {
+	if r.re != nil {
+		return r.re.MatchString(keyword)
+	}
+	return strings.HasPrefix(keyword, r.prefix)
+}
+
+// NewKeywordRouter creates an empty KeywordRouter.
+func NewKeywordRouter() *KeywordRouter {
+	return &KeywordRouter{
+		exact: make(map[string]func(*ReceivedMessage) error),
+	}
+}
+
+// Handle routes messages with exactly the given keyword to fn.
+func (r *KeywordRouter) Handle(keyword string, fn func(*ReceivedMessage) error) {
+	r.mu.Lock()
+	r.exact[strings.ToUpper(keyword)] = fn
+	r.mu.Unlock()
+}
+
+// HandlePrefix routes messages whose keyword starts with prefix to fn.
+func (r *KeywordRouter) HandlePrefix(prefix string, fn func(*ReceivedMessage) error) {
+	r.mu.Lock()
+	r.patterns = append(r.patterns, keywordRoute{prefix: strings.ToUpper(prefix), fn: fn})
+	r.mu.Unlock()
+}
+
+// HandleRegexp routes messages whose keyword matches re to fn. Keywords are
+// upper-cased before being matched against re.
+func (r *KeywordRouter) HandleRegexp(re *regexp.Regexp, fn func(*ReceivedMessage) error) {
+	r.mu.Lock()
+	r.patterns = append(r.patterns, keywordRoute{re: re, fn: fn})
+	r.mu.Unlock()
+}
+
+// HandleDefault routes messages not matched by any other route to fn.
+func (r *KeywordRouter) HandleDefault(fn func(*ReceivedMessage) error) {
+	r.mu.Lock()
+	r.fallback = fn
+	r.mu.Unlock()
+}
+
+// Route passes m to the handler of the first matching route and returns its
+// error.
+func (r *KeywordRouter) Route(m *ReceivedMessage) error {
+	if fn := r.lookup(MessageKeyword(m)); fn != nil {
+		return fn(m)
+	}
+	return &HandlerError{StatusCode: http.StatusOK, Err: ErrNoRoute}
+}
+
+func (r *KeywordRouter) lookup(keyword string) func(*ReceivedMessage) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if fn, ok := r.exact[keyword]; ok {
+		return fn
+	}
+	for _, route := range r.patterns {
+		if route.match(keyword) {
+			return route.fn
+		}
+	}
+	return r.fallback
+}
+
+// MessageKeyword returns the upper-cased keyword of m. If Nexmo did not
+// provide one, the first word of the message text is used.
+func MessageKeyword(m *ReceivedMessage) string {
+	keyword := m.Keyword
+	if keyword == "" {
+		if fields := strings.Fields(m.Text); len(fields) > 0 {
+			keyword = fields[0]
+		}
+	}
+	return strings.ToUpper(keyword)
+}
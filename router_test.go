@@ -0,0 +1,51 @@
+package nexmo
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestKeywordRouter(t *testing.T) {
+	var got string
+	route := func(name string) func(*ReceivedMessage) error {
+		return func(*ReceivedMessage) error {
+			got = name
+			return nil
+		}
+	}
+
+	router := NewKeywordRouter()
+	router.Handle("stop", route("stop"))
+	router.HandlePrefix("WIN", route("win"))
+	router.HandleRegexp(regexp.MustCompile(`^CODE\d+$`), route("code"))
+
+	tests := []struct {
+		m    *ReceivedMessage
+		want string
+	}{
+		{&ReceivedMessage{Keyword: "STOP"}, "stop"},
+		{&ReceivedMessage{Text: "Stop sending me these"}, "stop"},
+		{&ReceivedMessage{Keyword: "WINNER"}, "win"},
+		{&ReceivedMessage{Keyword: "code42"}, "code"},
+		{&ReceivedMessage{Keyword: "CODEX"}, ""},
+		{&ReceivedMessage{}, ""},
+	}
+
+	for _, test := range tests {
+		got = ""
+		err := router.Route(test.m)
+		if got != test.want {
+			t.Errorf("%#v routed to %q, want %q", test.m, got, test.want)
+		}
+		if test.want == "" && !errors.Is(err, ErrNoRoute) {
+			t.Errorf("%#v: got error %v, want %v", test.m, err, ErrNoRoute)
+		}
+	}
+
+	router.HandleDefault(route("default"))
+	got = ""
+	if err := router.Route(&ReceivedMessage{Keyword: "HELLO"}); err != nil || got != "default" {
+		t.Errorf("got route %q and error %v, want the default route", got, err)
+	}
+}
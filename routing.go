@@ -0,0 +1,91 @@
+package nexmo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CarrierResolver tells which mobile network a number belongs to.
+// NumberFilter implements it with cached Number Insight lookups.
+type CarrierResolver interface {
+	Network(ctx context.Context, number string) (MobileNetwork, error)
+}
+
+// Network implements CarrierResolver, using the cached lookup of number if
+// there is one. Level must be InsightStandard or higher.
+func (f *NumberFilter) Network(ctx context.Context, number string) (MobileNetwork, error) {
+	resp, err := f.lookup(ctx, number)
+	if err != nil {
+		return MobileNetwork{}, err
+	}
+	if resp.CurrentCarrier == nil {
+		return MobileNetwork{}, fmt.Errorf("no carrier known for %s", number)
+	}
+	return ParseMobileNetwork(resp.CurrentCarrier.NetworkCode)
+}
+
+// RoutingRule changes how messages to some networks are sent.
+type RoutingRule struct {
+	// Network codes the rule applies to, e.g. "23410", or MCCs, e.g. "234",
+	// to apply it to all networks of a country.
+	Networks []string
+
+	// Set on the message if not empty.
+	NetworkCode string
+	From        string
+
+	// Sent instead of the credentials of the client if not nil.
+	Credentials *Credentials
+}
+
+// matches returns true if r applies to network.
+func (r *RoutingRule) matches(network MobileNetwork) bool {
+	code := network.String()
+	for _, prefix := range r.Networks {
+		if strings.HasPrefix(code, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CarrierRouter applies the first of its rules matching the network of the
+// recipient to a message, for carrier-specific routes and sender
+// registrations. Messages whose network cannot be resolved, or which match
+// no rule, are sent unchanged.
+type CarrierRouter struct {
+	Resolver CarrierResolver
+	Rules    []RoutingRule
+}
+
+// WithCarrierRouting routes the message with r.
+func WithCarrierRouting(r *CarrierRouter) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.router = r
+	}
+}
+
+// route applies the rule matching the recipient of msg to msg and cfg.
+func (r *CarrierRouter) route(ctx context.Context, msg *SMSMessage, cfg *sendConfig) {
+	network, err := r.Resolver.Network(ctx, msg.To)
+	if err != nil {
+		return
+	}
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if !rule.matches(network) {
+			continue
+		}
+		if rule.NetworkCode != "" {
+			msg.NetworkCode = rule.NetworkCode
+		}
+		if rule.From != "" {
+			msg.From = rule.From
+		}
+		if rule.Credentials != nil {
+			cfg.creds = rule.Credentials
+		}
+		return
+	}
+}
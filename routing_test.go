@@ -0,0 +1,72 @@
+package nexmo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// resolverFunc adapts a function to a CarrierResolver.
+type resolverFunc func(number string) (MobileNetwork, error)
+
+func (f resolverFunc) Network(ctx context.Context, number string) (MobileNetwork, error) {
+	return f(number)
+}
+
+func TestCarrierRouting(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent map[string]interface{}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = nil
+		json.NewDecoder(req.Body).Decode(&sent)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	router := &CarrierRouter{
+		Resolver: resolverFunc(func(number string) (MobileNetwork, error) {
+			switch number {
+			case "447700900000":
+				return MobileNetwork{MCC: "234", MNC: "10"}, nil
+			case "33700000000":
+				return MobileNetwork{MCC: "208", MNC: "01"}, nil
+			}
+			return MobileNetwork{}, errors.New("unknown number")
+		}),
+		Rules: []RoutingRule{
+			{Networks: []string{"23410"}, NetworkCode: "23410", Credentials: &Credentials{APIKey: "sub", APISecret: "subs3cr3t"}},
+			{Networks: []string{"208"}, From: "Registered"},
+		},
+	}
+
+	for _, tc := range []struct {
+		to   string
+		want map[string]interface{}
+	}{
+		{"447700900000", map[string]interface{}{"network-code": "23410", "from": "Test", "api_key": "sub"}},
+		{"33700000000", map[string]interface{}{"network-code": nil, "from": "Registered", "api_key": "k3y"}},
+		{"15550000000", map[string]interface{}{"network-code": nil, "from": "Test", "api_key": "k3y"}},
+	} {
+		msg := &SMSMessage{From: "Test", To: tc.to, Type: Text, Text: "Hello"}
+		if _, err := client.SMS().Send(msg, WithCarrierRouting(router)); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range tc.want {
+			if sent[k] != v {
+				t.Errorf("message to %s: %s = %v, want %v", tc.to, k, sent[k], v)
+			}
+		}
+		if msg.From != "Test" || msg.NetworkCode != "" {
+			t.Errorf("routing modified the message: %#v", msg)
+		}
+	}
+}
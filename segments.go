@@ -0,0 +1,97 @@
+package nexmo
+
+import "unicode/utf16"
+
+// SMSEncoding is the character encoding a text message is sent with.
+type SMSEncoding int
+
+// SMS encodings
+const (
+	GSM7 SMSEncoding = iota + 1 // GSM 03.38 default alphabet, 7 bits per character.
+	UCS2                        // UTF-16, 16 bits per character.
+)
+
+func (e SMSEncoding) String() string {
+	switch e {
+	case GSM7:
+		return "GSM-7"
+	case UCS2:
+		return "UCS-2"
+	}
+	return "undefined"
+}
+
+// The GSM 03.38 default alphabet, and its extension table, whose characters
+// take two septets.
+const (
+	gsm7Basic     = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extension = "\f^{}\\[~]|€"
+)
+
+var gsm7Septets = make(map[rune]int)
+
+func init() {
+	for _, r := range gsm7Basic {
+		gsm7Septets[r] = 1
+	}
+	for _, r := range gsm7Extension {
+		gsm7Septets[r] = 2
+	}
+}
+
+// IsGSM7 returns true if r can be sent in the GSM-7 encoding.
+func IsGSM7(r rune) bool {
+	_, ok := gsm7Septets[r]
+	return ok
+}
+
+// SegmentInfo describes how a text is split into SMS segments, each of which
+// is charged as a message.
+type SegmentInfo struct {
+	Encoding SMSEncoding
+
+	// Length of the text in septets for GSM-7, and UTF-16 code units for
+	// UCS-2.
+	Length   int
+	Segments int
+
+	// The characters forcing UCS-2, in the order they first occur.
+	NonGSM []rune
+}
+
+// CountSegments returns how text is sent: in GSM-7 if all of its characters
+// allow, in up to 160 characters per message or 153 per segment of a longer
+// one, and otherwise in UCS-2, in up to 70 characters per message or 67 per
+// segment.
+func CountSegments(text string) SegmentInfo {
+	info := SegmentInfo{Encoding: GSM7}
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		septets, ok := gsm7Septets[r]
+		if !ok {
+			if !seen[r] {
+				seen[r] = true
+				info.NonGSM = append(info.NonGSM, r)
+			}
+			continue
+		}
+		info.Length += septets
+	}
+
+	single, multi := 160, 153
+	if len(info.NonGSM) > 0 {
+		info.Encoding = UCS2
+		info.Length = len(utf16.Encode([]rune(text)))
+		single, multi = 70, 67
+	}
+
+	switch {
+	case info.Length == 0:
+		info.Segments = 0
+	case info.Length <= single:
+		info.Segments = 1
+	default:
+		info.Segments = (info.Length + multi - 1) / multi
+	}
+	return info
+}
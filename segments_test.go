@@ -0,0 +1,33 @@
+package nexmo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountSegments(t *testing.T) {
+	for _, tc := range []struct {
+		text     string
+		encoding SMSEncoding
+		length   int
+		segments int
+		nonGSM   string
+	}{
+		{"", GSM7, 0, 0, ""},
+		{"Hello", GSM7, 5, 1, ""},
+		{strings.Repeat("a", 160), GSM7, 160, 1, ""},
+		{strings.Repeat("a", 161), GSM7, 161, 2, ""},
+		{"Price: 5€ [promo]", GSM7, 20, 1, ""},
+		{strings.Repeat("€", 80), GSM7, 160, 1, ""},
+		{"Привет", UCS2, 6, 1, "Привет"},
+		{strings.Repeat("a", 70) + "ç", UCS2, 71, 2, "ç"},
+		{"👍👍", UCS2, 4, 1, "👍"},
+	} {
+		info := CountSegments(tc.text)
+		if info.Encoding != tc.encoding || info.Length != tc.length || info.Segments != tc.segments || string(info.NonGSM) != tc.nonGSM {
+			t.Errorf("CountSegments(%q) = %v %d %d %q, want %v %d %d %q", tc.text,
+				info.Encoding, info.Length, info.Segments, string(info.NonGSM),
+				tc.encoding, tc.length, tc.segments, tc.nonGSM)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package nexmo
+
+import (
+	"context"
+	"net/http/httptrace"
+	"time"
+)
+
+// SendOption tweaks a single call to SMS.Send without modifying the message
+// passed to it.
+type SendOption func(*sendConfig)
+
+type sendConfig struct {
+	ctx    context.Context
+	trace  *httptrace.ClientTrace
+	creds  *Credentials
+	router *CarrierRouter
+
+	// Overrides of the SMSMessage fields.
+	callback       string
+	ttl            time.Duration
+	validity       time.Duration
+	dlr            bool
+	idempotencyKey string
+}
+
+func newSendConfig(opts []SendOption) *sendConfig {
+	cfg := &sendConfig{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// apply returns a copy of msg with the overrides of cfg applied.
+func (cfg *sendConfig) apply(msg *SMSMessage) *SMSMessage {
+	m := *msg
+	if cfg.callback != "" {
+		m.Callback = cfg.callback
+	}
+	if cfg.ttl > 0 {
+		m.TTL = int(cfg.ttl / time.Millisecond)
+	}
+	if cfg.validity > 0 {
+		m.SetValidity(cfg.validity)
+	}
+	if cfg.dlr {
+		m.StatusReportRequired = StatusReportOn
+	}
+	if cfg.idempotencyKey != "" {
+		m.ClientReference = cfg.idempotencyKey
+	}
+	return &m
+}
+
+// context returns the context the request should be sent with.
+func (cfg *sendConfig) context() context.Context {
+	if cfg.trace != nil {
+		return httptrace.WithClientTrace(cfg.ctx, cfg.trace)
+	}
+	return cfg.ctx
+}
+
+// WithContext sends the message with ctx, so it can be canceled or given a
+// deadline.
+func WithContext(ctx context.Context) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.ctx = ctx
+	}
+}
+
+// WithCallback overrides the URL delivery receipts of the message are sent to.
+func WithCallback(url string) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.callback = url
+	}
+}
+
+// WithTTL sets how long Nexmo tries to deliver the message.
+func WithTTL(ttl time.Duration) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithValidity sets how long a WAP push message is available, between
+// MinWAPPushValidity and MaxWAPPushValidity.
+func WithValidity(d time.Duration) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.validity = d
+	}
+}
+
+// WithDLR requests a delivery receipt for the message.
+func WithDLR() SendOption {
+	return func(cfg *sendConfig) {
+		cfg.dlr = true
+	}
+}
+
+// WithIdempotencyKey sends the message with key as its client reference, so
+// that retries of the same send can be recognized in delivery receipts. The
+// key must not be longer than 40 characters.
+func WithIdempotencyKey(key string) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.idempotencyKey = key
+	}
+}
+
+// WithTrace reports the events of the HTTP request sending the message to
+// trace.
+func WithTrace(trace *httptrace.ClientTrace) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.trace = trace
+	}
+}
+
+// WithSendCredentials sends the message with creds instead of the credentials
+// of the client, e.g. those of a subaccount.
+func WithSendCredentials(creds Credentials) SendOption {
+	return func(cfg *sendConfig) {
+		cfg.creds = &creds
+	}
+}
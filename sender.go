@@ -0,0 +1,92 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrSenderClosed is returned by Sender.Send once the Sender is closed.
+var ErrSenderClosed = errors.New("sender closed")
+
+// Sender is a long-lived pool sending the messages queued with Send in the
+// background, like SMS.SendStream. Create it with SMS.NewSender and stop it
+// with Close.
+type Sender struct {
+	in      chan *SMSMessage
+	closing chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu        sync.RWMutex
+	closeOnce sync.Once
+}
+
+// NewSender starts a Sender configured by opts, which reports the outcome of
+// each message to handle. handle is called from a single goroutine, and must
+// not block for long. Up to queue messages are buffered before Send blocks.
+func (c *SMS) NewSender(handle func(SendResult), queue int, opts ...StreamOption) *Sender {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Sender{
+		in:      make(chan *SMSMessage, queue),
+		closing: make(chan struct{}),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	out := c.SendStream(ctx, s.in, opts...)
+	go func() {
+		defer close(s.done)
+		for res := range out {
+			handle(res)
+		}
+	}()
+	return s
+}
+
+// Send queues msg, waiting for room in the queue if it is full. It returns
+// ErrSenderClosed if the Sender is closed before msg is queued.
+func (s *Sender) Send(msg *SMSMessage) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	select {
+	case <-s.closing:
+		return ErrSenderClosed
+	default:
+	}
+	select {
+	case s.in <- msg:
+		return nil
+	case <-s.closing:
+		return ErrSenderClosed
+	}
+}
+
+// Close stops the Sender from accepting messages and waits for the queued
+// and in-flight ones to be sent. If ctx is done first, the requests in flight
+// are canceled, which is reported to the handler like a message taken from
+// the queue as ctx got done, and the messages which were still queued are
+// returned along with the error of ctx. No message is lost either way.
+func (s *Sender) Close(ctx context.Context) ([]*SMSMessage, error) {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		s.mu.Lock()
+		close(s.in)
+		s.mu.Unlock()
+	})
+
+	select {
+	case <-s.done:
+		s.cancel()
+		return nil, nil
+	case <-ctx.Done():
+	}
+
+	s.cancel()
+	<-s.done
+	var unsent []*SMSMessage
+	for msg := range s.in {
+		unsent = append(unsent, msg)
+	}
+	return unsent, ctx.Err()
+}
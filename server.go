@@ -1,6 +1,14 @@
 package nexmo
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net"
 	"net/http"
 	"net/url"
@@ -33,6 +41,12 @@ var messageTypeIntMap = map[MessageType]string{
 	BinaryMessage:  "binary",
 }
 
+// ParseMessageType returns the MessageType for the type field of an inbound
+// message, e.g. "text", or 0 if the type is unknown.
+func ParseMessageType(s string) MessageType {
+	return messageTypeMap[s]
+}
+
 func (m MessageType) String() string {
 	if m < 1 || m > 3 {
 		return "undefined"
@@ -41,6 +55,19 @@ func (m MessageType) String() string {
 	return messageTypeIntMap[m]
 }
 
+// MarshalText implements the encoding.TextMarshaler interface, so message
+// types are stored as "text", "unicode" or "binary" in JSON.
+func (m MessageType) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Unknown
+// types are decoded as 0.
+func (m *MessageType) UnmarshalText(text []byte) error {
+	*m = ParseMessageType(string(text))
+	return nil
+}
+
 // ReceivedMessage represents a message that was received from the Nexmo API.
 type ReceivedMessage struct {
 	// Expected values are "text" or "binary".
@@ -85,202 +112,699 @@ type ReceivedMessage struct {
 	// Content of the message.
 	Data []byte
 
-	// User Data Header.
+	// User Data Header, see ParseUDH.
 	UDH []byte
+
+	// The callback as received, only set if the handler was created with the
+	// WithRawPayload option.
+	Raw *RawPayload
+
+	// The request the message was received in, set by the handlers.
+	Meta RequestMeta
 }
 
 // DeliveryReceipt is a delivery receipt for a single SMS sent via the Nexmo API
 type DeliveryReceipt struct {
-	To              string    `json:"to"`
-	NetworkCode     string    `json:"network-code"`
-	MessageID       string    `json:"messageId"`
-	MSISDN          string    `json:"msisdn"`
-	Status          string    `json:"status"`
-	ErrorCode       string    `json:"err-code"`
-	Price           string    `json:"price"`
-	SCTS            time.Time `json:"scts"`
-	Timestamp       time.Time `json:"message-timestamp"`
-	ClientReference string    `json:"client-ref"`
+	To              string         `json:"to"`
+	NetworkCode     string         `json:"network-code"`
+	MessageID       string         `json:"messageId"`
+	MSISDN          string         `json:"msisdn"`
+	Status          DeliveryStatus `json:"status"`
+	ErrorCode       DLRErrorCode   `json:"err-code"`
+	Price           string         `json:"price"`
+	SCTS            time.Time      `json:"scts"`
+	Timestamp       time.Time      `json:"message-timestamp"`
+	ClientReference string         `json:"client-ref"`
+	APIKey          string         `json:"api-key"`
+
+	// The callback as received, only set if the handler was created with the
+	// WithRawPayload option.
+	Raw *RawPayload `json:"raw,omitempty"`
+
+	// The request the receipt was received in, set by the handlers.
+	Meta RequestMeta `json:"meta"`
+}
+
+// RawPayload is a callback as it was received from Nexmo, kept for auditing
+// and later re-parsing.
+type RawPayload struct {
+	Method string `json:"method"`
+
+	// The raw request body or, for GET callbacks, the raw query string.
+	Body []byte `json:"body"`
+
+	// Only the headers requested with WithRawPayload.
+	Header http.Header `json:"header,omitempty"`
+}
+
+func (m *ReceivedMessage) setRaw(raw *RawPayload) { m.Raw = raw }
+func (m *DeliveryReceipt) setRaw(raw *RawPayload) { m.Raw = raw }
+
+// HandlerError can be returned from the callbacks passed to
+// NewMessageHandlerFunc and NewDeliveryHandlerFunc to choose the HTTP status
+// code sent back to Nexmo. Nexmo retries callbacks that are not answered with
+// a 2xx status, so returning e.g. http.StatusOK stops retries of messages that
+// will never be processed successfully.
+type HandlerError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *HandlerError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.StatusCode)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// ErrUntrustedIP is reported to the ErrorHandler of a handler verifying IPs when
+// a callback did not come from a trusted Nexmo server.
+var ErrUntrustedIP = errors.New("callback did not come from a trusted IP")
+
+// statusCode maps an error returned by a handler callback to the HTTP status
+// code to reply with. Errors other than HandlerError result in a 500.
+func statusCode(err error) int {
+	var herr *HandlerError
+	if errors.As(err, &herr) && herr.StatusCode != 0 {
+		return herr.StatusCode
+	}
+	return http.StatusInternalServerError
 }
 
 // NewDeliveryHandler creates a new http.HandlerFunc that can be used to listen
 // for delivery receipts from the Nexmo server. Any receipts received will be
-// decoded nad passed to the out chan.
-func NewDeliveryHandler(out chan *DeliveryReceipt, verifyIPs bool) http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
-		if verifyIPs {
-			// Check if the request came from Nexmo
-			host, _, err := net.SplitHostPort(req.RemoteAddr)
-			if !IsTrustedIP(host) || err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
-				return
-			}
-		}
+// decoded nad passed to the out chan. By default the handler blocks until the
+// receipt is received from out, see WithBuffer, WithSendTimeout and
+// WithDropWhenFull for alternatives.
+func NewDeliveryHandler(out chan *DeliveryReceipt, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	// Pass it out on the chan
+	send := channelSender(out, newHandlerConfig(handlerName("delivery", opts)))
+	return NewDeliveryHandlerFunc(send, verifyIPs, opts...)
+}
 
-		var err error
-		// Check if the query is empty. If it is, it's just Nexmo
-		// making sure our service is up, so we don't want to return
-		// an error.
-		if req.URL.RawQuery == "" {
-			return
-		}
+// NewDeliveryHandlerFunc creates a new http.HandlerFunc that can be used to
+// listen for delivery receipts from the Nexmo server. Any receipts received
+// will be decoded and passed to fn. If fn returns an error, Nexmo is answered
+// with a 500, or with the status code of a returned HandlerError.
+func NewDeliveryHandlerFunc(fn func(*DeliveryReceipt) error, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	return newWebhookHandler(parseDeliveryReceipt, fn, verifyIPs, handlerName("delivery", opts))
+}
 
-		req.ParseForm()
-		// Decode the form data
-		m := new(DeliveryReceipt)
+// NewMessageHandler creates a new http.HandlerFunc that can be used to listen
+// for new messages from the Nexmo server. Any new messages received will be
+// decoded and passed to the out chan. By default the handler blocks until the
+// message is received from out, see WithBuffer, WithSendTimeout and
+// WithDropWhenFull for alternatives.
+func NewMessageHandler(out chan *ReceivedMessage, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	// Pass it out on the chan
+	send := channelSender(out, newHandlerConfig(handlerName("message", opts)))
+	return NewMessageHandlerFunc(send, verifyIPs, opts...)
+}
 
-		m.To = req.FormValue("to")
-		m.NetworkCode = req.FormValue("network-code")
-		m.MessageID = req.FormValue("messageId")
-		m.MSISDN = req.FormValue("msisdn")
-		m.Status = req.FormValue("status")
-		m.ErrorCode = req.FormValue("err-code")
-		m.Price = req.FormValue("price")
-		m.ClientReference = req.FormValue("client-ref")
+// NewMessageHandlerFunc creates a new http.HandlerFunc that can be used to
+// listen for new messages from the Nexmo server. Any new messages received
+// will be decoded and passed to fn. If fn returns an error, Nexmo is answered
+// with a 500, or with the status code of a returned HandlerError.
+func NewMessageHandlerFunc(fn func(*ReceivedMessage) error, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	return newWebhookHandler(parseReceivedMessage, fn, verifyIPs, handlerName("message", opts))
+}
 
-		t, err := url.QueryUnescape(req.FormValue("scts"))
-		if err != nil {
-			http.Error(w, "", http.StatusInternalServerError)
-			return
-		}
+// NewVoiceEventHandler creates a new http.HandlerFunc that can be used as the
+// event URL of a Voice API application. Any call events received will be
+// decoded and passed to the out chan.
+func NewVoiceEventHandler(out chan *VoiceEvent, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	// Pass it out on the chan
+	send := channelSender(out, newHandlerConfig(handlerName("voice", opts)))
+	return NewVoiceEventHandlerFunc(send, verifyIPs, opts...)
+}
 
-		// Convert the timestamp to a time.Time.
-		timestamp, err := time.Parse("0601021504", t)
-		if err != nil {
-			http.Error(w, "", http.StatusInternalServerError)
-			return
-		}
+// NewVoiceEventHandlerFunc creates a new http.HandlerFunc that can be used as
+// the event URL of a Voice API application. Any call events received will be
+// decoded and passed to fn. If fn returns an error, Nexmo is answered with a
+// 500, or with the status code of a returned HandlerError.
+func NewVoiceEventHandlerFunc(fn func(*VoiceEvent) error, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	return newWebhookHandler(withoutTimestamps(ParseVoiceEvent), fn, verifyIPs, handlerName("voice", opts))
+}
 
-		m.SCTS = timestamp
+// NewVerifyEventHandler creates a new http.HandlerFunc that can be used to
+// listen for Verify API status callbacks. Any events received will be decoded
+// and passed to the out chan.
+func NewVerifyEventHandler(out chan *VerifyEvent, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	// Pass it out on the chan
+	send := channelSender(out, newHandlerConfig(handlerName("verify", opts)))
+	return NewVerifyEventHandlerFunc(send, verifyIPs, opts...)
+}
 
-		t, err = url.QueryUnescape(req.FormValue("message-timestamp"))
-		if err != nil {
-			http.Error(w, "", http.StatusInternalServerError)
-			return
-		}
+// NewVerifyEventHandlerFunc creates a new http.HandlerFunc that can be used to
+// listen for Verify API status callbacks. Any events received will be decoded
+// and passed to fn. If fn returns an error, Nexmo is answered with a 500, or
+// with the status code of a returned HandlerError.
+func NewVerifyEventHandlerFunc(fn func(*VerifyEvent) error, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	return newWebhookHandler(withoutTimestamps(ParseVerifyEvent), fn, verifyIPs, handlerName("verify", opts))
+}
 
-		// Convert the timestamp to a time.Time.
-		timestamp, err = time.Parse("2006-01-02 15:04:05", t)
-		if err != nil {
-			http.Error(w, "", http.StatusInternalServerError)
-			return
-		}
+// NewMessageStatusHandler creates a new http.HandlerFunc that can be used to
+// listen for status callbacks of the Messages API. Any statuses received will
+// be decoded and passed to the out chan.
+func NewMessageStatusHandler(out chan *MessageStatus, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	// Pass it out on the chan
+	send := channelSender(out, newHandlerConfig(handlerName("status", opts)))
+	return NewMessageStatusHandlerFunc(send, verifyIPs, opts...)
+}
 
-		m.Timestamp = timestamp
+// NewMessageStatusHandlerFunc creates a new http.HandlerFunc that can be used
+// to listen for status callbacks of the Messages API. Any statuses received
+// will be decoded and passed to fn. If fn returns an error, Nexmo is answered
+// with a 500, or with the status code of a returned HandlerError.
+func NewMessageStatusHandlerFunc(fn func(*MessageStatus) error, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	return newWebhookHandler(withoutTimestamps(ParseMessageStatus), fn, verifyIPs, handlerName("status", opts))
+}
 
-		// Pass it out on the chan
-		out <- m
-	}
+// NewAnswerHandler creates a new http.HandlerFunc that can be used as the
+// answer URL of a Voice API application. Every call is answered with ncco and
+// passed to the out chan.
+func NewAnswerHandler(out chan *AnswerRequest, ncco NCCO, verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	// Pass it out on the chan
+	send := channelSender(out, newHandlerConfig(handlerName("answer", opts)))
+	return NewAnswerHandlerFunc(func(a *AnswerRequest) (NCCO, error) {
+		return ncco, send(a)
+	}, verifyIPs, opts...)
+}
 
+// NewAnswerHandlerFunc creates a new http.HandlerFunc that can be used as the
+// answer URL of a Voice API application. The NCCO returned by fn is used to
+// control the call. If fn returns an error, Nexmo is answered with a 500, or
+// with the status code of a returned HandlerError.
+func NewAnswerHandlerFunc(fn func(*AnswerRequest) (NCCO, error), verifyIPs bool, opts ...HandlerOption) http.HandlerFunc {
+	return newWebhookHandler(withoutTimestamps(ParseAnswerRequest), func(a *AnswerRequest) (err error) {
+		a.ncco, err = fn(a)
+		return err
+	}, verifyIPs, handlerName("answer", opts))
 }
 
-// NewMessageHandler creates a new http.HandlerFunc that can be used to listen
-// for new messages from the Nexmo server. Any new messages received will be
-// decoded and passed to the out chan.
-func NewMessageHandler(out chan *ReceivedMessage, verifyIPs bool) http.HandlerFunc {
+// newWebhookHandler returns an http.HandlerFunc which checks where a callback
+// came from, decodes it using parse and passes the result to fn.
+func newWebhookHandler[T any](parse func(*http.Request, *TimestampParser) (T, error), fn func(T) error, verifyIPs bool, opts []HandlerOption) http.HandlerFunc {
+	cfg := newHandlerConfig(opts)
+
+	var limiter *rateLimiter
+	if cfg.rate > 0 {
+		limiter = newRateLimiter(cfg.clock, cfg.rate, cfg.burst)
+	}
+
+	var inFlight chan struct{}
+	if cfg.maxConcurrent > 0 {
+		inFlight = make(chan struct{}, cfg.maxConcurrent)
+	}
+
 	return func(w http.ResponseWriter, req *http.Request) {
+		received := cfg.clock.Now()
+		if req.Body != nil {
+			req.Body = http.MaxBytesReader(w, req.Body, cfg.maxBodySize)
+		}
+
+		var payload []byte
+		if cfg.metrics != nil {
+			cfg.metrics.CallbackReceived(cfg.name)
+			defer func() {
+				cfg.metrics.CallbackHandled(cfg.name, cfg.clock.Now().Sub(received))
+			}()
+		}
+
+		reject := func(code int, reason string, err error) {
+			if cfg.metrics != nil {
+				cfg.metrics.CallbackRejected(cfg.name, reason)
+			}
+			if cfg.errorHandler != nil {
+				cfg.errorHandler(req, payload, err)
+			}
+			http.Error(w, "", code)
+		}
+
 		if verifyIPs {
 			// Check if the request came from Nexmo
-			host, _, err := net.SplitHostPort(req.RemoteAddr)
-			if !IsTrustedIP(host) || err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
+			if err := checkIP(cfg.trustedIPs, req); err != nil {
+				payload = []byte(req.URL.RawQuery)
+				reject(cfg.untrustedIPStatus, RejectUntrustedIP, err)
 				return
 			}
 		}
 
-		var err error
+		if cfg.errorHandler != nil || cfg.rawHeaders != nil {
+			var err error
+			if payload, err = capturePayload(req); err != nil {
+				reject(payloadStatus(cfg, err), RejectParseError, err)
+				return
+			}
+		}
 
-		// Check if the query is empty. If it is, it's just Nexmo
+		// Check if the request is empty. If it is, it's just Nexmo
 		// making sure our service is up, so we don't want to return
 		// an error.
-		if req.URL.RawQuery == "" {
+		if isProbe(req) {
 			return
 		}
 
-		req.ParseForm()
-		// Decode the form data
-		m := new(ReceivedMessage)
-		switch req.FormValue("type") {
-		case "text":
-			m.Text, err = url.QueryUnescape(req.FormValue("text"))
-			if err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
+		// Shed load before doing any real work.
+		if limiter != nil && !limiter.allow() {
+			reject(http.StatusTooManyRequests, RejectRateLimited, ErrRateLimited)
+			return
+		}
+		if inFlight != nil {
+			select {
+			case inFlight <- struct{}{}:
+				defer func() { <-inFlight }()
+			default:
+				reject(http.StatusServiceUnavailable, RejectOverloaded, ErrOverloaded)
 				return
 			}
-			m.Type = TextMessage
-		case "unicode":
-			m.Text, err = url.QueryUnescape(req.FormValue("text"))
+		}
+
+		if cfg.sigSecret != "" {
+			fields, err := payloadValues(req)
 			if err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
+				reject(payloadStatus(cfg, err), RejectParseError, err)
 				return
 			}
-			m.Type = UnicodeMessage
+			verify := VerifySignature
+			if isReceiptPayload(fields) {
+				verify = VerifyReceiptSignature
+			}
+			if err := verify(fields, cfg.sigSecret, cfg.sigMethod); err != nil {
+				reject(http.StatusUnauthorized, RejectBadSignature, err)
+				return
+			}
+		}
 
-			// TODO: I have no idea if this data stuff works, as I'm unable to
-			// send data SMS messages.
-		case "binary":
-			data, err := url.QueryUnescape(req.FormValue("data"))
+		var replayKey string
+		if cfg.replay != nil {
+			fields, err := payloadValues(req)
 			if err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
+				reject(payloadStatus(cfg, err), RejectParseError, err)
 				return
 			}
-			m.Data = []byte(data)
 
-			udh, err := url.QueryUnescape(req.FormValue("udh"))
+			replayKey, err = cfg.replay.Check(fields)
 			if err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
+				reject(replayStatus(err), RejectReplay, err)
 				return
 			}
-			m.UDH = []byte(udh)
-			m.Type = BinaryMessage
+		}
 
-		default:
-			//error
-			http.Error(w, "", http.StatusInternalServerError)
+		m, err := parse(req, cfg.timestamps)
+		if err != nil {
+			if cfg.replay != nil {
+				cfg.replay.Release(replayKey)
+			}
+			reject(payloadStatus(cfg, err), RejectParseError, err)
 			return
 		}
 
-		m.To = req.FormValue("to")
-		m.MSISDN = req.FormValue("msisdn")
-		m.NetworkCode = req.FormValue("network-code")
-		m.ID = req.FormValue("messageId")
+		if cfg.rawHeaders != nil {
+			if r, ok := any(m).(interface{ setRaw(*RawPayload) }); ok {
+				r.setRaw(newRawPayload(req, payload, cfg.rawHeaders))
+			}
+		}
 
-		m.Keyword = req.FormValue("keyword")
-		t, err := url.QueryUnescape(req.FormValue("message-timestamp"))
-		if err != nil {
-			http.Error(w, "", http.StatusInternalServerError)
-			return
+		if r, ok := any(m).(interface{ setMeta(RequestMeta) }); ok {
+			r.setMeta(newRequestMeta(req, cfg.requestIDHeader, received))
 		}
 
-		// Convert the timestamp to a time.Time.
-		timestamp, err := time.Parse("2006-01-02 15:04:05", t)
-		if err != nil {
-			http.Error(w, "", http.StatusInternalServerError)
+		if cfg.store != nil {
+			if err := save(cfg.store, m); err != nil {
+				if cfg.replay != nil {
+					cfg.replay.Release(replayKey)
+				}
+				reject(http.StatusInternalServerError, RejectStoreError, err)
+				return
+			}
+		}
+
+		if err := fn(m); err != nil {
+			if cfg.replay != nil {
+				cfg.replay.Release(replayKey)
+			}
+			reject(statusCode(err), RejectConsumerError, err)
 			return
 		}
 
-		m.Timestamp = timestamp
+		if r, ok := any(m).(interface{ respond(http.ResponseWriter) }); ok {
+			r.respond(w)
+		}
+	}
+}
 
-		// TODO: I don't know if this works as I've been unable to send an SMS
-		// message longer than 160 characters that doesn't get concatenated
-		// automatically.
-		if req.FormValue("concat") == "true" {
-			m.Concatenated = true
-			m.Concat.Reference = req.FormValue("concat-ref")
-			m.Concat.Total, err = strconv.Atoi(req.FormValue("concat-total"))
-			if err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
+// withoutTimestamps adapts parse functions which do not need a TimestampParser
+// for use with newWebhookHandler.
+func withoutTimestamps[T any](parse func(*http.Request) (T, error)) func(*http.Request, *TimestampParser) (T, error) {
+	return func(req *http.Request, _ *TimestampParser) (T, error) {
+		return parse(req)
+	}
+}
+
+// payloadStatus returns the status code to reject a callback with when its
+// payload could not be read or parsed because of err.
+func payloadStatus(cfg *handlerConfig, err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return cfg.parseFailureStatus
+}
+
+// replayStatus returns the status code to reject a callback with when
+// ReplayProtection.Check returned err.
+func replayStatus(err error) int {
+	switch err {
+	case ErrReplayedCallback:
+		return http.StatusOK
+	case ErrStaleTimestamp, ErrMissingTimestamp:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// VerifyIPs returns middleware which only passes on requests coming from a
+// trusted Nexmo server, so the same verification as in the webhook handlers can
+// protect other routes, e.g. voice answer URLs. The WithTrustedIPs,
+// WithUntrustedIPStatus and WithErrorHandler options are honoured.
+func VerifyIPs(opts ...HandlerOption) func(http.Handler) http.Handler {
+	cfg := newHandlerConfig(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if err := checkIP(cfg.trustedIPs, req); err != nil {
+				if cfg.errorHandler != nil {
+					cfg.errorHandler(req, []byte(req.URL.RawQuery), err)
+				}
+				http.Error(w, "", cfg.untrustedIPStatus)
 				return
 			}
-			m.Concat.Part, err = strconv.Atoi(req.FormValue("concat-part"))
-			if err != nil {
-				http.Error(w, "", http.StatusInternalServerError)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// checkIP returns an error if req did not come from an IP in trusted.
+func checkIP(trusted *TrustedIPs, req *http.Request) error {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return err
+	}
+	if !trusted.IsTrustedIP(host) {
+		return ErrUntrustedIP
+	}
+	return nil
+}
+
+// newRawPayload creates a RawPayload for req, keeping only the named headers.
+func newRawPayload(req *http.Request, payload []byte, headers []string) *RawPayload {
+	raw := &RawPayload{
+		Method: req.Method,
+		Body:   payload,
+	}
+
+	for _, name := range headers {
+		if values := req.Header.Values(name); len(values) > 0 {
+			if raw.Header == nil {
+				raw.Header = make(http.Header)
+			}
+			raw.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return raw
+}
+
+// capturePayload returns the raw body of req or, if the body is empty, its
+// query string. The body is restored so it can be parsed afterwards.
+func capturePayload(req *http.Request) ([]byte, error) {
+	if req.Body != nil && req.ContentLength != 0 {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			return body, nil
+		}
+	}
+	return []byte(req.URL.RawQuery), nil
+}
+
+// isProbe reports whether req carries no callback data at all, neither in the
+// query string nor in the body. Nexmo sends such requests when checking that
+// a callback URL is reachable.
+func isProbe(req *http.Request) bool {
+	if req.Method == http.MethodHead {
+		return true
+	}
+	if req.URL.RawQuery != "" {
+		return false
+	}
+
+	switch {
+	case req.ContentLength == 0 || req.Body == nil:
+		return true
+	case req.ContentLength < 0:
+		// The length of the body is unknown, peek at it to find out if there
+		// is one at all.
+		body := bufio.NewReader(req.Body)
+		_, err := body.Peek(1)
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{body, req.Body}
+		return err == io.EOF
+	}
+	return false
+}
+
+// NewHealthHandler creates a new http.HandlerFunc answering health and
+// readiness probes, to be mounted alongside the webhook handlers. It replies
+// with a 200 OK if all checks pass, and with a 503 Service Unavailable
+// containing the error of the first failing check otherwise.
+func NewHealthHandler(checks ...func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		for _, check := range checks {
+			if err := check(); err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
 				return
 			}
 		}
 
-		// Pass it out on the chan
-		out <- m
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "ok\n")
+	}
+}
+
+// deliveryReceiptPayload mirrors a delivery receipt as it is sent on the wire,
+// where every field is a string.
+type deliveryReceiptPayload struct {
+	To              string `json:"to"`
+	NetworkCode     string `json:"network-code"`
+	MessageID       string `json:"messageId"`
+	MSISDN          string `json:"msisdn"`
+	Status          string `json:"status"`
+	ErrorCode       string `json:"err-code"`
+	Price           string `json:"price"`
+	SCTS            string `json:"scts"`
+	Timestamp       string `json:"message-timestamp"`
+	ClientReference string `json:"client-ref"`
+	APIKey          string `json:"api-key"`
+}
+
+// ParseDeliveryReceipt decodes a delivery receipt from an incoming Nexmo
+// callback. The receipt may be passed in the query string (GET), as an
+// URL-encoded POST body or, for accounts configured for JSON webhooks, as a
+// JSON POST body with the application/json content type. Timestamps are
+// parsed with DefaultTimestampParser.
+func ParseDeliveryReceipt(req *http.Request) (*DeliveryReceipt, error) {
+	return parseDeliveryReceipt(req, DefaultTimestampParser)
+}
+
+func parseDeliveryReceipt(req *http.Request, tp *TimestampParser) (*DeliveryReceipt, error) {
+	var p deliveryReceiptPayload
+
+	if isJSON(req) {
+		if err := json.NewDecoder(req.Body).Decode(&p); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+
+		p.To = req.FormValue("to")
+		p.NetworkCode = req.FormValue("network-code")
+		p.MessageID = req.FormValue("messageId")
+		p.MSISDN = req.FormValue("msisdn")
+		p.Status = req.FormValue("status")
+		p.ErrorCode = req.FormValue("err-code")
+		p.Price = req.FormValue("price")
+		p.SCTS = req.FormValue("scts")
+		p.Timestamp = req.FormValue("message-timestamp")
+		p.ClientReference = req.FormValue("client-ref")
+		p.APIKey = req.FormValue("api-key")
+	}
+
+	m := &DeliveryReceipt{
+		To:              p.To,
+		NetworkCode:     p.NetworkCode,
+		MessageID:       p.MessageID,
+		MSISDN:          p.MSISDN,
+		Status:          ParseDeliveryStatus(p.Status),
+		Price:           p.Price,
+		ClientReference: p.ClientReference,
+		APIKey:          p.APIKey,
+	}
+
+	if p.ErrorCode != "" {
+		code, err := strconv.Atoi(p.ErrorCode)
+		if err != nil {
+			return nil, err
+		}
+		m.ErrorCode = DLRErrorCode(code)
+	}
+
+	t, err := url.QueryUnescape(p.SCTS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the timestamp to a time.Time.
+	timestamp, err := tp.ParseSCTS(t)
+	if err != nil {
+		return nil, err
+	}
+
+	m.SCTS = timestamp
+
+	t, err = url.QueryUnescape(p.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the timestamp to a time.Time.
+	timestamp, err = tp.ParseTimestamp(t)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Timestamp = timestamp
+	return m, nil
+}
+
+// isJSON reports whether the body of req is declared as JSON.
+func isJSON(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// ParseReceivedMessage decodes an inbound message from an incoming Nexmo
+// callback. The message may be passed in the query string (GET), as an
+// URL-encoded POST body or as a JSON POST body with the application/json
+// content type. Timestamps are parsed with DefaultTimestampParser.
+func ParseReceivedMessage(req *http.Request) (*ReceivedMessage, error) {
+	return parseReceivedMessage(req, DefaultTimestampParser)
+}
+
+func parseReceivedMessage(req *http.Request, tp *TimestampParser) (*ReceivedMessage, error) {
+	var (
+		form     url.Values
+		unescape = url.QueryUnescape
+		err      error
+	)
+
+	if isJSON(req) {
+		form, err = payloadValues(req)
+		if err != nil {
+			return nil, err
+		}
+		// Values in JSON bodies are not URL encoded.
+		unescape = func(s string) (string, error) { return s, nil }
+	} else {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		form = req.Form
+	}
+
+	// Decode the form data
+	m := new(ReceivedMessage)
+	switch form.Get("type") {
+	case "text":
+		m.Text, err = unescape(form.Get("text"))
+		if err != nil {
+			return nil, err
+		}
+		m.Type = TextMessage
+	case "unicode":
+		m.Text, err = unescape(form.Get("text"))
+		if err != nil {
+			return nil, err
+		}
+		m.Type = UnicodeMessage
+
+		// TODO: I have no idea if this data stuff works, as I'm unable to
+		// send data SMS messages.
+	case "binary":
+		data, err := unescape(form.Get("data"))
+		if err != nil {
+			return nil, err
+		}
+		m.Data = []byte(data)
+
+		udh, err := unescape(form.Get("udh"))
+		if err != nil {
+			return nil, err
+		}
+		m.UDH = []byte(udh)
+		m.Type = BinaryMessage
+
+	default:
+		return nil, fmt.Errorf("unknown message type %q", form.Get("type"))
+	}
+
+	m.To = form.Get("to")
+	m.MSISDN = form.Get("msisdn")
+	m.NetworkCode = form.Get("network-code")
+	m.ID = form.Get("messageId")
+
+	m.Keyword = form.Get("keyword")
+	t, err := unescape(form.Get("message-timestamp"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the timestamp to a time.Time.
+	timestamp, err := tp.ParseTimestamp(t)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Timestamp = timestamp
+
+	// TODO: I don't know if this works as I've been unable to send an SMS
+	// message longer than 160 characters that doesn't get concatenated
+	// automatically.
+	if form.Get("concat") == "true" {
+		m.Concatenated = true
+		m.Concat.Reference = form.Get("concat-ref")
+		m.Concat.Total, err = strconv.Atoi(form.Get("concat-total"))
+		if err != nil {
+			return nil, err
+		}
+		m.Concat.Part, err = strconv.Atoi(form.Get("concat-part"))
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	return m, nil
 }
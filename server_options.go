@@ -0,0 +1,372 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// HandlerOption configures the handlers returned by NewMessageHandler,
+// NewDeliveryHandler and their callback based variants.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	// Backpressure policy for the channel based handlers.
+	bufferSize   int
+	sendTimeout  time.Duration
+	dropWhenFull bool
+	dropped      *uint64
+	done         <-chan struct{}
+
+	name         string
+	errorHandler ErrorHandler
+	trustedIPs   *TrustedIPs
+	metrics      HandlerMetrics
+	replay       *ReplayProtection
+	rawHeaders   []string
+	timestamps   *TimestampParser
+	store        Store
+
+	sigSecret string
+	sigMethod SignatureMethod
+
+	requestIDHeader string
+	clock           Clock
+	maxBodySize     int64
+
+	// Load shedding.
+	rate          float64
+	burst         int
+	maxConcurrent int
+
+	// Status codes used to answer Nexmo when rejecting a callback.
+	parseFailureStatus int
+	untrustedIPStatus  int
+	backpressureStatus int
+}
+
+func newHandlerConfig(opts []HandlerOption) *handlerConfig {
+	cfg := &handlerConfig{
+		parseFailureStatus: http.StatusInternalServerError,
+		untrustedIPStatus:  http.StatusInternalServerError,
+		backpressureStatus: http.StatusServiceUnavailable,
+		trustedIPs:         DefaultTrustedIPs,
+		timestamps:         DefaultTimestampParser,
+		requestIDHeader:    DefaultRequestIDHeader,
+		clock:              SystemClock,
+		maxBodySize:        DefaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// handlerName is prepended to the options of a handler to set the default
+// name it reports metrics under.
+func handlerName(name string, opts []HandlerOption) []HandlerOption {
+	return append([]HandlerOption{WithHandlerName(name)}, opts...)
+}
+
+// WithHandlerName sets the name a handler reports its metrics under. It
+// defaults to "message", "delivery", "status", "verify", "voice", "answer" or
+// "event" depending on the kind of handler.
+func WithHandlerName(name string) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.name = name
+	}
+}
+
+// WithMetrics makes a handler report its activity to m.
+func WithMetrics(m HandlerMetrics) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.metrics = m
+	}
+}
+
+// WithReplayProtection makes a handler reject callbacks which are too old or
+// have already been processed, as determined by r. Stale callbacks are answered
+// with a 403 Forbidden. Duplicates are answered with a 200 OK, as they are most
+// likely retries of callbacks whose acknowledgement got lost.
+func WithReplayProtection(r *ReplayProtection) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.replay = r
+	}
+}
+
+// WithSignatureSecret makes a handler verify the signature of callbacks, for
+// accounts with callback signing enabled. Every handler can be given the
+// secret it is signed with, e.g. the one of the subaccount the delivery
+// receipts it receives belong to. Unsigned callbacks and those with an
+// invalid signature are answered with a 401 Unauthorized and reported to the
+// metrics as RejectBadSignature.
+//
+// All the fields of a callback but "sig" are signed, sorted by name in byte
+// order. Delivery receipts are verified with VerifyReceiptSignature: only
+// their own fields are signed, so "message-timestamp" and "err-code" come
+// before "messageId", and parameters of the callback URL are left out.
+func WithSignatureSecret(secret string, method SignatureMethod) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.sigSecret = secret
+		cfg.sigMethod = method
+	}
+}
+
+// WithRawPayload makes a handler attach the callback as it was received to the
+// Raw field of the decoded messages and receipts, so it can be archived for
+// auditing or parsed again later. Besides the body, the named headers are
+// kept; Content-Type is always kept.
+func WithRawPayload(headers ...string) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.rawHeaders = append([]string{"Content-Type"}, headers...)
+	}
+}
+
+// WithTimestampParser makes a handler parse timestamps with p instead of
+// DefaultTimestampParser.
+func WithTimestampParser(p *TimestampParser) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.timestamps = p
+	}
+}
+
+// WithStore makes a handler persist every message and receipt in s before
+// passing it on. If s fails, Nexmo is answered with a 500 Internal Server
+// Error and will retry the callback later.
+func WithStore(s Store) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.store = s
+	}
+}
+
+// WithRateLimit makes a handler accept at most perSecond callbacks per second
+// on average, with bursts of up to burst callbacks. Callbacks beyond that are
+// answered with a 429 Too Many Requests and retried by Nexmo later. Every
+// handler has its own limit, even if they are given the same option. If
+// perSecond is 0 or less, callbacks are not limited.
+func WithRateLimit(perSecond float64, burst int) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.rate = perSecond
+		cfg.burst = burst
+	}
+}
+
+// WithMaxConcurrent makes a handler process at most n callbacks at the same
+// time. Callbacks beyond that are answered with a 503 Service Unavailable and
+// retried by Nexmo later, so a stalled consumer does not pile up requests.
+func WithMaxConcurrent(n int) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.maxConcurrent = n
+	}
+}
+
+// DefaultMaxBodySize is the size of the largest callback body a handler reads,
+// unless set with WithMaxBodySize.
+const DefaultMaxBodySize = 1 << 20
+
+// WithMaxBodySize makes a handler reject callbacks whose body is larger than n
+// bytes with a 413 Request Entity Too Large.
+func WithMaxBodySize(n int64) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.maxBodySize = n
+	}
+}
+
+// WithRequestIDHeader sets the header the request ID in the Meta of messages
+// and receipts is taken from. Defaults to DefaultRequestIDHeader.
+func WithRequestIDHeader(name string) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.requestIDHeader = name
+	}
+}
+
+// WithClock makes a handler, and the rate limiter and send timeout configured
+// for it, tell the time with c instead of SystemClock.
+func WithClock(c Clock) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.clock = c
+	}
+}
+
+// WithBuffer makes a channel handler queue up to size values internally, so
+// short stalls of the consumer do not hold up the HTTP handler. Once the buffer
+// is full, the handler blocks, times out or drops values as configured with
+// WithSendTimeout and WithDropWhenFull.
+func WithBuffer(size int) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.bufferSize = size
+	}
+}
+
+// WithHandlerContext stops a channel handler once ctx is done: the goroutine
+// started by WithBuffer exits, values still buffered are dropped, and further
+// callbacks are answered with a 503 Service Unavailable.
+func WithHandlerContext(ctx context.Context) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.done = ctx.Done()
+	}
+}
+
+// WithSendTimeout makes a channel handler wait at most d for the consumer to
+// receive a value. If the consumer does not receive it in time, Nexmo is
+// answered with a 503 Service Unavailable (see WithBackpressureStatus) and
+// will retry the callback later.
+func WithSendTimeout(d time.Duration) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.sendTimeout = d
+	}
+}
+
+// WithDropWhenFull makes a channel handler drop values instead of blocking
+// when the consumer is not ready to receive them. Nexmo is still answered
+// with a 200 OK, so dropped values are lost. If dropped is not nil, it is
+// incremented atomically for every value dropped.
+func WithDropWhenFull(dropped *uint64) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.dropWhenFull = true
+		cfg.dropped = dropped
+	}
+}
+
+// ErrorHandler is called by the webhook handlers whenever a callback from Nexmo
+// is rejected, be it because it came from an untrusted IP, could not be parsed
+// or was refused by the consumer. payload holds the raw request body or, for
+// GET callbacks, the raw query string. The body of callbacks from untrusted
+// IPs is not read, so their payload is always the query string.
+type ErrorHandler func(req *http.Request, payload []byte, err error)
+
+// WithErrorHandler sets fn to be called whenever a callback is rejected.
+func WithErrorHandler(fn ErrorHandler) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.errorHandler = fn
+	}
+}
+
+// WithErrorLog logs every rejected callback, along with its payload, to l.
+func WithErrorLog(l *log.Logger) HandlerOption {
+	return WithErrorHandler(func(req *http.Request, payload []byte, err error) {
+		l.Printf("nexmo: rejected %s %s from %s: %v (payload: %q)",
+			req.Method, req.URL.Path, req.RemoteAddr, err, payload)
+	})
+}
+
+// WithTrustedIPs makes a handler verifying IPs accept callbacks from the
+// ranges in t instead of those in DefaultTrustedIPs.
+func WithTrustedIPs(t *TrustedIPs) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.trustedIPs = t
+	}
+}
+
+// WithParseFailureStatus sets the status code returned for callbacks which
+// can not be parsed. Defaults to 500 Internal Server Error, which makes Nexmo
+// retry the callback; use http.StatusOK to acknowledge and discard them.
+func WithParseFailureStatus(code int) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.parseFailureStatus = code
+	}
+}
+
+// WithUntrustedIPStatus sets the status code returned for callbacks from
+// untrusted IPs when IP verification is enabled. Defaults to 500 Internal
+// Server Error.
+func WithUntrustedIPStatus(code int) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.untrustedIPStatus = code
+	}
+}
+
+// WithBackpressureStatus sets the status code returned when the consumer of a
+// channel handler configured with WithSendTimeout does not keep up. Defaults
+// to 503 Service Unavailable, which makes Nexmo retry the callback later.
+func WithBackpressureStatus(code int) HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.backpressureStatus = code
+	}
+}
+
+// ErrConsumerTimeout is returned, wrapped in a HandlerError, by channel
+// handlers configured with WithSendTimeout when the consumer did not receive a
+// value in time.
+var ErrConsumerTimeout = errors.New("timed out waiting for the consumer")
+
+// ErrHandlerStopped is returned, wrapped in a HandlerError, by channel handlers
+// whose context set with WithHandlerContext is done.
+var ErrHandlerStopped = errors.New("handler stopped")
+
+// channelSender returns a callback passing values to out according to the
+// backpressure policy in cfg.
+func channelSender[T any](out chan T, cfg *handlerConfig) func(T) error {
+	if cfg.bufferSize > 0 {
+		buf := make(chan T, cfg.bufferSize)
+		go func(out chan T) {
+			for {
+				select {
+				case v := <-buf:
+					select {
+					case out <- v:
+					case <-cfg.done:
+						return
+					}
+				case <-cfg.done:
+					return
+				}
+			}
+		}(out)
+		out = buf
+	}
+
+	stopped := &HandlerError{
+		StatusCode: http.StatusServiceUnavailable,
+		Err:        ErrHandlerStopped,
+	}
+
+	return func(v T) error {
+		select {
+		case <-cfg.done:
+			return stopped
+		default:
+		}
+
+		switch {
+		case cfg.dropWhenFull:
+			select {
+			case out <- v:
+			default:
+				if cfg.dropped != nil {
+					atomic.AddUint64(cfg.dropped, 1)
+				}
+				if cfg.metrics != nil {
+					cfg.metrics.Backpressure(cfg.name, BackpressureDropped)
+				}
+			}
+			return nil
+
+		case cfg.sendTimeout > 0:
+			select {
+			case out <- v:
+				return nil
+			case <-cfg.done:
+				return stopped
+			case <-cfg.clock.After(cfg.sendTimeout):
+				if cfg.metrics != nil {
+					cfg.metrics.Backpressure(cfg.name, BackpressureTimeout)
+				}
+				return &HandlerError{
+					StatusCode: cfg.backpressureStatus,
+					Err:        ErrConsumerTimeout,
+				}
+			}
+		}
+
+		select {
+		case out <- v:
+			return nil
+		case <-cfg.done:
+			return stopped
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendTimeout(t *testing.T) {
+	out := make(chan *ReceivedMessage)
+	h := NewMessageHandler(out, false, WithSendTimeout(10*time.Millisecond))
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", testInboundValues))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestDropWhenFull(t *testing.T) {
+	var dropped uint64
+	out := make(chan *DeliveryReceipt, 1)
+	h := NewDeliveryHandler(out, false, WithDropWhenFull(&dropped))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		h(w, newFormRequest("GET", testReceiptValues))
+		if w.Code != http.StatusOK {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+
+	if n := atomic.LoadUint64(&dropped); n != 2 {
+		t.Errorf("got %d dropped receipts, want 2", n)
+	}
+	if len(out) != 1 {
+		t.Errorf("got %d receipts on the channel, want 1", len(out))
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	out := make(chan *ReceivedMessage)
+	h := NewMessageHandler(out, false, WithBuffer(2), WithSendTimeout(10*time.Millisecond))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h(w, newFormRequest("POST", testInboundValues))
+		if w.Code != http.StatusOK {
+			t.Errorf("message %d: got status %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-out:
+		case <-time.After(time.Second):
+			t.Fatal("buffered message was not forwarded to the consumer")
+		}
+	}
+}
+
+func TestHandlerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan *ReceivedMessage)
+	h := NewMessageHandler(out, false, WithBuffer(1), WithHandlerContext(ctx))
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", testInboundValues))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	cancel()
+	w = httptest.NewRecorder()
+	h(w, newFormRequest("POST", testInboundValues))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("stopped handler: got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	var (
+		gotPayload []byte
+		gotErr     error
+	)
+	onError := WithErrorHandler(func(req *http.Request, payload []byte, err error) {
+		gotPayload, gotErr = payload, err
+	})
+
+	out := make(chan *ReceivedMessage, 1)
+	h := NewMessageHandler(out, false, onError)
+
+	values := url.Values{"type": {"bogus"}}
+	h(httptest.NewRecorder(), newFormRequest("POST", values))
+	if gotErr == nil {
+		t.Fatal("error handler was not called for an invalid message")
+	}
+	if string(gotPayload) != values.Encode() {
+		t.Errorf("got payload %q, want %q", gotPayload, values.Encode())
+	}
+
+	gotErr = nil
+	h = NewMessageHandler(out, true, onError)
+	req := newFormRequest("GET", testInboundValues)
+	req.RemoteAddr = "192.0.2.1:1234"
+	h(httptest.NewRecorder(), req)
+	if gotErr != ErrUntrustedIP {
+		t.Errorf("got error %v, want %v", gotErr, ErrUntrustedIP)
+	}
+	if string(gotPayload) != testInboundValues.Encode() {
+		t.Errorf("got payload %q, want %q", gotPayload, testInboundValues.Encode())
+	}
+
+	// The body of callbacks from untrusted IPs is not read.
+	gotErr = nil
+	req = newFormRequest("POST", testInboundValues)
+	req.RemoteAddr = "192.0.2.1:1234"
+	h(httptest.NewRecorder(), req)
+	if gotErr != ErrUntrustedIP || len(gotPayload) != 0 {
+		t.Errorf("got error %v and payload %q", gotErr, gotPayload)
+	}
+	if n, _ := req.Body.Read(make([]byte, 1)); n != 1 {
+		t.Error("body of an untrusted callback was read")
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	var gotErr error
+	out := make(chan *ReceivedMessage, 1)
+	h := NewMessageHandler(out, false, WithMaxBodySize(16),
+		WithErrorHandler(func(req *http.Request, payload []byte, err error) { gotErr = err }))
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", testInboundValues))
+	if w.Code != http.StatusRequestEntityTooLarge || gotErr == nil {
+		t.Errorf("got status %d and error %v", w.Code, gotErr)
+	}
+	if len(out) != 0 {
+		t.Error("oversized callback was passed on")
+	}
+}
+
+func TestAcknowledgementStatus(t *testing.T) {
+	out := make(chan *ReceivedMessage)
+	h := NewMessageHandler(out, true,
+		WithParseFailureStatus(http.StatusOK),
+		WithUntrustedIPStatus(http.StatusForbidden),
+		WithBackpressureStatus(http.StatusTooManyRequests),
+		WithSendTimeout(time.Millisecond))
+
+	req := newFormRequest("POST", url.Values{"type": {"bogus"}})
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("untrusted IP: got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	req = newFormRequest("POST", url.Values{"type": {"bogus"}})
+	req.RemoteAddr = "174.37.245.33:1234"
+	w = httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("parse failure: got status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	req = newFormRequest("POST", testInboundValues)
+	req.RemoteAddr = "174.37.245.33:1234"
+	w = httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("backpressure: got status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRawPayload(t *testing.T) {
+	out := make(chan *ReceivedMessage, 1)
+	h := NewMessageHandler(out, false, WithRawPayload("User-Agent"))
+
+	req := newFormRequest("POST", testInboundValues)
+	req.Header.Set("User-Agent", "Nexmo/MessagingHUB/v1.0")
+	req.Header.Set("X-Unrelated", "dropped")
+	h(httptest.NewRecorder(), req)
+
+	m := <-out
+	if m.Raw == nil {
+		t.Fatal("raw payload was not attached")
+	}
+	if string(m.Raw.Body) != testInboundValues.Encode() || m.Raw.Method != "POST" {
+		t.Errorf("unexpected raw payload %#v", m.Raw)
+	}
+	if m.Raw.Header.Get("User-Agent") == "" || m.Raw.Header.Get("X-Unrelated") != "" {
+		t.Errorf("unexpected raw headers %v", m.Raw.Header)
+	}
+
+	h = NewMessageHandler(out, false)
+	h(httptest.NewRecorder(), newFormRequest("POST", testInboundValues))
+	if m := <-out; m.Raw != nil {
+		t.Error("raw payload was attached without WithRawPayload")
+	}
+}
+
+type testStore struct {
+	err      error
+	messages []*ReceivedMessage
+	receipts []*DeliveryReceipt
+}
+
+func (s *testStore) SaveMessage(m *ReceivedMessage) error {
+	s.messages = append(s.messages, m)
+	return s.err
+}
+
+func (s *testStore) SaveReceipt(r *DeliveryReceipt) error {
+	s.receipts = append(s.receipts, r)
+	return s.err
+}
+
+func TestStoreMessageStatus(t *testing.T) {
+	store := new(testStore)
+	out := make(chan *MessageStatus, 1)
+	h := NewMessageStatusHandler(out, false, WithStore(store))
+
+	w := httptest.NewRecorder()
+	h(w, newJSONRequest(`{"message_uuid":"aaaaaaaa-bbbb-cccc-dddd-0123456789ab","to":"447700900000",`+
+		`"status":"delivered","client_ref":"order-42"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	<-out
+	if len(store.receipts) != 1 {
+		t.Fatalf("saved %d receipts", len(store.receipts))
+	}
+	if r := store.receipts[0]; r.MessageID != "aaaaaaaa-bbbb-cccc-dddd-0123456789ab" ||
+		r.Status != DeliveryDelivered || r.MSISDN != "447700900000" || r.ClientReference != "order-42" {
+		t.Errorf("saved receipt %+v", r)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := new(testStore)
+	out := make(chan *DeliveryReceipt, 1)
+	h := NewDeliveryHandler(out, false, WithStore(store))
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", testReceiptValues))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if len(store.receipts) != 1 || store.receipts[0] != <-out {
+		t.Errorf("receipt was not stored before being passed on")
+	}
+
+	store.err = errors.New("disk full")
+	w = httptest.NewRecorder()
+	h(w, newFormRequest("POST", testReceiptValues))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if len(out) != 0 {
+		t.Error("receipt which could not be stored was passed on")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	out := make(chan *ReceivedMessage, 10)
+	h := NewMessageHandler(out, false, WithRateLimit(0.001, 2))
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		h(w, newFormRequest("POST", testInboundValues))
+		if w.Code != want {
+			t.Errorf("request %d: got status %d, want %d", i, w.Code, want)
+		}
+	}
+
+	// A rate of 0 does not limit anything.
+	if d := newRateLimiter(SystemClock, 0, 1); !d.allow() || !d.allow() {
+		t.Error("limiter with a rate of 0 did not allow events")
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := NewMessageHandlerFunc(func(*ReceivedMessage) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, false, WithMaxConcurrent(1))
+
+	done := make(chan struct{})
+	go func() {
+		h(httptest.NewRecorder(), newFormRequest("POST", testInboundValues))
+		close(done)
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", testInboundValues))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	<-done
+}
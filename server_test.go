@@ -0,0 +1,357 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testInboundValues = url.Values{
+	"type":              {"text"},
+	"to":                {"447700900000"},
+	"msisdn":            {"447700900001"},
+	"messageId":         {"0A0000000123ABCD1"},
+	"text":              {"Hello world"},
+	"keyword":           {"HELLO"},
+	"message-timestamp": {"2018-08-06 12:00:00"},
+}
+
+var testReceiptValues = url.Values{
+	"to":                {"gonexmo"},
+	"msisdn":            {"447700900001"},
+	"messageId":         {"0A0000000123ABCD1"},
+	"status":            {"delivered"},
+	"err-code":          {"0"},
+	"price":             {"0.03330000"},
+	"scts":              {"1808061200"},
+	"message-timestamp": {"2018-08-06 12:00:05"},
+	"client-ref":        {"ref-1"},
+}
+
+func newFormRequest(method string, values url.Values) *http.Request {
+	if method == "GET" {
+		return httptest.NewRequest("GET", "/inbound?"+values.Encode(), nil)
+	}
+	req := httptest.NewRequest(method, "/inbound", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestMessageHandlerMethods(t *testing.T) {
+	for _, method := range []string{"GET", "POST"} {
+		out := make(chan *ReceivedMessage, 1)
+		h := NewMessageHandler(out, false)
+
+		w := httptest.NewRecorder()
+		h(w, newFormRequest(method, testInboundValues))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d", method, w.Code, http.StatusOK)
+		}
+
+		select {
+		case m := <-out:
+			if m.Text != "Hello world" || m.MSISDN != "447700900001" {
+				t.Errorf("%s: unexpected message %#v", method, m)
+			}
+			if m.Type != TextMessage {
+				t.Errorf("%s: got type %v, want %v", method, m.Type, MessageType(TextMessage))
+			}
+			want := time.Date(2018, 8, 6, 12, 0, 0, 0, time.UTC)
+			if !m.Timestamp.Equal(want) {
+				t.Errorf("%s: got timestamp %v, want %v", method, m.Timestamp, want)
+			}
+		default:
+			t.Errorf("%s: no message was passed to the channel", method)
+		}
+	}
+}
+
+func TestDeliveryHandlerMethods(t *testing.T) {
+	for _, method := range []string{"GET", "POST"} {
+		out := make(chan *DeliveryReceipt, 1)
+		h := NewDeliveryHandler(out, false)
+
+		w := httptest.NewRecorder()
+		h(w, newFormRequest(method, testReceiptValues))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d", method, w.Code, http.StatusOK)
+		}
+
+		select {
+		case m := <-out:
+			if m.MessageID != "0A0000000123ABCD1" || m.ClientReference != "ref-1" {
+				t.Errorf("%s: unexpected receipt %#v", method, m)
+			}
+		default:
+			t.Errorf("%s: no receipt was passed to the channel", method)
+		}
+	}
+}
+
+func TestHandlerProbe(t *testing.T) {
+	for _, method := range []string{"GET", "POST"} {
+		out := make(chan *ReceivedMessage, 1)
+		h := NewMessageHandler(out, false)
+
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(method, "/inbound", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: got status %d for probe, want %d", method, w.Code, http.StatusOK)
+		}
+		if len(out) != 0 {
+			t.Errorf("%s: probe was passed to the channel", method)
+		}
+	}
+}
+
+func TestMessageHandlerInvalidType(t *testing.T) {
+	out := make(chan *ReceivedMessage, 1)
+	h := NewMessageHandler(out, false)
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", url.Values{"type": {"bogus"}}))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestParseDeliveryReceiptJSON(t *testing.T) {
+	body := `{
+		"msisdn": "447700900001",
+		"to": "gonexmo",
+		"network-code": "23410",
+		"messageId": "0A0000000123ABCD1",
+		"price": "0.03330000",
+		"status": "delivered",
+		"scts": "1808061200",
+		"err-code": "0",
+		"api-key": "abcd1234",
+		"client-ref": "ref-1",
+		"message-timestamp": "2018-08-06 12:00:05"
+	}`
+	req := httptest.NewRequest("POST", "/receipt", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	m, err := ParseDeliveryReceipt(req)
+	if err != nil {
+		t.Fatal("failed to parse JSON delivery receipt:", err)
+	}
+
+	if m.MessageID != "0A0000000123ABCD1" || m.APIKey != "abcd1234" ||
+		m.Price != "0.03330000" || m.ErrorCode != 0 || m.Status != DeliveryDelivered {
+		t.Errorf("unexpected receipt %#v", m)
+	}
+
+	want := time.Date(2018, 8, 6, 12, 0, 0, 0, time.UTC)
+	if !m.SCTS.Equal(want) {
+		t.Errorf("got SCTS %v, want %v", m.SCTS, want)
+	}
+}
+
+func TestHandlerFuncStatusCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("database unavailable"), http.StatusInternalServerError},
+		{&HandlerError{StatusCode: http.StatusServiceUnavailable}, http.StatusServiceUnavailable},
+		{&HandlerError{StatusCode: http.StatusOK, Err: errors.New("discarded")}, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		var got *ReceivedMessage
+		h := NewMessageHandlerFunc(func(m *ReceivedMessage) error {
+			got = m
+			return test.err
+		}, false)
+
+		w := httptest.NewRecorder()
+		h(w, newFormRequest("POST", testInboundValues))
+		if w.Code != test.want {
+			t.Errorf("error %v: got status %d, want %d", test.err, w.Code, test.want)
+		}
+		if got == nil {
+			t.Errorf("error %v: callback was not invoked", test.err)
+		}
+	}
+}
+
+func TestDeliveryHandlerFunc(t *testing.T) {
+	var got *DeliveryReceipt
+	h := NewDeliveryHandlerFunc(func(m *DeliveryReceipt) error {
+		got = m
+		return nil
+	}, false)
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("GET", testReceiptValues))
+	if w.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if got == nil || got.MessageID != "0A0000000123ABCD1" {
+		t.Errorf("unexpected receipt %#v", got)
+	}
+}
+
+func TestVerifyIPs(t *testing.T) {
+	trusted, _ := NewTrustedIPs("192.0.2.0/24")
+	called := false
+	h := VerifyIPs(WithTrustedIPs(trusted), WithUntrustedIPStatus(http.StatusForbidden))(
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			called = true
+		}))
+
+	tests := []struct {
+		remoteAddr string
+		want       int
+		called     bool
+	}{
+		{"192.0.2.1:1234", http.StatusOK, true},
+		{"198.51.100.1:1234", http.StatusForbidden, false},
+		{"garbage", http.StatusForbidden, false},
+	}
+
+	for _, test := range tests {
+		called = false
+		req := httptest.NewRequest("POST", "/answer", nil)
+		req.RemoteAddr = test.remoteAddr
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != test.want || called != test.called {
+			t.Errorf("%s: got status %d (called %v), want %d (called %v)",
+				test.remoteAddr, w.Code, called, test.want, test.called)
+		}
+	}
+}
+
+func TestHandlerProbeChunked(t *testing.T) {
+	out := make(chan *DeliveryReceipt, 1)
+	h := NewDeliveryHandler(out, false)
+
+	for _, body := range []string{"", testReceiptValues.Encode()} {
+		req := newFormRequest("POST", nil)
+		req.Body = ioutil.NopCloser(strings.NewReader(body))
+		req.ContentLength = -1
+
+		w := httptest.NewRecorder()
+		h(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("body %q: got status %d, want %d", body, w.Code, http.StatusOK)
+		}
+	}
+
+	if len(out) != 1 {
+		t.Errorf("got %d receipts, want 1", len(out))
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewHealthHandler()(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok\n" {
+		t.Errorf("got %d %q, want 200 \"ok\\n\"", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	NewHealthHandler(func() error {
+		return errors.New("consumer stopped")
+	})(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestVoiceEventHandler(t *testing.T) {
+	out := make(chan *VoiceEvent, 1)
+	h := NewVoiceEventHandler(out, false)
+
+	req := httptest.NewRequest("GET", "/event?uuid=aaaa&conversation_uuid=CON-bbbb"+
+		"&status=completed&duration=12&timestamp=2018-08-06T12:00:00.000Z", nil)
+	w := httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if e := <-out; e.Status != "completed" || e.Duration != "12" {
+		t.Errorf("unexpected event %#v", e)
+	}
+}
+
+func TestAnswerHandler(t *testing.T) {
+	out := make(chan *AnswerRequest, 1)
+	ncco := NCCO{{"action": "talk", "text": "Hello"}}
+	h := NewAnswerHandler(out, ncco, false)
+
+	req := httptest.NewRequest("GET", "/answer?to=447700900000&from=447700900001"+
+		"&uuid=aaaa&conversation_uuid=CON-bbbb", nil)
+	w := httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `[{"action":"talk","text":"Hello"}]` {
+		t.Errorf("got NCCO %s", body)
+	}
+	if a := <-out; a.From != "447700900001" || a.UUID != "aaaa" {
+		t.Errorf("unexpected answer request %#v", a)
+	}
+}
+
+func TestRequestMeta(t *testing.T) {
+	out := make(chan *ReceivedMessage, 2)
+	h := NewMessageHandler(out, false)
+
+	req := newFormRequest("POST", testInboundValues)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Request-Id", "abc123")
+	h(httptest.NewRecorder(), req)
+
+	m := <-out
+	if m.Meta.RequestID != "abc123" || m.Meta.RemoteIP != "192.0.2.1" {
+		t.Errorf("unexpected meta %#v", m.Meta)
+	}
+	if time.Since(m.Meta.ReceivedAt) > time.Minute {
+		t.Errorf("unexpected receive time %v", m.Meta.ReceivedAt)
+	}
+
+	// Without the header, a request ID is generated.
+	h(httptest.NewRecorder(), newFormRequest("POST", testInboundValues))
+	if m := <-out; m.Meta.RequestID == "" {
+		t.Error("no request ID was generated")
+	}
+}
+
+func TestReceivedMessageJSON(t *testing.T) {
+	m, err := ParseReceivedMessage(newFormRequest("POST", testInboundValues))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"Type":"text"`) {
+		t.Errorf("type was not marshaled as text: %s", buf)
+	}
+
+	var got ReceivedMessage
+	if err := json.Unmarshal(buf, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != TextMessage || got.Text != m.Text || !got.Timestamp.Equal(m.Timestamp) {
+		t.Errorf("round trip changed the message from %#v to %#v", m, got)
+	}
+
+	if ParseMessageType("binary") != BinaryMessage || ParseMessageType("mms") != 0 {
+		t.Error("ParseMessageType returned the wrong types")
+	}
+}
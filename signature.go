@@ -0,0 +1,190 @@
+package nexmo
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SignatureMethod is the algorithm used to sign requests and callbacks with
+// the signature secret of an account.
+type SignatureMethod string
+
+// Signature methods supported by Nexmo.
+const (
+	SignatureMD5Hash SignatureMethod = "md5hash" // MD5 of the parameters followed by the secret.
+	SignatureMD5     SignatureMethod = "md5"     // HMAC-MD5
+	SignatureSHA1    SignatureMethod = "sha1"    // HMAC-SHA1
+	SignatureSHA256  SignatureMethod = "sha256"  // HMAC-SHA256
+	SignatureSHA512  SignatureMethod = "sha512"  // HMAC-SHA512
+)
+
+// Sign computes the "sig" parameter for params using the given signature
+// secret and method. The "sig" parameter itself, if present, is ignored.
+// params should include a "timestamp" parameter holding the current Unix time.
+func Sign(params url.Values, secret string, method SignatureMethod) (string, error) {
+	return sign(signatureData(params), secret, method)
+}
+
+// sign computes the signature of data with the given secret and method.
+func sign(data, secret string, method SignatureMethod) (string, error) {
+	if method == SignatureMD5Hash {
+		sum := md5.Sum([]byte(data + secret))
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	var h func() hash.Hash
+	switch method {
+	case SignatureMD5:
+		h = md5.New
+	case SignatureSHA1:
+		h = sha1.New
+	case SignatureSHA256:
+		h = sha256.New
+	case SignatureSHA512:
+		h = sha512.New
+	default:
+		return "", fmt.Errorf("unknown signature method %q", method)
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(data))
+	return strings.ToUpper(hex.EncodeToString(mac.Sum(nil))), nil
+}
+
+// NewClientWithSignature creates a Client for an account configured to
+// require signed requests. Instead of the API secret, its requests carry a
+// "sig" parameter computed with sigSecret and method over their parameters
+// and the current time; see SignatureAuth.
+//
+// Requests sent with the credentials of WithSendCredentials are not signed.
+func NewClientWithSignature(apiKey, sigSecret string, method SignatureMethod, opts ...ClientOption) (*Client, error) {
+	if apiKey == "" {
+		return nil, errors.New("apiKey can not be empty")
+	}
+	if sigSecret == "" {
+		return nil, errors.New("sigSecret can not be empty")
+	}
+	if _, err := Sign(nil, sigSecret, method); err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		APIKey: apiKey,
+		Auth:   &SignatureAuth{APIKey: apiKey, Secret: sigSecret, Method: method},
+	}
+	c.Init()
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Errors reported when the signature of a callback is not valid.
+var (
+	ErrMissingSignature = errors.New("callback is not signed")
+	ErrInvalidSignature = errors.New("callback signature does not match")
+)
+
+// VerifySignature checks the "sig" parameter of a callback signed with the
+// given signature secret and method. The hexadecimal signature is compared
+// regardless of case, as Nexmo sends MD5 hashes in lower case and HMACs in
+// upper case.
+func VerifySignature(params url.Values, secret string, method SignatureMethod) error {
+	return verifySignature(params, signatureData(params), secret, method)
+}
+
+// VerifyReceiptSignature is like VerifySignature, for delivery receipts. Only
+// the fields of a receipt, listed in receiptSignatureFields, are signed, so
+// parameters added to the callback URL by the application don't invalidate
+// the signature.
+func VerifyReceiptSignature(params url.Values, secret string, method SignatureMethod) error {
+	return verifySignature(params, receiptSignatureData(params), secret, method)
+}
+
+// verifySignature checks the "sig" parameter of params against the signature
+// of data.
+func verifySignature(params url.Values, data, secret string, method SignatureMethod) error {
+	sig := params.Get("sig")
+	if sig == "" {
+		return ErrMissingSignature
+	}
+	want, err := sign(data, secret, method)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(strings.ToUpper(sig)), []byte(strings.ToUpper(want))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// signatureData returns the string that is hashed to sign params: every
+// parameter but "sig", sorted by name, as "&name=value" with any '&' and '='
+// in the values replaced by '_'.
+func signatureData(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "sig" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return joinSignatureData(params, keys)
+}
+
+// receiptSignatureFields are the fields of a delivery receipt covered by its
+// signature, in the order they are signed: byte order, so "err-code" and
+// "message-timestamp" come before "messageId", which comes before "msisdn".
+var receiptSignatureFields = []string{
+	"api-key",
+	"client-ref",
+	"err-code",
+	"message-timestamp",
+	"messageId",
+	"msisdn",
+	"network-code",
+	"nonce",
+	"price",
+	"scts",
+	"status",
+	"timestamp",
+	"to",
+}
+
+// receiptSignatureData is like signatureData for delivery receipts, whose
+// signature covers the receiptSignatureFields present in params only.
+func receiptSignatureData(params url.Values) string {
+	keys := make([]string, 0, len(receiptSignatureFields))
+	for _, key := range receiptSignatureFields {
+		if _, ok := params[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return joinSignatureData(params, keys)
+}
+
+// joinSignatureData returns the fields of params named by keys as
+// "&name=value", with any '&' and '=' in the values replaced by '_'.
+func joinSignatureData(params url.Values, keys []string) string {
+	replacer := strings.NewReplacer("&", "_", "=", "_")
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString("&")
+		b.WriteString(key)
+		b.WriteString("=")
+		b.WriteString(replacer.Replace(params.Get(key)))
+	}
+	return b.String()
+}
@@ -0,0 +1,182 @@
+package nexmo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	params := url.Values{
+		"api_key":   {"abcd1234"},
+		"timestamp": {"1533556800"},
+		"text":      {"a=b&c"},
+		"sig":       {"ignored"},
+	}
+
+	// The data which is signed is "&api_key=abcd1234&text=a_b_c&timestamp=1533556800".
+	if data := signatureData(params); data != "&api_key=abcd1234&text=a_b_c&timestamp=1533556800" {
+		t.Errorf("got signature data %q", data)
+	}
+
+	tests := []struct {
+		method SignatureMethod
+		want   int
+	}{
+		{SignatureMD5Hash, 32},
+		{SignatureMD5, 32},
+		{SignatureSHA1, 40},
+		{SignatureSHA256, 64},
+		{SignatureSHA512, 128},
+	}
+
+	for _, test := range tests {
+		sig, err := Sign(params, "secret", test.method)
+		if err != nil {
+			t.Errorf("%s: failed to sign: %v", test.method, err)
+		}
+		if len(sig) != test.want {
+			t.Errorf("%s: got signature %q of length %d, want %d", test.method, sig, len(sig), test.want)
+		}
+	}
+
+	if _, err := Sign(params, "secret", "crc32"); err == nil {
+		t.Error("expected an error for an unknown signature method")
+	}
+}
+
+type rejectionCounter map[string]int
+
+func (c rejectionCounter) CallbackReceived(string)                 {}
+func (c rejectionCounter) CallbackRejected(handler, reason string) { c[reason]++ }
+func (c rejectionCounter) CallbackHandled(string, time.Duration)   {}
+func (c rejectionCounter) Backpressure(handler, outcome string)    {}
+
+func TestHandlerSignatureVerification(t *testing.T) {
+	signed := signedValues(testReceiptValues, time.Now(), "")
+	sig, err := Sign(signed, "s3cr3t", SignatureSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Set("sig", strings.ToLower(sig))
+
+	// Fields are sorted in byte order, so "message-timestamp" comes before
+	// "messageId".
+	data := signatureData(signed)
+	if i, j := strings.Index(data, "&message-timestamp="), strings.Index(data, "&messageId="); i < 0 || i > j {
+		t.Errorf("got signature data %q", data)
+	}
+
+	tampered := signedValues(signed, time.Now(), "")
+	tampered.Set("status", "failed")
+
+	metrics := make(rejectionCounter)
+	var received int
+	h := NewDeliveryHandlerFunc(func(*DeliveryReceipt) error {
+		received++
+		return nil
+	}, false, WithSignatureSecret("s3cr3t", SignatureSHA256), WithMetrics(metrics))
+
+	for _, test := range []struct {
+		values url.Values
+		want   int
+	}{
+		{signed, http.StatusOK},
+		{testReceiptValues, http.StatusUnauthorized},
+		{tampered, http.StatusUnauthorized},
+	} {
+		for _, method := range []string{"GET", "POST"} {
+			w := httptest.NewRecorder()
+			h(w, newFormRequest(method, test.values))
+			if w.Code != test.want {
+				t.Errorf("%s %v: got status %d, want %d", method, test.values, w.Code, test.want)
+			}
+		}
+	}
+	if received != 2 || metrics[RejectBadSignature] != 4 {
+		t.Errorf("got %d receipts and rejections %v", received, metrics)
+	}
+}
+
+func TestReceiptSignature(t *testing.T) {
+	// A delivery receipt signed with HMAC-SHA256 and the secret "s3cr3t",
+	// received on a callback URL carrying a parameter of its own.
+	query := "msisdn=447700900000&to=AcmeInc&network-code=23410&messageId=0A0000000123ABCD1" +
+		"&price=0.03330000&status=delivered&scts=2001011400&err-code=0&api-key=abcd1234" +
+		"&message-timestamp=2020-01-01+12%3A00%3A00&timestamp=1577880000" +
+		"&nonce=aaaaaaaa-bbbb-cccc-dddd-0123456789ab" +
+		"&sig=5E722FDFB92E928B42DC42C7546F72E389A0CD0FB061377FCD133AC4DE7264B7&tenant=acme"
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "&api-key=abcd1234&err-code=0&message-timestamp=2020-01-01 12:00:00&messageId=0A0000000123ABCD1" +
+		"&msisdn=447700900000&network-code=23410&nonce=aaaaaaaa-bbbb-cccc-dddd-0123456789ab" +
+		"&price=0.03330000&scts=2001011400&status=delivered&timestamp=1577880000&to=AcmeInc"
+	if data := receiptSignatureData(params); data != want {
+		t.Errorf("got signature data %q, want %q", data, want)
+	}
+	if err := VerifyReceiptSignature(params, "s3cr3t", SignatureSHA256); err != nil {
+		t.Errorf("receipt signature: %v", err)
+	}
+	if err := VerifySignature(params, "s3cr3t", SignatureSHA256); err != ErrInvalidSignature {
+		t.Errorf("generic signature: got %v, want %v", err, ErrInvalidSignature)
+	}
+
+	var received *DeliveryReceipt
+	h := NewDeliveryHandlerFunc(func(r *DeliveryReceipt) error {
+		received = r
+		return nil
+	}, false, WithSignatureSecret("s3cr3t", SignatureSHA256))
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/delivery?"+query, nil))
+	if w.Code != http.StatusOK || received == nil || received.MessageID != "0A0000000123ABCD1" {
+		t.Errorf("got status %d and receipt %+v", w.Code, received)
+	}
+}
+
+func TestNewClientWithSignature(t *testing.T) {
+	if _, err := NewClientWithSignature("abcd1234", "", SignatureSHA256); err == nil {
+		t.Error("expected an error for an empty signature secret")
+	}
+	if _, err := NewClientWithSignature("abcd1234", "secret", SignatureMethod("crc32")); err == nil {
+		t.Error("expected an error for an unknown signature method")
+	}
+
+	client, err := NewClientWithSignature("abcd1234", "secret", SignatureSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Auth.(*SignatureAuth).Clock = &stoppedClock{now: time.Unix(1500000000, 0)}
+
+	var form url.Values
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		form = req.PostForm
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	if _, err := client.SMS().Send(&SMSMessage{From: "12345", To: "447700900001", Type: Text, Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := form["api_secret"]; ok {
+		t.Error("signed request carries the API secret")
+	}
+	if got := form.Get("timestamp"); got != "1500000000" {
+		t.Errorf("got timestamp %q", got)
+	}
+	want, _ := Sign(form, "secret", SignatureSHA256)
+	if got := form.Get("sig"); got != want {
+		t.Errorf("got signature %q, want %q", got, want)
+	}
+}
@@ -1,11 +1,13 @@
 package nexmo
 
 import (
-	"bytes"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
-	"io/ioutil"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // SMS represents the SMS API functions for sending text messages.
@@ -28,6 +30,9 @@ const (
 //	- Standard
 //	- SIMData
 //	- Forward
+//
+// The zero value means no class is set, in which case Nexmo and the handset
+// treat the message as Standard.
 type MessageClass int
 
 // SMS message classes.
@@ -35,7 +40,7 @@ const (
 	// This type of SMS message is displayed on the mobile screen without being
 	// saved in the message store or on the SIM card; unless explicitly saved
 	// by the mobile user.
-	Flash MessageClass = iota
+	Flash MessageClass = iota + 1
 
 	// This message is to be stored in the device memory or the SIM card
 	// (depending on memory availability).
@@ -65,28 +70,94 @@ func (m MessageClass) String() string {
 	return messageClassMap[m]
 }
 
-// MarshalJSON implements the json.Marshaller interface
-func (m *SMSMessage) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		APIKey    string `json:"api_key"`
-		APISecret string `json:"api_secret"`
-		SMSMessage
-	}{
-		APIKey:     m.apiKey,
-		APISecret:  m.apiSecret,
-		SMSMessage: *m,
-	})
+// MarshalJSON implements the json.Marshaler interface. Classes are sent to
+// Nexmo as the numbers 0 (Flash) to 3 (Forward).
+func (m MessageClass) MarshalJSON() ([]byte, error) {
+	if _, ok := messageClassMap[m]; !ok {
+		return nil, fmt.Errorf("invalid message class %d", int(m))
+	}
+	return []byte(strconv.Itoa(int(m) - 1)), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (m *MessageClass) UnmarshalJSON(b []byte) error {
+	n, err := strconv.Atoi(strings.Trim(string(b), `"`))
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > 3 {
+		return fmt.Errorf("invalid message class %d", n)
+	}
+	*m = MessageClass(n + 1)
+	return nil
+}
+
+// HexBytes is binary data, such as the body and UDH of a binary message. It
+// is sent to Nexmo hex encoded, as the API expects.
+type HexBytes []byte
+
+// MarshalJSON implements the json.Marshaler interface.
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(b))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// StatusReport tells Nexmo whether to send a delivery receipt for a message.
+// The zero value leaves it to the settings of the account.
+type StatusReport int
+
+// Status report settings.
+const (
+	StatusReportOn StatusReport = iota + 1
+	StatusReportOff
+)
+
+// MarshalJSON implements the json.Marshaler interface. Settings are sent to
+// Nexmo as booleans.
+func (s StatusReport) MarshalJSON() ([]byte, error) {
+	switch s {
+	case StatusReportOn:
+		return []byte("true"), nil
+	case StatusReportOff:
+		return []byte("false"), nil
+	}
+	return nil, fmt.Errorf("invalid status report setting %d", int(s))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Booleans are
+// accepted, as well as the numbers 0 and 1, quoted or not.
+func (s *StatusReport) UnmarshalJSON(b []byte) error {
+	switch strings.Trim(string(b), `"`) {
+	case "true", "1":
+		*s = StatusReportOn
+	case "false", "0":
+		*s = StatusReportOff
+	default:
+		return fmt.Errorf("invalid status report setting %s", b)
+	}
+	return nil
 }
 
 // SMSMessage defines a single SMS message.
 type SMSMessage struct {
-	apiKey               string
-	apiSecret            string
 	From                 string       `json:"from"`
 	To                   string       `json:"to"`
 	Type                 string       `json:"type"`
 	Text                 string       `json:"text,omitempty"`              // Optional.
-	StatusReportRequired int          `json:"status-report-req,omitempty"` // Optional.
+	StatusReportRequired StatusReport `json:"status-report-req,omitempty"` // Optional.
 	ClientReference      string       `json:"client-ref,omitempty"`        // Optional.
 	NetworkCode          string       `json:"network-code,omitempty"`      // Optional.
 	VCard                string       `json:"vcrad,omitempty"`             // Optional.
@@ -94,25 +165,79 @@ type SMSMessage struct {
 	TTL                  int          `json:"ttl,omitempty"`               // Optional.
 	Class                MessageClass `json:"message-class,omitempty"`     // Optional.
 	Callback             string       `json:"callback,omitempty"`          // Optional.
-	Body                 []byte       `json:"body,omitempty"`              // Required for Binary message.
-	UDH                  []byte       `json:"udh,omitempty"`               // Required for Binary message.
+	Body                 HexBytes     `json:"body,omitempty"`              // Required for Binary message.
+	UDH                  HexBytes     `json:"udh,omitempty"`               // Required for Binary message.
 
 	// The following is only for type=wappush
 
 	Title    string `json:"title,omitempty"`    // Title shown to recipient
 	URL      string `json:"url,omitempty"`      // WAP Push URL
-	Validity int    `json:"validity,omitempty"` // Duration WAP Push is available in milliseconds
+	Validity int    `json:"validity,omitempty"` // Duration WAP Push is available in milliseconds, see SetValidity
+}
+
+// Bounds and default of the validity of WAP push messages.
+const (
+	MinWAPPushValidity     = 20 * time.Second
+	MaxWAPPushValidity     = 7 * 24 * time.Hour
+	DefaultWAPPushValidity = 48 * time.Hour // Used by Nexmo if Validity is 0.
+)
+
+// SetValidity sets how long a WAP push message is available, in the
+// milliseconds Nexmo expects.
+func (m *SMSMessage) SetValidity(d time.Duration) {
+	m.Validity = int(d / time.Millisecond)
+}
+
+// ValidityDuration returns how long a WAP push message is available, or
+// DefaultWAPPushValidity if Validity is not set.
+func (m *SMSMessage) ValidityDuration() time.Duration {
+	if m.Validity == 0 {
+		return DefaultWAPPushValidity
+	}
+	return time.Duration(m.Validity) * time.Millisecond
 }
 
 // A ResponseCode will be returned
 // whenever an SMSMessage is sent.
 type ResponseCode int
 
-// String implements the fmt.Stringer interface
+// String implements the fmt.Stringer interface. The description can be
+// replaced with SetDescriptions.
 func (c ResponseCode) String() string {
+	if str, ok := currentDescriptions().ResponseCodes[c]; ok {
+		return str
+	}
 	return responseCodeMap[c]
 }
 
+// ResponseUnrecognized is decoded from status values which are not numbers.
+const ResponseUnrecognized ResponseCode = -1
+
+// MarshalJSON implements the json.Marshaler interface. Codes are sent as
+// strings, like Nexmo does.
+func (c ResponseCode) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.Itoa(int(c)) + `"`), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Nexmo sends codes
+// as strings or as numbers, and both are accepted. Codes unknown to this
+// package keep their value; strings which are not numbers are decoded as
+// ResponseUnrecognized rather than failing the whole response.
+func (c *ResponseCode) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		*c = ResponseUnrecognized
+		return nil
+	}
+	*c = ResponseCode(n)
+	return nil
+}
+
 // Possible response codes
 const (
 	ResponseSuccess ResponseCode = iota
@@ -159,7 +284,7 @@ var responseCodeMap = map[ResponseCode]string{
 
 // MessageReport is the "status report" for a single SMS sent via the Nexmo API
 type MessageReport struct {
-	Status           ResponseCode `json:"status,string"`
+	Status           ResponseCode `json:"status"`
 	MessageID        string       `json:"message-id"`
 	To               string       `json:"to"`
 	ClientReference  string       `json:"client-ref"`
@@ -169,74 +294,127 @@ type MessageReport struct {
 	ErrorText        string       `json:"error-text"`
 }
 
+// MessagePriceFloat returns the price of the message in euros.
+func (r *MessageReport) MessagePriceFloat() (float64, error) {
+	return strconv.ParseFloat(r.MessagePrice, 64)
+}
+
+// RemainingBalanceFloat returns the balance of the account, in euros, after
+// sending the message.
+func (r *MessageReport) RemainingBalanceFloat() (float64, error) {
+	return strconv.ParseFloat(r.RemainingBalance, 64)
+}
+
+// MobileNetwork returns the network the message was sent to.
+func (r *MessageReport) MobileNetwork() (MobileNetwork, error) {
+	return ParseMobileNetwork(r.Network)
+}
+
+// MobileNetwork identifies a mobile network by its mobile country code and
+// mobile network code.
+type MobileNetwork struct {
+	MCC string // Mobile country code, always three digits.
+	MNC string // Mobile network code, two or three digits.
+}
+
+// ParseMobileNetwork parses a network code as sent by Nexmo, the MCC directly
+// followed by the MNC, e.g. "23410".
+func ParseMobileNetwork(code string) (MobileNetwork, error) {
+	if len(code) < 5 || len(code) > 6 || !isDigits(code) {
+		return MobileNetwork{}, fmt.Errorf("invalid network code %q", code)
+	}
+	return MobileNetwork{MCC: code[:3], MNC: code[3:]}, nil
+}
+
+func (n MobileNetwork) String() string {
+	return n.MCC + n.MNC
+}
+
 // MessageResponse contains the response from Nexmo's API after we attempt to
 // send any kind of message.
 // It will contain one MessageReport for every 160 chars sent.
 type MessageResponse struct {
 	MessageCount int             `json:"message-count,string"`
 	Messages     []MessageReport `json:"messages"`
-}
+	ResponseMeta
 
-// Send the message using the specified SMS client.
-func (c *SMS) Send(msg *SMSMessage) (*MessageResponse, error) {
-	if len(msg.From) <= 0 {
-		return nil, errors.New("Invalid From field specified")
-	}
+	// Client reference the message was sent with, including one generated
+	// by a client created with WithAutoClientReference.
+	ClientReference string `json:"-"`
 
-	if len(msg.To) <= 0 {
-		return nil, errors.New("Invalid To field specified")
-	}
+	// Trace of the request, if the client was created with WithTracing.
+	Trace *Trace `json:"-"`
 
-	if len(msg.ClientReference) > 40 {
-		return nil, errors.New("Client reference too long")
-	}
+	// Set if the message was not sent again because it had been sent with
+	// the same client reference before, see WithDeduplication. The response
+	// is then the one of the original send.
+	Duplicate bool `json:"-"`
+
+	// Set if the message was not sent because the client was created with
+	// WithDryRun.
+	DryRun bool `json:"-"`
+}
 
-	var messageResponse *MessageResponse
+// Send the message using the specified SMS client. The options apply to this
+// call only; msg itself is left unchanged by them.
+func (c *SMS) Send(msg *SMSMessage, opts ...SendOption) (*MessageResponse, error) {
+	cfg := newSendConfig(opts)
 
-	switch msg.Type {
-	case Text:
-	case Unicode:
-		if len(msg.Text) <= 0 {
-			return nil, errors.New("Invalid message text")
-		}
-	case Binary:
-		if len(msg.UDH) == 0 || len(msg.Body) == 0 {
-			return nil, errors.New("Invalid binary message")
-		}
+	m := cfg.apply(msg)
+	if d := c.client.dedup; d != nil && m.ClientReference != "" {
+		return d.send(cfg.ctx, m, func() (*MessageResponse, error) {
+			return c.send(cfg, m)
+		})
+	}
+	return c.send(cfg, m)
+}
 
-	case WAPPush:
-		if len(msg.URL) == 0 || len(msg.Title) == 0 {
-			return nil, errors.New("Invalid WAP Push parameters")
-		}
+// send implements Send for m, the message with the options of cfg applied.
+func (c *SMS) send(cfg *sendConfig, m *SMSMessage) (*MessageResponse, error) {
+	if c.client.autoClientRef && m.ClientReference == "" {
+		m.ClientReference = newClientReference()
 	}
-	if !c.client.useOauth {
-		msg.apiKey = c.client.apiKey
-		msg.apiSecret = c.client.apiSecret
+	if cfg.router != nil {
+		cfg.router.route(cfg.ctx, m, cfg)
+	}
+	if err := c.client.filterContent(cfg.ctx, m); err != nil {
+		return nil, err
 	}
 
-	var r *http.Request
-	buf, err := json.Marshal(msg)
+	r, err := c.newRequest(m, cfg.creds)
 	if err != nil {
-		return nil, errors.New("invalid message struct - unable to convert to JSON")
+		return nil, err
+	}
+	if c.client.dryRun {
+		return dryRunResponse(m), nil
 	}
-	b := bytes.NewBuffer(buf)
-	r, _ = http.NewRequest("POST", apiRoot+"/sms/json", b)
-
-	r.Header.Add("Accept", "application/json")
-	r.Header.Add("Content-Type", "application/json")
-
-	resp, err := c.client.HTTPClient.Do(r)
 
-	if err != nil {
+	messageResponse := new(MessageResponse)
+	if err := c.client.do(cfg.context(), r, messageResponse); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	messageResponse.ClientReference = m.ClientReference
+	c.client.messageSent(messageResponse)
+	return messageResponse, nil
+}
 
-	body, _ := ioutil.ReadAll(resp.Body)
+// SendFlash sends text from one number to another as a flash message, which
+// is displayed immediately and not stored by the phone. The message is sent
+// as Unicode if text does not fit the GSM-7 alphabet.
+func (c *SMS) SendFlash(from, to, text string, opts ...SendOption) (*MessageResponse, error) {
+	msg := &SMSMessage{From: from, To: to, Type: Text, Text: text, Class: Flash}
+	if CountSegments(text).Encoding == UCS2 {
+		msg.Type = Unicode
+	}
+	return c.Send(msg, opts...)
+}
 
-	err = json.Unmarshal(body, &messageResponse)
-	if err != nil {
+// newRequest validates msg and creates the request sending it, with creds
+// instead of the credentials of the client if not nil.
+func (c *SMS) newRequest(msg *SMSMessage, creds *Credentials) (*http.Request, error) {
+	if err := msg.Validate(); err != nil {
 		return nil, err
 	}
-	return messageResponse, nil
+
+	return c.client.newRequestAs(EndpointSMS, msg, creds)
 }
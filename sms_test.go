@@ -1,43 +1,32 @@
-// +build messages
-
-// Tests in this file will only be run if the build tag messages is set:
-// `go test -tag messages`
-// Test with only sending one message using:
-// `go test -test.run SendText -tags messages`
 package nexmo
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
-// TODO(inhies): Only create a Client once in an init() function.
-
 func TestSendTextMessage(t *testing.T) {
-	// TODO(inhies): Create an internal rate limiting system and do away with
-	// this hacky 1 second delay.
-	time.Sleep(1 * time.Second) // Sleep 1 second due to API limitation
-	if TEST_PHONE_NUMBER == "" {
-		t.Fatal("No test phone number specified. Please set NEXMO_NUM")
-	}
-	nexmo, err := NewClientFromAPI(API_KEY, API_SECRET)
-	if err != nil {
-		t.Error("Failed to create Client with error:", err)
-	}
+	nexmo := liveClient(t)
 
 	message := &SMSMessage{
-		From:            TEST_FROM,
-		To:              TEST_PHONE_NUMBER,
+		From:            testFrom,
+		To:              liveRecipient(t),
 		Type:            Text,
 		Text:            "Gonexmo test SMS message, sent at " + time.Now().String(),
 		ClientReference: "gonexmo-test " + strconv.FormatInt(time.Now().Unix(), 10),
 		Class:           Standard,
 	}
 
-	messageResponse, err := nexmo.SMS.Send(message)
+	messageResponse, err := nexmo.SMS().Send(message)
 	if err != nil {
 		t.Error("Failed to send text message with error:", err)
 	}
@@ -46,25 +35,18 @@ func TestSendTextMessage(t *testing.T) {
 }
 
 func TestFlashMessage(t *testing.T) {
-	time.Sleep(1 * time.Second) // Sleep 1 second due to API limitation
-	if TEST_PHONE_NUMBER == "" {
-		t.Fatal("No test phone number specified. Please set NEXMO_NUM")
-	}
-	nexmo, err := NewClientFromAPI(API_KEY, API_SECRET)
-	if err != nil {
-		t.Error("Failed to create Client with error:", err)
-	}
+	nexmo := liveClient(t)
 
 	message := &SMSMessage{
-		From:            TEST_FROM,
-		To:              TEST_PHONE_NUMBER,
+		From:            testFrom,
+		To:              liveRecipient(t),
 		Type:            Text,
 		Text:            "Gonexmo test flash SMS message, sent at " + time.Now().String(),
 		ClientReference: "gonexmo-test " + strconv.FormatInt(time.Now().Unix(), 10),
 		Class:           Flash,
 	}
 
-	messageResponse, err := nexmo.SMS.Send(message)
+	messageResponse, err := nexmo.SMS().Send(message)
 	if err != nil {
 		t.Error("Failed to send flash message (class 0 SMS) with error:", err)
 	}
@@ -79,8 +61,8 @@ func TestCallbackAttributeShouldBeFilled(t *testing.T) {
 	smsMessageWithCallback := &SMSMessage{}
 	smsMessageWithoutCallback := &SMSMessage{}
 
-	errWithCallback := json.Unmarshal(smsMessageWithCallbackString.([]byte), smsMessageWithCallback)
-	errWithoutCallback := json.Unmarshal(smsMessageWithoutCallbackString.([]byte), smsMessageWithoutCallback)
+	errWithCallback := json.Unmarshal([]byte(smsMessageWithCallbackString), smsMessageWithCallback)
+	errWithoutCallback := json.Unmarshal([]byte(smsMessageWithoutCallbackString), smsMessageWithoutCallback)
 
 	if errWithCallback != nil || errWithoutCallback != nil {
 		t.Error("Failed to unmarshal Json string.")
@@ -115,13 +97,345 @@ func TestCallbackAttributeShouldBeOmited(t *testing.T) {
 		t.Error("Failed to marshal SMSMessage.")
 	}
 
-	if !strings.Contains(str(smsMessageWithCallbackByte), callback) {
+	if !strings.Contains(string(smsMessageWithCallbackByte), callback) {
 		t.Error("Callback attribute was omited.")
 	}
 
-	if strings.Contains(str(smsMessageWithoutCallbackByte), "callback") {
+	if strings.Contains(string(smsMessageWithoutCallbackByte), "callback") {
 		t.Error("Callback attribute wasn't omited.")
 	}
 
 	t.Log("Callback attribute works as it should be.")
 }
+
+func TestMessageClassJSON(t *testing.T) {
+	for _, test := range []struct {
+		class MessageClass
+		want  string
+	}{
+		{0, ``},
+		{Flash, `"message-class":0`},
+		{Standard, `"message-class":1`},
+		{Forward, `"message-class":3`},
+	} {
+		buf, err := json.Marshal(&SMSMessage{Class: test.class})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.want == "" && strings.Contains(string(buf), "message-class") {
+			t.Errorf("unset class was serialized: %s", buf)
+		}
+		if !strings.Contains(string(buf), test.want) {
+			t.Errorf("%v: got %s, want %s", test.class, buf, test.want)
+		}
+
+		var m SMSMessage
+		if err := json.Unmarshal(buf, &m); err != nil || m.Class != test.class {
+			t.Errorf("%v: round trip gave %v, %v", test.class, m.Class, err)
+		}
+	}
+
+	if _, err := json.Marshal(&SMSMessage{Class: 7}); err == nil {
+		t.Error("invalid class was serialized")
+	}
+}
+
+func TestResponseCodeJSON(t *testing.T) {
+	var resp MessageResponse
+	err := json.Unmarshal([]byte(`{"message-count":"3","messages":[
+		{"status":"0","message-id":"a"},
+		{"status":1,"message-id":"b"},
+		{"status":"banana","message-id":"c"},
+		{"status":"42","message-id":"d"}]}`), &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ResponseCode{ResponseSuccess, ResponseThrottled, ResponseUnrecognized, 42}
+	for i, code := range want {
+		if resp.Messages[i].Status != code {
+			t.Errorf("message %d: got status %d, want %d", i, resp.Messages[i].Status, code)
+		}
+	}
+
+	buf, _ := json.Marshal(resp.Messages[1])
+	if !strings.Contains(string(buf), `"status":"1"`) {
+		t.Errorf("status was not marshaled as a string: %s", buf)
+	}
+}
+
+func TestMessageReportAccessors(t *testing.T) {
+	r := &MessageReport{
+		MessagePrice:     "0.03330000",
+		RemainingBalance: "3.14159265",
+		Network:          "310410",
+	}
+
+	if price, err := r.MessagePriceFloat(); err != nil || price != 0.0333 {
+		t.Errorf("MessagePriceFloat() = %v, %v", price, err)
+	}
+	if balance, err := r.RemainingBalanceFloat(); err != nil || balance != 3.14159265 {
+		t.Errorf("RemainingBalanceFloat() = %v, %v", balance, err)
+	}
+	if n, err := r.MobileNetwork(); err != nil || n.MCC != "310" || n.MNC != "410" {
+		t.Errorf("MobileNetwork() = %#v, %v", n, err)
+	}
+
+	r.Network = ""
+	if _, err := r.MobileNetwork(); err == nil {
+		t.Error("expected an error for a missing network")
+	}
+}
+
+func TestSendOptions(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace := &httptrace.ClientTrace{}
+	var sent map[string]interface{}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if httptrace.ContextClientTrace(req.Context()) != trace {
+			t.Error("request was sent without the trace")
+		}
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	_, err = client.SMS().Send(msg,
+		WithCallback("https://example.com/dlr"),
+		WithTTL(time.Hour),
+		WithDLR(),
+		WithIdempotencyKey("order-42"),
+		WithTrace(trace),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"callback":          "https://example.com/dlr",
+		"ttl":               float64(3600000),
+		"status-report-req": true,
+		"client-ref":        "order-42",
+	}
+	for k, v := range want {
+		if sent[k] != v {
+			t.Errorf("%s = %v, want %v", k, sent[k], v)
+		}
+	}
+	if msg.Callback != "" || msg.TTL != 0 || msg.ClientReference != "" {
+		t.Errorf("options modified the message: %#v", msg)
+	}
+}
+
+func TestSendFlash(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent map[string]interface{}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	for _, tc := range []struct {
+		text, typ string
+	}{
+		{"Your code is 1234", Text},
+		{"Код 1234", Unicode},
+	} {
+		if _, err := client.SMS().SendFlash("Test", "447700900000", tc.text); err != nil {
+			t.Fatal(err)
+		}
+		if sent["type"] != tc.typ || sent["message-class"] != float64(0) || sent["text"] != tc.text {
+			t.Errorf("SendFlash(%q) sent %v", tc.text, sent)
+		}
+	}
+}
+
+func TestStatusReportJSON(t *testing.T) {
+	for _, test := range []struct {
+		report StatusReport
+		want   string
+	}{
+		{0, ``},
+		{StatusReportOn, `"status-report-req":true`},
+		{StatusReportOff, `"status-report-req":false`},
+	} {
+		buf, err := json.Marshal(&SMSMessage{StatusReportRequired: test.report})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.want == "" && strings.Contains(string(buf), "status-report-req") {
+			t.Errorf("unset status report was serialized: %s", buf)
+		}
+		if !strings.Contains(string(buf), test.want) {
+			t.Errorf("%d: got %s, want %s", test.report, buf, test.want)
+		}
+
+		var m SMSMessage
+		if err := json.Unmarshal(buf, &m); err != nil || m.StatusReportRequired != test.report {
+			t.Errorf("%d: round trip gave %d, %v", test.report, m.StatusReportRequired, err)
+		}
+	}
+
+	var m SMSMessage
+	if err := json.Unmarshal([]byte(`{"status-report-req":"1"}`), &m); err != nil || m.StatusReportRequired != StatusReportOn {
+		t.Errorf("legacy value decoded as %d, %v", m.StatusReportRequired, err)
+	}
+}
+
+func TestHexBytes(t *testing.T) {
+	buf, err := json.Marshal(SMSMessage{Type: Binary, Body: []byte{0x01, 0xab}, UDH: []byte{0x06}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"body":"01ab"`) || !strings.Contains(string(buf), `"udh":"06"`) {
+		t.Errorf("encoded as %s", buf)
+	}
+
+	var m SMSMessage
+	if err := json.Unmarshal(buf, &m); err != nil || string(m.Body) != "\x01\xab" || string(m.UDH) != "\x06" {
+		t.Errorf("decoded as %+v, %v", m, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		msg   SMSMessage
+		field string // Of the error, if any.
+	}{
+		{SMSMessage{Text: "Hello"}, ""},
+		{SMSMessage{Type: Text, Text: "Hello", Class: Flash}, ""},
+		{SMSMessage{Type: Text}, "Text"},
+		{SMSMessage{Type: Unicode, Text: "Привет", UDH: []byte{0}}, "UDH"},
+		{SMSMessage{Type: Binary, Body: []byte{1}, UDH: []byte{0}, Class: SIMData}, ""},
+		{SMSMessage{Type: Binary, Body: []byte{1}, UDH: []byte{0}, Text: "Hello"}, "Text"},
+		{SMSMessage{Type: WAPPush, URL: "https://example.com", Title: "Offer", Validity: 60000}, ""},
+		{SMSMessage{Type: WAPPush, URL: "https://example.com", Title: "Offer", Body: []byte{1}}, "Body"},
+		{SMSMessage{Type: WAPPush, URL: "https://example.com", Title: "Offer", Class: Flash}, "Class"},
+		{SMSMessage{Type: WAPPush, URL: "https://example.com"}, "URL"},
+		{SMSMessage{Type: VCard, VCard: "BEGIN:VCARD"}, ""},
+		{SMSMessage{Type: VCard}, "VCard"},
+		{SMSMessage{Type: VCal, VCal: "BEGIN:VCALENDAR", Validity: 60000}, "Validity"},
+		{SMSMessage{Type: "fax", Text: "Hello"}, "Type"},
+	} {
+		msg := tc.msg
+		msg.From, msg.To = "gonexmo", "447700900000"
+		err := msg.Validate()
+		var verr *ValidationError
+		switch {
+		case tc.field == "" && err != nil:
+			t.Errorf("Validate(%+v) = %v", tc.msg, err)
+		case tc.field != "" && (!errors.As(err, &verr) || verr.Field != tc.field):
+			t.Errorf("Validate(%+v) = %v, want an error for %s", tc.msg, err, tc.field)
+		}
+	}
+}
+
+func TestValidity(t *testing.T) {
+	msg := &SMSMessage{}
+	if d := msg.ValidityDuration(); d != DefaultWAPPushValidity {
+		t.Errorf("default validity = %v", d)
+	}
+	msg.SetValidity(time.Hour)
+	if msg.Validity != 3600000 || msg.ValidityDuration() != time.Hour {
+		t.Errorf("Validity = %d, %v", msg.Validity, msg.ValidityDuration())
+	}
+
+	m := newSendConfig([]SendOption{WithValidity(2 * time.Hour)}).apply(&SMSMessage{})
+	if m.Validity != 7200000 {
+		t.Errorf("WithValidity set Validity = %d", m.Validity)
+	}
+}
+
+func TestSendConcurrently(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	// The same message is sent from several goroutines.
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.SMS().Send(msg); err != nil {
+				t.Error(err)
+			}
+			if _, err := client.DumpRequest(msg); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if b, _ := json.Marshal(msg); strings.Contains(string(b), "s3cr3t") {
+		t.Errorf("credentials leaked into the message: %s", b)
+	}
+}
+
+func TestContentFilter(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t",
+		WithContentFilter(ContentFilterFunc(func(ctx context.Context, msg *SMSMessage) (string, error) {
+			if strings.Contains(msg.Text, "darn") {
+				return "", &ContentRejectedError{Reason: "profanity"}
+			}
+			return msg.Text, nil
+		})),
+		WithContentFilter(ContentFilterFunc(func(ctx context.Context, msg *SMSMessage) (string, error) {
+			return strings.Replace(msg.Text, "4111111111111111", "****", -1), nil
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent SMSMessage
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&sent)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Card 4111111111111111 charged"}
+	if _, err := client.SMS().Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Text != "Card **** charged" || msg.Text != "Card 4111111111111111 charged" {
+		t.Errorf("sent %q for %q", sent.Text, msg.Text)
+	}
+
+	_, err = client.SMS().Send(&SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "darn"})
+	if e, ok := err.(*ContentRejectedError); !ok || e.Reason != "profanity" {
+		t.Errorf("got error %v", err)
+	}
+	// Messages without a type are text messages, and are filtered too.
+	_, err = client.SMS().Send(&SMSMessage{From: "Test", To: "447700900000", Text: "darn"})
+	if e, ok := err.(*ContentRejectedError); !ok || e.Reason != "profanity" {
+		t.Errorf("untyped message: got error %v", err)
+	}
+}
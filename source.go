@@ -0,0 +1,67 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MessageSource supplies the messages sent by SMS.SendFromSource, e.g. from a
+// Kafka or SQS consumer. Next blocks until a message is available or ctx is
+// done, and returns io.EOF once there are no more messages. The returned ack
+// function, which may be nil, is called once the message has been sent, with
+// nil if all of its parts were accepted by Nexmo, so the message can be
+// committed or requeued.
+type MessageSource interface {
+	Next(ctx context.Context) (msg *SMSMessage, ack func(error), err error)
+}
+
+// ErrNoMessage is the error of the result reported when a MessageSource
+// returns neither a message nor an error.
+var ErrNoMessage = errors.New("nexmo: message source returned no message")
+
+// channelSource adapts a channel to a MessageSource.
+type channelSource <-chan *SMSMessage
+
+func (c channelSource) Next(ctx context.Context) (*SMSMessage, func(error), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	select {
+	case msg, ok := <-c:
+		if !ok {
+			return nil, nil, io.EOF
+		}
+		return msg, nil, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// RejectedError is passed to the ack function of a message, one part of which
+// Nexmo did not accept.
+type RejectedError struct {
+	Report MessageReport
+}
+
+func (e *RejectedError) Error() string {
+	if e.Report.ErrorText != "" {
+		return fmt.Sprintf("message rejected: %v: %s", e.Report.Status, e.Report.ErrorText)
+	}
+	return fmt.Sprintf("message rejected: %v", e.Report.Status)
+}
+
+// submitErr returns the error of res, or a RejectedError if a part of the
+// message was not accepted.
+func (res *SendResult) submitErr() error {
+	if res.Err != nil {
+		return res.Err
+	}
+	for _, report := range res.Response.Messages {
+		if report.Status != ResponseSuccess {
+			return &RejectedError{Report: report}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package nexmo
+
+// Store persists inbound traffic before it is handed to the consumer of a
+// handler, see WithStore. If the application crashes before processing a
+// message or receipt, it can be recovered from the Store on restart, giving
+// at-least-once processing. Removing entries once they have been processed is
+// up to the application. Messages API statuses are saved as the receipts
+// returned by MessageStatus.Receipt.
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	SaveMessage(m *ReceivedMessage) error
+	SaveReceipt(r *DeliveryReceipt) error
+}
+
+// save persists v in s if it is a message, a receipt or a status, or an Event
+// carrying one.
+func save(s Store, v interface{}) error {
+	switch v := v.(type) {
+	case *ReceivedMessage:
+		return s.SaveMessage(v)
+	case *DeliveryReceipt:
+		return s.SaveReceipt(v)
+	case *MessageStatus:
+		return s.SaveReceipt(v.Receipt())
+	case *Event:
+		if v.Message != nil {
+			return s.SaveMessage(v.Message)
+		}
+		if v.Receipt != nil {
+			return s.SaveReceipt(v.Receipt)
+		}
+		if v.MessageStatus != nil {
+			return s.SaveReceipt(v.MessageStatus.Receipt())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// SendResult is the outcome of sending one of the messages of a stream.
+type SendResult struct {
+	// Position of Message in the input stream, starting at 0.
+	Index   int
+	Message *SMSMessage
+
+	Response *MessageResponse
+	Err      error
+
+	// Number of times the message was submitted.
+	Attempts int
+}
+
+// StreamOption configures SMS.SendStream.
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	inFlight int
+	rate     float64
+	burst    int
+	retries  int
+	sendOpts []SendOption
+}
+
+// WithInFlight sets how many requests are in flight at most. Defaults to 10.
+func WithInFlight(n int) StreamOption {
+	return func(cfg *streamConfig) {
+		cfg.inFlight = n
+	}
+}
+
+// WithSendRate limits the stream to perSecond messages per second on
+// average, with bursts of up to burst messages. If perSecond is 0 or less,
+// the stream is not limited.
+func WithSendRate(perSecond float64, burst int) StreamOption {
+	return func(cfg *streamConfig) {
+		cfg.rate = perSecond
+		cfg.burst = burst
+	}
+}
+
+// WithStreamRetries makes the stream resubmit a message up to n times when the
+// request provably never reached Nexmo, e.g. the connection was refused, or
+// when Nexmo throttled all of its parts. Messages failing with other
+// connection errors, like timeouts, are not resubmitted, as Nexmo may have
+// accepted them. The retries back off exponentially, starting at one second.
+func WithStreamRetries(n int) StreamOption {
+	return func(cfg *streamConfig) {
+		cfg.retries = n
+	}
+}
+
+// WithStreamSendOptions applies opts to every message of the stream.
+func WithStreamSendOptions(opts ...SendOption) StreamOption {
+	return func(cfg *streamConfig) {
+		cfg.sendOpts = append(cfg.sendOpts, opts...)
+	}
+}
+
+// SendStream sends the messages received from in, keeping several requests in
+// flight, and reports the outcome of each of them on the returned channel, in
+// the order they complete. The channel is closed once in is closed and all
+// messages are sent, or once ctx is done; messages still in in are then left
+// there. The returned channel must be drained.
+func (c *SMS) SendStream(ctx context.Context, in <-chan *SMSMessage, opts ...StreamOption) <-chan SendResult {
+	return c.SendFromSource(ctx, channelSource(in), opts...)
+}
+
+// SendFromSource sends the messages returned by src like SendStream. Each
+// message is acknowledged once its outcome is known. The returned channel is
+// closed once src returns io.EOF and all messages are sent, or once ctx is
+// done; a message src returned as ctx got done is reported with the error of
+// ctx, and its ack function called with it. If src returns another error, it is reported as a result without a
+// message and the stream stops.
+func (c *SMS) SendFromSource(ctx context.Context, src MessageSource, opts ...StreamOption) <-chan SendResult {
+	cfg := &streamConfig{inFlight: 10}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.inFlight < 1 {
+		cfg.inFlight = 1
+	}
+
+	var limiter *rateLimiter
+	if cfg.rate > 0 {
+		limiter = newRateLimiter(clockOrSystem(c.client.Clock), cfg.rate, cfg.burst)
+	}
+
+	out := make(chan SendResult, cfg.inFlight)
+	go func() {
+		defer close(out)
+
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		sem := make(chan struct{}, cfg.inFlight)
+		for index := 0; ; index++ {
+			// Wait for a free slot first, so the source is not read ahead.
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+			msg, ack, err := src.Next(ctx)
+			if msg != nil && ctx.Err() != nil {
+				// The message was dequeued as ctx got done: report it
+				// rather than losing it.
+				if ack != nil {
+					ack(ctx.Err())
+				}
+				out <- SendResult{Index: index, Message: msg, Err: ctx.Err()}
+				return
+			}
+			if err == io.EOF || ctx.Err() != nil {
+				return
+			}
+			if err == nil && msg == nil {
+				err = ErrNoMessage
+				if ack != nil {
+					ack(err)
+				}
+			}
+			if err != nil {
+				out <- SendResult{Index: index, Err: err}
+				return
+			}
+
+			wg.Add(1)
+			go func(index int, msg *SMSMessage) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				res := c.sendWithRetries(ctx, cfg, limiter, index, msg)
+				if ack != nil {
+					ack(res.submitErr())
+				}
+				out <- res
+			}(index, msg)
+		}
+	}()
+	return out
+}
+
+// sendWithRetries sends msg for SendStream.
+func (c *SMS) sendWithRetries(ctx context.Context, cfg *streamConfig, limiter *rateLimiter, index int, msg *SMSMessage) SendResult {
+	opts := append(append([]SendOption(nil), cfg.sendOpts...), WithContext(ctx))
+
+	res := SendResult{Index: index, Message: msg}
+	for {
+		if limiter != nil {
+			if err := limiter.waitContext(ctx); err != nil {
+				res.Err = err
+				return res
+			}
+		}
+
+		res.Attempts++
+		res.Response, res.Err = c.Send(msg, opts...)
+		if res.Attempts > cfg.retries || !isRetryable(res.Response, res.Err) {
+			return res
+		}
+
+		select {
+		case <-clockOrSystem(c.client.Clock).After(time.Second << uint(res.Attempts-1)):
+		case <-ctx.Done():
+			return res
+		}
+	}
+}
+
+// isRetryable returns true if a message for which Send returned resp and err
+// can safely be submitted again.
+func isRetryable(resp *MessageResponse, err error) bool {
+	if err != nil {
+		return neverSent(err)
+	}
+
+	if len(resp.Messages) == 0 {
+		return false
+	}
+	for _, report := range resp.Messages {
+		if report.Status != ResponseThrottled {
+			return false
+		}
+	}
+	return true
+}
+
+// neverSent returns true if err proves that the request failing with it never
+// reached Nexmo, e.g. because the connection was refused. Other connection
+// errors, like timeouts, may occur after Nexmo accepted the message, so
+// sending it again could deliver it twice.
+func neverSent(err error) bool {
+	e, ok := err.(*SendConnectionError)
+	if !ok || e.StatusCode != 0 {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(e.Err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(e.Err, &dnsErr)
+}
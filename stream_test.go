@@ -0,0 +1,316 @@
+package nexmo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestSendStream(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &instantClock{}
+	client.Clock = clock
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	throttled := make(map[string]bool)
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		// The first attempt of message 3 is throttled.
+		status := "0"
+		if strings.Contains(string(b), `"text":"Message 3"`) && !throttled["3"] {
+			throttled["3"] = true
+			status = "1"
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"` + status + `"}]}`)),
+		}, nil
+	})}
+
+	in := make(chan *SMSMessage)
+	go func() {
+		for i := 0; i < 20; i++ {
+			in <- &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: fmt.Sprint("Message ", i)}
+		}
+		close(in)
+	}()
+
+	seen := make(map[int]bool)
+	for res := range client.SMS().SendStream(context.Background(), in, WithInFlight(4), WithStreamRetries(1)) {
+		if res.Err != nil {
+			t.Errorf("message %d: %v", res.Index, res.Err)
+			continue
+		}
+		if res.Message.Text != fmt.Sprint("Message ", res.Index) {
+			t.Errorf("result %d is for %q", res.Index, res.Message.Text)
+		}
+		if res.Response.Messages[0].Status != ResponseSuccess {
+			t.Errorf("message %d: got status %v", res.Index, res.Response.Messages[0].Status)
+		}
+		want := 1
+		if res.Index == 3 {
+			want = 2
+		}
+		if res.Attempts != want {
+			t.Errorf("message %d was submitted %d times", res.Index, res.Attempts)
+		}
+		seen[res.Index] = true
+	}
+
+	if len(seen) != 20 {
+		t.Errorf("got %d results", len(seen))
+	}
+	if maxInFlight > 4 {
+		t.Errorf("%d requests were in flight", maxInFlight)
+	}
+}
+
+// failingBody fails after returning part of a response.
+type failingBody struct{ io.Reader }
+
+func (failingBody) Close() error { return nil }
+
+func TestSendStreamRetriesUnsentOnly(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Clock = &instantClock{}
+
+	attempts := make(map[string]int)
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var msg SMSMessage
+		json.NewDecoder(req.Body).Decode(&msg)
+		attempts[msg.Text]++
+		if msg.Text == "refused" && attempts[msg.Text] == 1 {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		}
+		// Nexmo accepted the message, but the connection broke while
+		// reading its answer.
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: failingBody{io.MultiReader(strings.NewReader(`{"message-count":"1",`),
+				iotest.ErrReader(io.ErrUnexpectedEOF))},
+		}, nil
+	})}
+
+	in := make(chan *SMSMessage, 2)
+	in <- &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "refused"}
+	in <- &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "read failure"}
+	close(in)
+	for range client.SMS().SendStream(context.Background(), in, WithInFlight(1), WithStreamRetries(2)) {
+	}
+
+	// The refused message is resent, then fails like the other one, which is
+	// never resent.
+	if attempts["refused"] != 2 || attempts["read failure"] != 1 {
+		t.Errorf("got attempts %v", attempts)
+	}
+}
+
+func TestSenderClose(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	var results []SendResult
+	sender := client.SMS().NewSender(func(res SendResult) { results = append(results, res) }, 10, WithInFlight(2))
+	for i := 0; i < 5; i++ {
+		if err := sender.Send(&SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: fmt.Sprint("Message ", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unsent, err := sender.Close(context.Background())
+	if err != nil || len(unsent) != 0 {
+		t.Fatalf("got unsent messages %v, error %v", unsent, err)
+	}
+	if len(results) != 5 {
+		t.Errorf("got %d results, want 5", len(results))
+	}
+	if err := sender.Send(&SMSMessage{}); err != ErrSenderClosed {
+		t.Errorf("Send after Close returned %v", err)
+	}
+
+	// Requests still in flight once the deadline passes are canceled, and
+	// queued messages returned.
+	client.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	results = nil
+	sender = client.SMS().NewSender(func(res SendResult) { results = append(results, res) }, 10, WithInFlight(1))
+	for i := 0; i < 5; i++ {
+		sender.Send(&SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: fmt.Sprint("Message ", i)})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	unsent, err = sender.Close(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("got error %v", err)
+	}
+	if len(unsent) == 0 || len(unsent)+len(results) != 5 {
+		t.Errorf("got %d unsent messages and %d results", len(unsent), len(results))
+	}
+	for _, res := range results {
+		if res.Err == nil {
+			t.Errorf("message %d was sent", res.Index)
+		}
+	}
+}
+
+// sliceSource is a MessageSource recording the acknowledgements of its
+// messages.
+type sliceSource struct {
+	mu   sync.Mutex
+	msgs []*SMSMessage
+	acks map[string]error
+}
+
+func (s *sliceSource) Next(ctx context.Context) (*SMSMessage, func(error), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.msgs) == 0 {
+		return nil, nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, func(err error) {
+		s.mu.Lock()
+		s.acks[msg.Text] = err
+		s.mu.Unlock()
+	}, nil
+}
+
+func TestSendFromSource(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+		status := "0"
+		if strings.Contains(string(b), `"text":"Message 2"`) {
+			status = "6"
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"` + status + `"}]}`)),
+		}, nil
+	})}
+
+	src := &sliceSource{acks: make(map[string]error)}
+	for i := 0; i < 5; i++ {
+		src.msgs = append(src.msgs, &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: fmt.Sprint("Message ", i)})
+	}
+
+	n := 0
+	for range client.SMS().SendFromSource(context.Background(), src, WithInFlight(2)) {
+		n++
+	}
+	if n != 5 || len(src.acks) != 5 {
+		t.Fatalf("got %d results and %d acknowledgements", n, len(src.acks))
+	}
+	for text, err := range src.acks {
+		if text == "Message 2" {
+			if e, ok := err.(*RejectedError); !ok || e.Report.Status != ResponseInvalidMessage {
+				t.Errorf("%s acknowledged with %v", text, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s acknowledged with %v", text, err)
+		}
+	}
+}
+
+// nilSource returns no message and no error.
+type nilSource struct{ acked error }
+
+func (s *nilSource) Next(ctx context.Context) (*SMSMessage, func(error), error) {
+	return nil, func(err error) { s.acked = err }, nil
+}
+
+func TestSendFromSourceNilMessage(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &nilSource{}
+	var results []SendResult
+	for res := range client.SMS().SendFromSource(context.Background(), src) {
+		results = append(results, res)
+	}
+	if len(results) != 1 || results[0].Err != ErrNoMessage || src.acked != ErrNoMessage {
+		t.Errorf("got results %+v, acknowledged with %v", results, src.acked)
+	}
+}
+
+// cancelingSource cancels the stream as it returns its message.
+type cancelingSource struct {
+	cancel context.CancelFunc
+	acked  error
+}
+
+func (s *cancelingSource) Next(ctx context.Context) (*SMSMessage, func(error), error) {
+	s.cancel()
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	return msg, func(err error) { s.acked = err }, nil
+}
+
+func TestSendFromSourceCanceled(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Error("message sent after cancellation")
+		return nil, req.Context().Err()
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &cancelingSource{cancel: cancel}
+	var results []SendResult
+	for res := range client.SMS().SendFromSource(ctx, src) {
+		results = append(results, res)
+	}
+	if len(results) != 1 || results[0].Message == nil || results[0].Err != context.Canceled {
+		t.Fatalf("got results %+v", results)
+	}
+	if src.acked != context.Canceled {
+		t.Errorf("message acknowledged with %v", src.acked)
+	}
+}
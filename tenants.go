@@ -0,0 +1,161 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrUnknownTenant can be returned by a TenantResolver for tenants it does
+// not know.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// TenantConfig describes the Nexmo account, or subaccount, a tenant sends
+// with.
+type TenantConfig struct {
+	Credentials Credentials
+
+	// Messages per second the tenant may send on average, with bursts of up
+	// to Burst messages. Unlimited if zero.
+	Rate  float64
+	Burst int
+}
+
+// TenantResolver returns the configuration of a tenant, e.g. from a
+// database.
+type TenantResolver func(tenantID string) (TenantConfig, error)
+
+// ClientManager holds a Client per tenant of a platform sending on behalf of
+// several Nexmo accounts. Clients are created when a tenant first sends, and
+// share the HTTP client, so connections to Nexmo are pooled across tenants.
+// A ClientManager is safe for concurrent use.
+type ClientManager struct {
+	Resolve TenantResolver
+
+	// Shared by all clients. Defaults to DefaultHTTPClient.
+	HTTPClient *http.Client
+
+	// Applied to every client created.
+	Options []ClientOption
+
+	// If set, called once per tenant to create the MetricsCollector of its
+	// client, e.g. one labelling the measurements with the tenant ID.
+	Metrics func(tenantID string) MetricsCollector
+
+	// Defaults to SystemClock.
+	Clock Clock
+
+	mu      sync.Mutex
+	tenants map[string]*tenant
+}
+
+type tenant struct {
+	ready chan struct{} // Closed once the fields below are set.
+
+	client  *Client
+	limiter *rateLimiter
+	err     error
+}
+
+// Client returns the client of a tenant, creating it if needed.
+func (m *ClientManager) Client(tenantID string) (*Client, error) {
+	t, err := m.tenant(context.Background(), tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return t.client, nil
+}
+
+// Send sends msg with the client of a tenant, once the rate limit of the
+// tenant allows.
+func (m *ClientManager) Send(ctx context.Context, tenantID string, msg *SMSMessage, opts ...SendOption) (*MessageResponse, error) {
+	t, err := m.tenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if t.limiter != nil {
+		if err := t.limiter.waitContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return t.client.SMS().Send(msg, append([]SendOption{WithContext(ctx)}, opts...)...)
+}
+
+// Remove forgets the client of a tenant, so the next send resolves its
+// configuration again, e.g. after its credentials were rotated.
+func (m *ClientManager) Remove(tenantID string) {
+	m.mu.Lock()
+	delete(m.tenants, tenantID)
+	m.mu.Unlock()
+}
+
+// tenant returns the tenant with the given ID. The first call for a tenant
+// resolves it without holding m.mu, so a slow resolver only holds up the
+// sends of that tenant; concurrent calls for the same tenant wait for it.
+func (m *ClientManager) tenant(ctx context.Context, tenantID string) (*tenant, error) {
+	m.mu.Lock()
+	t, ok := m.tenants[tenantID]
+	if !ok {
+		t = &tenant{ready: make(chan struct{})}
+		if m.tenants == nil {
+			m.tenants = make(map[string]*tenant)
+		}
+		m.tenants[tenantID] = t
+	}
+	m.mu.Unlock()
+
+	if ok {
+		select {
+		case <-t.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if t.err != nil {
+			return nil, t.err
+		}
+		return t, nil
+	}
+
+	t.client, t.limiter, t.err = m.newClient(tenantID)
+	if t.err != nil {
+		// Not kept, so the next call resolves the tenant again.
+		m.mu.Lock()
+		if m.tenants[tenantID] == t {
+			delete(m.tenants, tenantID)
+		}
+		m.mu.Unlock()
+	}
+	close(t.ready)
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t, nil
+}
+
+// newClient resolves a tenant and creates its client and rate limiter.
+func (m *ClientManager) newClient(tenantID string) (*Client, *rateLimiter, error) {
+	cfg, err := m.Resolve(tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := NewClient(cfg.Credentials.APIKey, cfg.Credentials.APISecret, m.Options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.HTTPClient != nil {
+		client.HTTPClient = m.HTTPClient
+	}
+	if m.Clock != nil {
+		client.Clock = m.Clock
+	}
+	if m.Metrics != nil {
+		client.Metrics = m.Metrics(tenantID)
+	}
+
+	var limiter *rateLimiter
+	if cfg.Rate > 0 {
+		limiter = newRateLimiter(clockOrSystem(m.Clock), cfg.Rate, cfg.Burst)
+	}
+	return client, limiter, nil
+}
@@ -0,0 +1,96 @@
+package nexmo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientManager(t *testing.T) {
+	var keys []string
+	hc := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var sent map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, sent["api_key"].(string))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	resolved := 0
+	m := &ClientManager{
+		HTTPClient: hc,
+		Resolve: func(id string) (TenantConfig, error) {
+			resolved++
+			if id == "acme" || id == "globex" {
+				return TenantConfig{Credentials: Credentials{APIKey: id + "-key", APISecret: "secret"}}, nil
+			}
+			return TenantConfig{}, ErrUnknownTenant
+		},
+	}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	for _, id := range []string{"acme", "globex", "acme"} {
+		if _, err := m.Send(context.Background(), id, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(keys, " ") != "acme-key globex-key acme-key" || resolved != 2 {
+		t.Errorf("sent with keys %q after %d resolutions", keys, resolved)
+	}
+
+	a, _ := m.Client("acme")
+	g, _ := m.Client("globex")
+	if a == g || a.HTTPClient != hc || g.HTTPClient != hc {
+		t.Error("tenants do not have their own clients sharing the HTTP client")
+	}
+	m.Remove("acme")
+	if c, _ := m.Client("acme"); c == a || resolved != 3 {
+		t.Error("removed tenant was not resolved again")
+	}
+
+	if _, err := m.Send(context.Background(), "initech", msg); err != ErrUnknownTenant {
+		t.Errorf("got error %v for an unknown tenant", err)
+	}
+}
+
+func TestClientManagerSlowResolver(t *testing.T) {
+	release := make(chan struct{})
+	var resolved int32
+	m := &ClientManager{
+		Resolve: func(id string) (TenantConfig, error) {
+			atomic.AddInt32(&resolved, 1)
+			if id == "slow" {
+				<-release
+			}
+			return TenantConfig{Credentials: Credentials{APIKey: id + "-key", APISecret: "secret"}}, nil
+		},
+	}
+
+	clients := make(chan *Client, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			c, _ := m.Client("slow")
+			clients <- c
+		}()
+	}
+
+	// Other tenants are not held up by the slow one.
+	if _, err := m.Client("fast"); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if a, b := <-clients, <-clients; a == nil || a != b {
+		t.Error("concurrent callers did not get the same client")
+	}
+	if n := atomic.LoadInt32(&resolved); n != 2 {
+		t.Errorf("resolved %d times", n)
+	}
+}
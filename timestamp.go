@@ -0,0 +1,121 @@
+package nexmo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestampParser converts the timestamps found in Nexmo callbacks into
+// time.Time values. Nexmo has used a number of formats over time, so several
+// layouts are tried in order.
+//
+// It can be used on Nexmo payloads received outside of the handlers in this
+// package as well. Besides trying its layouts, it copes with the following
+// quirks:
+//   - surrounding white space is ignored;
+//   - a '+' between date and time, left over from double URL decoding, is
+//     read as a space;
+//   - message timestamps made of digits only are read as Unix seconds.
+type TimestampParser struct {
+	// Layouts tried for message-timestamp fields.
+	Layouts []string
+
+	// Layouts tried for the SCTS (service center timestamp) of delivery
+	// receipts.
+	SCTSLayouts []string
+
+	// Location of timestamps without time zone information. Defaults to UTC.
+	Location *time.Location
+}
+
+// DefaultTimestampParser is used by the Parse* functions and by handlers
+// which have not been given a TimestampParser of their own.
+var DefaultTimestampParser = &TimestampParser{
+	Layouts: []string{
+		"2006-01-02 15:04:05",
+		time.RFC3339Nano,
+	},
+	SCTSLayouts: []string{
+		"0601021504",
+		"060102150405",
+	},
+}
+
+// ParseTimestamp parses a message-timestamp field using
+// DefaultTimestampParser.
+func ParseTimestamp(s string) (time.Time, error) {
+	return DefaultTimestampParser.ParseTimestamp(s)
+}
+
+// ParseSCTS parses the scts field of a delivery receipt using
+// DefaultTimestampParser.
+func ParseSCTS(s string) (time.Time, error) {
+	return DefaultTimestampParser.ParseSCTS(s)
+}
+
+// WithLayouts returns a copy of p which also tries the given message-timestamp
+// layouts, after those of p.
+func (p *TimestampParser) WithLayouts(layouts ...string) *TimestampParser {
+	c := *p
+	c.Layouts = append(append([]string(nil), p.Layouts...), layouts...)
+	return &c
+}
+
+// WithSCTSLayouts returns a copy of p which also tries the given SCTS layouts,
+// after those of p.
+func (p *TimestampParser) WithSCTSLayouts(layouts ...string) *TimestampParser {
+	c := *p
+	c.SCTSLayouts = append(append([]string(nil), p.SCTSLayouts...), layouts...)
+	return &c
+}
+
+// ParseTimestamp parses a message-timestamp field.
+func (p *TimestampParser) ParseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	// "2006-01-02+15:04:05" when a form value was decoded twice.
+	if len(s) > 10 && s[10] == '+' {
+		s = s[:10] + " " + s[11:]
+	}
+
+	t, err := p.parse(s, p.Layouts)
+	if err != nil && isDigits(s) {
+		if secs, perr := strconv.ParseInt(s, 10, 64); perr == nil {
+			return time.Unix(secs, 0).UTC(), nil
+		}
+	}
+	return t, err
+}
+
+// ParseSCTS parses the scts field of a delivery receipt.
+func (p *TimestampParser) ParseSCTS(s string) (time.Time, error) {
+	return p.parse(strings.TrimSpace(s), p.SCTSLayouts)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *TimestampParser) parse(s string, layouts []string) (time.Time, error) {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
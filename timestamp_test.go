@@ -0,0 +1,75 @@
+package nexmo
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTimestampParser(t *testing.T) {
+	want := time.Date(2018, 8, 6, 12, 0, 5, 0, time.UTC)
+
+	for _, s := range []string{"2018-08-06 12:00:05", "2018-08-06T12:00:05Z", "2018-08-06T14:00:05+02:00"} {
+		got, err := DefaultTimestampParser.ParseTimestamp(s)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	got, err := DefaultTimestampParser.ParseSCTS("180806120005")
+	if err != nil || !got.Equal(want) {
+		t.Errorf("ParseSCTS = %v, %v, want %v", got, err, want)
+	}
+
+	if _, err := DefaultTimestampParser.ParseTimestamp("06/08/2018"); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+}
+
+func TestTimestampQuirks(t *testing.T) {
+	want := time.Date(2018, 8, 6, 12, 0, 5, 0, time.UTC)
+
+	for _, s := range []string{" 2018-08-06 12:00:05\n", "2018-08-06+12:00:05", "1533556805"} {
+		got, err := ParseTimestamp(s)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	p := DefaultTimestampParser.WithLayouts("02/01/2006 15:04:05")
+	if got, err := p.ParseTimestamp("06/08/2018 12:00:05"); err != nil || !got.Equal(want) {
+		t.Errorf("extended parser = %v, %v, want %v", got, err, want)
+	}
+	if len(DefaultTimestampParser.Layouts) != 2 {
+		t.Error("WithLayouts modified the original parser")
+	}
+}
+
+func TestHandlerTimestampParser(t *testing.T) {
+	helsinki := time.FixedZone("EEST", 3*60*60)
+	tp := &TimestampParser{
+		Layouts:  []string{"02.01.2006 15:04"},
+		Location: helsinki,
+	}
+
+	values := url.Values{}
+	for key, value := range testInboundValues {
+		values[key] = value
+	}
+	values.Set("message-timestamp", "06.08.2018 15:00")
+
+	out := make(chan *ReceivedMessage, 1)
+	h := NewMessageHandler(out, false, WithTimestampParser(tp))
+
+	w := httptest.NewRecorder()
+	h(w, newFormRequest("POST", values))
+	if w.Code != 200 {
+		t.Fatalf("got status %d, want 200", w.Code)
+	}
+
+	want := time.Date(2018, 8, 6, 12, 0, 0, 0, time.UTC)
+	if m := <-out; !m.Timestamp.Equal(want) {
+		t.Errorf("got timestamp %v, want %v", m.Timestamp, want)
+	}
+}
@@ -0,0 +1,263 @@
+package nexmo
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Trace records the phases of an HTTP request sent to Nexmo. Phases which did
+// not take place, e.g. DNS resolution and connecting when a connection was
+// reused, have zero timestamps. If a request was retried, the trace is the
+// one of the last attempt.
+type Trace struct {
+	Start    time.Time
+	Deadline time.Time // Of the request context, if it had one.
+
+	DNSStart, DNSDone         time.Time
+	ConnectStart, ConnectDone time.Time
+	TLSStart, TLSDone         time.Time
+	WroteRequest              time.Time
+	FirstByte                 time.Time
+	End                       time.Time
+
+	ReusedConn bool
+	RemoteAddr string
+
+	// Error of the phase which failed, if any.
+	Err error
+
+	// Set if the context of the request was canceled, or its deadline
+	// exceeded, before the request was done.
+	Canceled bool
+}
+
+// DNS returns how long resolving the host name took.
+func (t *Trace) DNS() time.Duration { return since(t.DNSStart, t.DNSDone) }
+
+// Connect returns how long establishing the TCP connection took.
+func (t *Trace) Connect() time.Duration { return since(t.ConnectStart, t.ConnectDone) }
+
+// TLS returns how long the TLS handshake took.
+func (t *Trace) TLS() time.Duration { return since(t.TLSStart, t.TLSDone) }
+
+// TTFB returns the time from the start of the request to the first byte of
+// the response.
+func (t *Trace) TTFB() time.Duration { return since(t.Start, t.FirstByte) }
+
+// Total returns how long the request took.
+func (t *Trace) Total() time.Duration { return since(t.Start, t.End) }
+
+// TracePhase is a phase of an HTTP request.
+type TracePhase string
+
+// Trace phases, in the order they take place.
+const (
+	PhaseDNS     TracePhase = "dns"     // Resolving the host name.
+	PhaseConnect TracePhase = "connect" // Establishing the TCP connection, or waiting for one.
+	PhaseTLS     TracePhase = "tls"     // The TLS handshake.
+	PhaseWrite   TracePhase = "write"   // Writing the request.
+	PhaseWait    TracePhase = "wait"    // Waiting for the response.
+	PhaseRead    TracePhase = "read"    // Reading the response.
+)
+
+// Phase returns the phase the request was in when it ended. For requests
+// which could not be sent, it tells e.g. DNS failures from TLS failures.
+func (t *Trace) Phase() TracePhase {
+	switch {
+	case !t.FirstByte.IsZero():
+		return PhaseRead
+	case !t.WroteRequest.IsZero():
+		if t.Err != nil {
+			return PhaseWrite
+		}
+		return PhaseWait
+	case !t.TLSStart.IsZero():
+		if t.TLSDone.IsZero() || t.Err != nil {
+			return PhaseTLS
+		}
+	case !t.ConnectStart.IsZero():
+		if t.ConnectDone.IsZero() || t.Err != nil {
+			return PhaseConnect
+		}
+	case !t.DNSStart.IsZero():
+		if t.DNSDone.IsZero() || t.Err != nil {
+			return PhaseDNS
+		}
+		return PhaseConnect
+	default:
+		return PhaseConnect
+	}
+	return PhaseWrite
+}
+
+// MarshalJSON implements the json.Marshaler interface. Traces are encoded
+// with the durations of their phases in milliseconds, e.g.
+//
+//	{"start":"2017-07-14T02:40:00Z","phase":"tls","dns_ms":12.5,...,"error":"EOF"}
+func (t *Trace) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	v := struct {
+		Start      time.Time  `json:"start"`
+		Deadline   *time.Time `json:"deadline,omitempty"`
+		Phase      TracePhase `json:"phase"`
+		DNS        float64    `json:"dns_ms"`
+		Connect    float64    `json:"connect_ms"`
+		TLS        float64    `json:"tls_ms"`
+		TTFB       float64    `json:"ttfb_ms"`
+		Total      float64    `json:"total_ms"`
+		ReusedConn bool       `json:"reused_conn"`
+		RemoteAddr string     `json:"remote_addr,omitempty"`
+		Canceled   bool       `json:"canceled,omitempty"`
+		Err        string     `json:"error,omitempty"`
+	}{
+		Start:      t.Start,
+		Phase:      t.Phase(),
+		DNS:        ms(t.DNS()),
+		Connect:    ms(t.Connect()),
+		TLS:        ms(t.TLS()),
+		TTFB:       ms(t.TTFB()),
+		Total:      ms(t.Total()),
+		ReusedConn: t.ReusedConn,
+		RemoteAddr: t.RemoteAddr,
+		Canceled:   t.Canceled,
+	}
+	if !t.Deadline.IsZero() {
+		v.Deadline = &t.Deadline
+	}
+	if t.Err != nil {
+		v.Err = t.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// since returns the time from start to end, or 0 if either is not set.
+func since(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// WithTracing makes the client record a Trace of its requests. Traces of
+// requests which could not be sent are attached to the *SendConnectionError
+// returned, and if successes is true, those of successful sends to the
+// MessageResponse.
+func WithTracing(successes bool) ClientOption {
+	return func(c *Client) {
+		c.tracing = true
+		c.traceSuccesses = successes
+	}
+}
+
+// traceSetter is implemented by the responses a Trace can be attached to.
+type traceSetter interface {
+	setTrace(*Trace)
+}
+
+func (r *MessageResponse) setTrace(t *Trace) { r.Trace = t }
+
+// traceRecorder builds a Trace from the events of an httptrace.ClientTrace.
+// Events occurring after finish, e.g. a DNS lookup completing after the
+// request was canceled, are ignored.
+type traceRecorder struct {
+	clock Clock
+
+	mu       sync.Mutex
+	t        Trace
+	finished bool
+}
+
+func newTraceRecorder(ctx context.Context, clock Clock) *traceRecorder {
+	r := &traceRecorder{clock: clock}
+	r.t.Start = clock.Now()
+	r.t.Deadline, _ = ctx.Deadline()
+	return r
+}
+
+// record calls fn with the trace, unless it is finished.
+func (r *traceRecorder) record(fn func(t *Trace, now time.Time)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.finished {
+		fn(&r.t, r.clock.Now())
+	}
+}
+
+// withTrace returns ctx with hooks recording into r, in addition to those of
+// a trace already in ctx.
+func (r *traceRecorder) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// A new attempt starts.
+			r.record(func(t *Trace, now time.Time) {
+				*t = Trace{Start: t.Start, Deadline: t.Deadline}
+			})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.record(func(t *Trace, now time.Time) {
+				t.ReusedConn = info.Reused
+				if info.Conn != nil {
+					t.RemoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.record(func(t *Trace, now time.Time) { t.DNSStart = now })
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			r.record(func(t *Trace, now time.Time) {
+				t.DNSDone = now
+				t.Err = info.Err
+			})
+		},
+		ConnectStart: func(network, addr string) {
+			r.record(func(t *Trace, now time.Time) {
+				if t.ConnectStart.IsZero() {
+					t.ConnectStart = now
+				}
+			})
+		},
+		ConnectDone: func(network, addr string, err error) {
+			// With several addresses, the dial succeeds if any of them does.
+			r.record(func(t *Trace, now time.Time) {
+				if t.ConnectDone.IsZero() || t.Err != nil {
+					t.ConnectDone = now
+					t.Err = err
+				}
+			})
+		},
+		TLSHandshakeStart: func() {
+			r.record(func(t *Trace, now time.Time) { t.TLSStart = now })
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			r.record(func(t *Trace, now time.Time) {
+				t.TLSDone = now
+				t.Err = err
+			})
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			r.record(func(t *Trace, now time.Time) {
+				t.WroteRequest = now
+				t.Err = info.Err
+			})
+		},
+		GotFirstResponseByte: func() {
+			r.record(func(t *Trace, now time.Time) { t.FirstByte = now })
+		},
+	})
+}
+
+// finish ends the trace of a request sent with ctx, and returns it.
+func (r *traceRecorder) finish(ctx context.Context) *Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = true
+	r.t.End = r.clock.Now()
+	r.t.Canceled = ctx.Err() != nil
+	t := r.t
+	return &t
+}
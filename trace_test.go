@@ -0,0 +1,118 @@
+package nexmo
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracing(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithTracing(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	client.Clock = clock
+
+	tlsErr := errors.New("handshake failure")
+	failTLS := false
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		trace := httptrace.ContextClientTrace(req.Context())
+		step := func(fn func()) {
+			clock.now = clock.now.Add(10 * time.Millisecond)
+			fn()
+		}
+		step(func() { trace.GetConn("rest.nexmo.com:443") })
+		step(func() { trace.DNSStart(httptrace.DNSStartInfo{Host: "rest.nexmo.com"}) })
+		step(func() { trace.DNSDone(httptrace.DNSDoneInfo{}) })
+		step(func() { trace.ConnectStart("tcp", "203.0.113.1:443") })
+		step(func() { trace.ConnectDone("tcp", "203.0.113.1:443", nil) })
+		step(func() { trace.TLSHandshakeStart() })
+		if failTLS {
+			step(func() { trace.TLSHandshakeDone(tls.ConnectionState{}, tlsErr) })
+			return nil, tlsErr
+		}
+		step(func() { trace.TLSHandshakeDone(tls.ConnectionState{}, nil) })
+		step(func() { trace.WroteRequest(httptrace.WroteRequestInfo{}) })
+		step(func() { trace.GotFirstResponseByte() })
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message-count":"1","messages":[{"status":"0"}]}`)),
+		}, nil
+	})}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	resp, err := client.SMS().Send(msg, WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := resp.Trace
+	if tr == nil {
+		t.Fatal("no trace was attached to the response")
+	}
+	ms := 10 * time.Millisecond
+	if tr.DNS() != ms || tr.Connect() != ms || tr.TLS() != ms || tr.TTFB() != 9*ms || tr.Total() != 9*ms {
+		t.Errorf("got DNS %v, connect %v, TLS %v, TTFB %v, total %v", tr.DNS(), tr.Connect(), tr.TLS(), tr.TTFB(), tr.Total())
+	}
+	if tr.Deadline.IsZero() || tr.Canceled || tr.Err != nil {
+		t.Errorf("got trace %+v", tr)
+	}
+
+	failTLS = true
+	_, err = client.SMS().Send(msg)
+	var connErr *SendConnectionError
+	if !errors.As(err, &connErr) || connErr.Trace == nil {
+		t.Fatalf("got error %v without a trace", err)
+	}
+	if tr := connErr.Trace; tr.Err != tlsErr || tr.TLS() != ms || tr.Phase() != PhaseTLS {
+		t.Errorf("got trace %+v in phase %s", tr, tr.Phase())
+	}
+
+	buf, err := json.Marshal(connErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Endpoint string
+		Trace    map[string]interface{}
+	}
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Endpoint != "/sms/json" || decoded.Trace["phase"] != "tls" ||
+		decoded.Trace["tls_ms"] != float64(10) || decoded.Trace["error"] != "handshake failure" {
+		t.Errorf("got JSON %s", buf)
+	}
+}
+
+func TestTracePhase(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	for _, tc := range []struct {
+		trace Trace
+		want  TracePhase
+	}{
+		{Trace{}, PhaseConnect},
+		{Trace{DNSStart: at}, PhaseDNS},
+		{Trace{DNSStart: at, DNSDone: at, Err: errors.New("no such host")}, PhaseDNS},
+		{Trace{DNSStart: at, DNSDone: at}, PhaseConnect},
+		{Trace{ConnectStart: at, ConnectDone: at, Err: errors.New("connection refused")}, PhaseConnect},
+		{Trace{ConnectStart: at, ConnectDone: at}, PhaseWrite},
+		{Trace{TLSStart: at, TLSDone: at}, PhaseWrite},
+		{Trace{TLSStart: at, TLSDone: at, WroteRequest: at, Err: errors.New("broken pipe")}, PhaseWrite},
+		{Trace{WroteRequest: at}, PhaseWait},
+		{Trace{WroteRequest: at, FirstByte: at}, PhaseRead},
+	} {
+		if got := tc.trace.Phase(); got != tc.want {
+			t.Errorf("%+v: got phase %s, want %s", tc.trace, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package nexmo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DeliveryState is the state of a message tracked by a DeliveryTracker.
+type DeliveryState int
+
+// Delivery states. Delivered, Failed, Expired and Unknown are final.
+const (
+	StateSubmitted DeliveryState = iota + 1 // Accepted by Nexmo.
+	StateAccepted                           // Accepted or buffered by the carrier.
+	StateDelivered                          // Delivered to the handset.
+	StateFailed                             // Rejected by Nexmo or the carrier, or failed.
+	StateExpired                            // Not delivered before its TTL ran out.
+	StateUnknown                            // No final receipt received in time, or an "unknown" one.
+)
+
+var deliveryStateIntMap = map[DeliveryState]string{
+	StateSubmitted: "submitted",
+	StateAccepted:  "accepted",
+	StateDelivered: "delivered",
+	StateFailed:    "failed",
+	StateExpired:   "expired",
+	StateUnknown:   "unknown",
+}
+
+// String implements the fmt.Stringer interface.
+func (s DeliveryState) String() string {
+	if str, ok := deliveryStateIntMap[s]; ok {
+		return str
+	}
+	return "undefined"
+}
+
+// IsFinal returns true if the state will not change any more. A message
+// moved to StateUnknown by an "unknown" receipt rather than by the timeout is
+// an exception: Nexmo may still send a definitive receipt for it.
+func (s DeliveryState) IsFinal() bool {
+	return s >= StateDelivered
+}
+
+// StateChange reports that a message tracked by a DeliveryTracker changed
+// state.
+type StateChange struct {
+	MessageID       string
+	To              string
+	ClientReference string
+
+	Previous DeliveryState // 0 when the message starts being tracked.
+	State    DeliveryState
+
+	// The receipt causing the change, if any.
+	Receipt *DeliveryReceipt
+
+	// How many times the message was resent before, and whether it will be
+	// resent after this change, see ResendPolicy.
+	Resends int
+	Resend  bool
+}
+
+// DeliveryTracker follows sent messages, each part separately, from their
+// submission to their final state, driven by the responses to their
+// submission and their delivery receipts. Messages sent through the Messages
+// API are tracked by their UUID, driven by their statuses. Messages without a final receipt
+// are moved to StateUnknown once Timeout has passed.
+//
+// Messages are forgotten once they reach a final state.
+type DeliveryTracker struct {
+	// Called for every change of state, one at a time. It must not call the
+	// methods of the tracker.
+	OnChange func(StateChange)
+
+	// Defaults to 72 hours, the longest TTL accepted by Nexmo.
+	Timeout time.Duration
+
+	Clock Clock
+
+	// Resends messages which failed temporarily, if set. Only messages
+	// tracked with TrackMessage can be resent.
+	Resend *ResendPolicy
+
+	mu       sync.Mutex
+	messages map[string]*trackedMessage
+}
+
+type trackedMessage struct {
+	to, clientRef string
+	state         DeliveryState
+	submitted     time.Time
+	sent          *sentMessage // Shared by the parts of a message.
+}
+
+// sentMessage is a message which may be resent.
+type sentMessage struct {
+	msg     *SMSMessage // nil if unknown.
+	resends int
+	resent  bool
+}
+
+// Track starts tracking the parts of a message from the response to its
+// submission. Parts which Nexmo did not accept are reported as failed.
+func (t *DeliveryTracker) Track(resp *MessageResponse) {
+	t.track(&sentMessage{}, resp)
+}
+
+// TrackMessage is like Track, but also keeps msg so it can be resent.
+func (t *DeliveryTracker) TrackMessage(msg *SMSMessage, resp *MessageResponse) {
+	t.track(&sentMessage{msg: msg}, resp)
+}
+
+// TrackOutbound starts tracking a message sent through the Messages API from
+// the response to its submission.
+func (t *DeliveryTracker) TrackOutbound(msg *OutboundMessage, resp *OutboundMessageResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.messages == nil {
+		t.messages = make(map[string]*trackedMessage)
+	}
+	to := msg.To.Number
+	if to == "" {
+		to = msg.To.ID
+	}
+	t.messages[resp.MessageUUID] = &trackedMessage{
+		to:        to,
+		clientRef: msg.ClientReference,
+		state:     StateSubmitted,
+		submitted: clockOrSystem(t.Clock).Now(),
+		sent:      &sentMessage{},
+	}
+	t.notify(StateChange{
+		MessageID:       resp.MessageUUID,
+		To:              to,
+		ClientReference: msg.ClientReference,
+		State:           StateSubmitted,
+	})
+}
+
+func (t *DeliveryTracker) track(sent *sentMessage, resp *MessageResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.messages == nil {
+		t.messages = make(map[string]*trackedMessage)
+	}
+	now := clockOrSystem(t.Clock).Now()
+	for _, report := range resp.Messages {
+		change := StateChange{
+			MessageID:       report.MessageID,
+			To:              report.To,
+			ClientReference: report.ClientReference,
+			State:           StateSubmitted,
+			Resends:         sent.resends,
+		}
+		if report.Status != ResponseSuccess || report.MessageID == "" {
+			change.State = StateFailed
+		} else {
+			t.messages[report.MessageID] = &trackedMessage{
+				to:        report.To,
+				clientRef: report.ClientReference,
+				state:     StateSubmitted,
+				submitted: now,
+				sent:      sent,
+			}
+		}
+		t.notify(change)
+	}
+}
+
+// HandleReceipt moves the message r is for to the state it reports. It
+// returns false if the message is not tracked.
+func (t *DeliveryTracker) HandleReceipt(r *DeliveryReceipt) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m, ok := t.messages[r.MessageID]
+	if !ok {
+		return false
+	}
+
+	var state DeliveryState
+	switch r.Status {
+	case DeliveryAccepted, DeliveryBuffered:
+		state = StateAccepted
+	case DeliveryDelivered:
+		state = StateDelivered
+	case DeliveryExpired:
+		state = StateExpired
+	case DeliveryFailed, DeliveryRejected:
+		state = StateFailed
+	default:
+		state = StateUnknown
+	}
+	if state == m.state {
+		return true
+	}
+
+	t.transition(r.MessageID, m, state, r)
+	return true
+}
+
+// HandleStatus moves the message s is for, tracked with TrackOutbound, to the
+// state it reports. It returns false if the message is not tracked.
+func (t *DeliveryTracker) HandleStatus(s *MessageStatus) bool {
+	return t.HandleReceipt(s.Receipt())
+}
+
+// Expire moves the messages submitted longer than Timeout ago, without a
+// final receipt, to StateUnknown.
+func (t *DeliveryTracker) Expire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = 72 * time.Hour
+	}
+	now := clockOrSystem(t.Clock).Now()
+	for id, m := range t.messages {
+		if now.Sub(m.submitted) < timeout {
+			continue
+		}
+		if m.state == StateUnknown {
+			// Already reported by a receipt.
+			delete(t.messages, id)
+			continue
+		}
+		t.transition(id, m, StateUnknown, nil)
+	}
+}
+
+// Run calls Expire every interval until ctx is done.
+func (t *DeliveryTracker) Run(ctx context.Context, interval time.Duration) error {
+	clock := clockOrSystem(t.Clock)
+	for {
+		select {
+		case <-clock.After(interval):
+			t.Expire()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// State returns the state of the message with the given ID, if it is tracked.
+func (t *DeliveryTracker) State(messageID string) (DeliveryState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if m, ok := t.messages[messageID]; ok {
+		return m.state, true
+	}
+	return 0, false
+}
+
+// transition moves m to state. t.mu must be held.
+func (t *DeliveryTracker) transition(id string, m *trackedMessage, state DeliveryState, r *DeliveryReceipt) {
+	change := StateChange{
+		MessageID:       id,
+		To:              m.to,
+		ClientReference: m.clientRef,
+		Previous:        m.state,
+		State:           state,
+		Receipt:         r,
+		Resends:         m.sent.resends,
+	}
+	if t.Resend != nil && r != nil && !m.sent.resent && m.sent.msg != nil &&
+		t.Resend.allows(state, r.ErrorCode, m.sent.resends) {
+		m.sent.resent = true
+		change.Resend = true
+		go t.resend(m.sent)
+	}
+	m.state = state
+	// An "unknown" receipt may be followed by a definitive one, so such
+	// messages stay tracked until they time out.
+	if state.IsFinal() && (state != StateUnknown || r == nil) {
+		delete(t.messages, id)
+	}
+	t.notify(change)
+}
+
+// notify calls OnChange, if set. t.mu must be held.
+func (t *DeliveryTracker) notify(change StateChange) {
+	if t.OnChange != nil {
+		t.OnChange(change)
+	}
+}
@@ -0,0 +1,251 @@
+package nexmo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeliveryTracker(t *testing.T) {
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	var changes []StateChange
+	tracker := &DeliveryTracker{
+		Clock:    clock,
+		Timeout:  time.Hour,
+		OnChange: func(c StateChange) { changes = append(changes, c) },
+	}
+
+	tracker.Track(&MessageResponse{Messages: []MessageReport{
+		{Status: ResponseSuccess, MessageID: "1", To: "447700900000"},
+		{Status: ResponseSuccess, MessageID: "2", To: "447700900000"},
+		{Status: ResponseInvalidMessage, To: "447700900000"},
+	}})
+	if len(changes) != 3 || changes[0].State != StateSubmitted || changes[2].State != StateFailed {
+		t.Fatalf("got changes %+v", changes)
+	}
+
+	changes = nil
+	tracker.HandleReceipt(&DeliveryReceipt{MessageID: "1", Status: DeliveryBuffered})
+	tracker.HandleReceipt(&DeliveryReceipt{MessageID: "1", Status: DeliveryAccepted})
+	if state, _ := tracker.State("1"); state != StateAccepted {
+		t.Errorf("message 1 is %v", state)
+	}
+	tracker.HandleReceipt(&DeliveryReceipt{MessageID: "1", Status: DeliveryDelivered})
+	if _, ok := tracker.State("1"); ok {
+		t.Error("delivered message is still tracked")
+	}
+	if tracker.HandleReceipt(&DeliveryReceipt{MessageID: "1", Status: DeliveryFailed}) {
+		t.Error("receipt after the final state was handled")
+	}
+	if len(changes) != 2 || changes[1].Previous != StateAccepted || changes[1].State != StateDelivered {
+		t.Errorf("got changes %+v", changes)
+	}
+
+	changes = nil
+	tracker.Expire()
+	if len(changes) != 0 {
+		t.Errorf("got changes %+v before the timeout", changes)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	tracker.Expire()
+	if len(changes) != 1 || changes[0].MessageID != "2" || changes[0].State != StateUnknown {
+		t.Errorf("got changes %+v after the timeout", changes)
+	}
+}
+
+func TestDeliveryTrackerMessageStatus(t *testing.T) {
+	var changes []StateChange
+	tracker := &DeliveryTracker{
+		Clock:    &stoppedClock{now: time.Unix(1500000000, 0)},
+		OnChange: func(c StateChange) { changes = append(changes, c) },
+	}
+
+	msg := &OutboundMessage{
+		From:            MessageAddress{Type: "whatsapp", Number: "447700900001"},
+		To:              MessageAddress{Type: "whatsapp", Number: "447700900000"},
+		ClientReference: "order-42",
+	}
+	const uuid = "aaaaaaaa-bbbb-cccc-dddd-0123456789ab"
+	tracker.TrackOutbound(msg, &OutboundMessageResponse{MessageUUID: uuid})
+
+	if tracker.HandleStatus(&MessageStatus{MessageUUID: "other", Status: MessageDelivered}) {
+		t.Error("status of another message was handled")
+	}
+	if !tracker.HandleStatus(&MessageStatus{MessageUUID: uuid, Status: MessageFailed}) {
+		t.Error("status was not handled")
+	}
+	if _, ok := tracker.State(uuid); ok {
+		t.Error("failed message is still tracked")
+	}
+	if len(changes) != 2 || changes[1].MessageID != uuid || changes[1].To != "447700900000" ||
+		changes[1].ClientReference != "order-42" || changes[1].State != StateFailed {
+		t.Errorf("got changes %+v", changes)
+	}
+}
+
+func TestDeliveryTrackerUnknownReceipt(t *testing.T) {
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	var changes []StateChange
+	tracker := &DeliveryTracker{
+		Clock:    clock,
+		Timeout:  time.Hour,
+		OnChange: func(c StateChange) { changes = append(changes, c) },
+	}
+	tracker.Track(&MessageResponse{Messages: []MessageReport{
+		{Status: ResponseSuccess, MessageID: "1", To: "447700900000"},
+		{Status: ResponseSuccess, MessageID: "2", To: "447700900000"},
+	}})
+
+	changes = nil
+	for _, id := range []string{"1", "2"} {
+		tracker.HandleReceipt(&DeliveryReceipt{MessageID: id, Status: DeliveryUnknown})
+		if state, ok := tracker.State(id); !ok || state != StateUnknown {
+			t.Errorf("message %s is %v after an unknown receipt, tracked: %v", id, state, ok)
+		}
+	}
+
+	// A definitive receipt may follow.
+	if !tracker.HandleReceipt(&DeliveryReceipt{MessageID: "1", Status: DeliveryDelivered}) {
+		t.Fatal("delivery receipt after an unknown one was not handled")
+	}
+	if _, ok := tracker.State("1"); ok {
+		t.Error("delivered message is still tracked")
+	}
+	if len(changes) != 3 || changes[2].Previous != StateUnknown || changes[2].State != StateDelivered {
+		t.Errorf("got changes %+v", changes)
+	}
+
+	// Otherwise the message is forgotten once it times out.
+	changes = nil
+	clock.now = clock.now.Add(time.Hour)
+	tracker.Expire()
+	if _, ok := tracker.State("2"); ok || len(changes) != 0 {
+		t.Errorf("got changes %+v after the timeout, tracked: %v", changes, ok)
+	}
+}
+
+func TestResendPolicy(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := 0
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		ids++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(fmt.Sprintf(
+				`{"message-count":"1","messages":[{"status":"0","message-id":"%d"}]}`, ids))),
+		}, nil
+	})}
+
+	changes := make(chan StateChange, 10)
+	clock := &instantClock{}
+	tracker := &DeliveryTracker{
+		Clock:    clock,
+		OnChange: func(c StateChange) { changes <- c },
+		Resend: &ResendPolicy{
+			SMS:        client.SMS(),
+			MaxResends: 1,
+			Delay:      time.Hour,
+		},
+	}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello"}
+	resp, err := client.SMS().Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.TrackMessage(msg, resp)
+	<-changes
+
+	tracker.HandleReceipt(&DeliveryReceipt{MessageID: "1", Status: DeliveryFailed, ErrorCode: DLRAbsentSubscriberTemporary})
+	if c := <-changes; c.State != StateFailed || !c.Resend {
+		t.Errorf("got change %+v", c)
+	}
+	if c := <-changes; c.MessageID != "2" || c.State != StateSubmitted || c.Resends != 1 {
+		t.Errorf("got change %+v after resending", c)
+	}
+
+	// The resent message fails again, but may not be resent any more.
+	tracker.HandleReceipt(&DeliveryReceipt{MessageID: "2", Status: DeliveryFailed, ErrorCode: DLRAbsentSubscriberTemporary})
+	if c := <-changes; c.State != StateFailed || c.Resend {
+		t.Errorf("got change %+v", c)
+	}
+	if len(clock.delays) != 1 || clock.delays[0] != time.Hour {
+		t.Errorf("got delays %v", clock.delays)
+	}
+}
+
+func TestResendPolicyCodes(t *testing.T) {
+	p := &ResendPolicy{
+		MaxResends: 1,
+		Exclude:    []DLRErrorCode{DLRHandsetBusy},
+		Include:    []DLRErrorCode{DLRInsufficientFunds},
+	}
+	for _, test := range []struct {
+		code DLRErrorCode
+		want bool
+	}{
+		{DLRAbsentSubscriberTemporary, true},
+		{DLRHandsetBusy, false},
+		{DLRInsufficientFunds, true},
+		{DLRGeneralError, false},
+	} {
+		if got := p.allows(StateFailed, test.code, 0); got != test.want {
+			t.Errorf("allows(%v) = %v, want %v", test.code, got, test.want)
+		}
+	}
+	if p.allows(StateFailed, DLRInsufficientFunds, 1) {
+		t.Error("allowed more than MaxResends")
+	}
+}
+
+func TestResendPolicyDeduplication(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t", WithDeduplication(nil, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var sent SMSMessage
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			t.Error(err)
+		}
+		refs = append(refs, sent.ClientReference)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(fmt.Sprintf(
+				`{"message-count":"1","messages":[{"status":"0","message-id":"%d"}]}`, len(refs)))),
+		}, nil
+	})}
+
+	changes := make(chan StateChange, 10)
+	tracker := &DeliveryTracker{
+		Clock:    &instantClock{},
+		OnChange: func(c StateChange) { changes <- c },
+		Resend:   &ResendPolicy{SMS: client.SMS(), MaxResends: 1},
+	}
+
+	msg := &SMSMessage{From: "Test", To: "447700900000", Type: Text, Text: "Hello",
+		ClientReference: strings.Repeat("x", 40)}
+	resp, err := client.SMS().Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.TrackMessage(msg, resp)
+	<-changes
+
+	tracker.HandleReceipt(&DeliveryReceipt{MessageID: "1", Status: DeliveryFailed, ErrorCode: DLRAbsentSubscriberTemporary})
+	<-changes
+	if c := <-changes; c.MessageID != "2" || c.State != StateSubmitted {
+		t.Errorf("got change %+v after resending", c)
+	}
+	if want := strings.Repeat("x", 37) + "#r1"; len(refs) != 2 || refs[1] != want {
+		t.Errorf("sent client references %q, want the second to be %q", refs, want)
+	}
+}
@@ -0,0 +1,83 @@
+package nexmo
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultTransliterations maps characters outside the GSM-7 alphabet to
+// their closest equivalent within it. Accented letters lose their accent,
+// other characters are spelled out.
+var DefaultTransliterations = map[rune]string{
+	'á': "a", 'â': "a", 'ã': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'Á': "A", 'À': "A", 'Â': "A", 'Ã': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'ç': "c", 'ć': "c", 'č': "c", 'Ć': "C", 'Č': "C",
+	'ď': "d", 'đ': "d", 'Ď': "D", 'Đ': "D",
+	'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'È': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+	'ğ': "g", 'Ğ': "G",
+	'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'Í': "I", 'Ì': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'İ': "I",
+	'ł': "l", 'ľ': "l", 'Ł': "L", 'Ľ': "L",
+	'ń': "n", 'ň': "n", 'Ń': "N", 'Ň': "N",
+	'ó': "o", 'ô': "o", 'õ': "o", 'ō': "o", 'ő': "o",
+	'Ó': "O", 'Ò': "O", 'Ô': "O", 'Õ': "O", 'Ō': "O", 'Ő': "O",
+	'œ': "oe", 'Œ': "OE",
+	'ř': "r", 'Ř': "R",
+	'ś': "s", 'š': "s", 'ş': "s", 'Ś': "S", 'Š': "S", 'Ş': "S",
+	'ť': "t", 'ţ': "t", 'Ť': "T", 'Ţ': "T",
+	'ú': "u", 'û': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'Ú': "U", 'Ù': "U", 'Û': "U", 'Ū': "U", 'Ů': "U", 'Ű': "U",
+	'ý': "y", 'ÿ': "y", 'Ý': "Y", 'Ÿ': "Y",
+	'ź': "z", 'ż': "z", 'ž': "z", 'Ź': "Z", 'Ż': "Z", 'Ž': "Z",
+	'…': "...", '•': "-", '·': ".",
+	'™': "TM", '©': "(C)", '®': "(R)",
+	'´': "'", '`': "'",
+}
+
+// Transliterator is a ContentFilter opting into lossy conversion of text to
+// the GSM-7 alphabet, which for mostly Latin text cuts the number of
+// segments, and so the cost, by two to three times. Add it to a client with
+// WithContentFilter.
+type Transliterator struct {
+	// Substitutions replacing characters outside the alphabet. If nil,
+	// DefaultTransliterations is used.
+	Substitutions map[rune]string
+}
+
+// Transliterate normalizes text with NormalizeText, then replaces the
+// remaining characters outside the GSM-7 alphabet for which there is a
+// substitution. Characters without one are kept, and reported in the NonGSM
+// field of the returned segments.
+func (t *Transliterator) Transliterate(text string) (string, SegmentInfo) {
+	text, info := NormalizeText(text)
+	if len(info.NonGSM) == 0 {
+		return text, info
+	}
+
+	subs := t.Substitutions
+	if subs == nil {
+		subs = DefaultTransliterations
+	}
+	var b strings.Builder
+	for _, r := range text {
+		if s, ok := subs[r]; ok && !IsGSM7(r) {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	text = b.String()
+
+	return text, CountSegments(text)
+}
+
+// FilterContent implements ContentFilter. Unicode messages whose text is
+// transliterated entirely to GSM-7 are sent as Text messages.
+func (t *Transliterator) FilterContent(ctx context.Context, msg *SMSMessage) (string, error) {
+	text, info := t.Transliterate(msg.Text)
+	if msg.Type == Unicode && info.Encoding == GSM7 {
+		msg.Type = Text
+	}
+	return text, nil
+}
@@ -0,0 +1,35 @@
+package nexmo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	tr := &Transliterator{}
+	for _, tc := range []struct {
+		text, want string
+		encoding   SMSEncoding
+	}{
+		{"Façade “déjà vu”…", `Facade "déjà vu"...`, GSM7},
+		{"Łódź ©", "Lodz (C)", GSM7},
+		{"Crème brûlée", "Crème brulée", GSM7},
+		{"Привет", "Привет", UCS2},
+	} {
+		got, info := tr.Transliterate(tc.text)
+		if got != tc.want || info.Encoding != tc.encoding {
+			t.Errorf("Transliterate(%q) = %q, %v, want %q, %v", tc.text, got, info.Encoding, tc.want, tc.encoding)
+		}
+	}
+
+	custom := &Transliterator{Substitutions: map[rune]string{'ß': "ss", 'ł': "l"}}
+	if got, _ := custom.Transliterate("Straße, łódź"); got != "Straße, lódź" {
+		t.Errorf("custom Transliterate = %q", got)
+	}
+
+	msg := &SMSMessage{Type: Unicode, Text: "Ça coûte 5€"}
+	got, err := tr.FilterContent(context.Background(), msg)
+	if err != nil || got != "Ça coute 5€" || msg.Type != Text {
+		t.Errorf("FilterContent = %q, %v, type %q", got, err, msg.Type)
+	}
+}
@@ -0,0 +1,182 @@
+package nexmo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHTTPClient is used by clients which have not been given an
+// HTTPClient. Unlike http.DefaultClient it gives up on requests after
+// DefaultTimeout, so a stuck connection cannot hang a sender forever, and it
+// keeps enough idle connections to Nexmo around for high volume sending.
+var DefaultHTTPClient = NewHTTPClient()
+
+// DefaultTimeout is the timeout of the clients created by NewHTTPClient.
+const DefaultTimeout = 30 * time.Second
+
+// NewHTTPClient creates an http.Client tuned for talking to the Nexmo API:
+//   - requests time out after DefaultTimeout;
+//   - dialing, the TLS handshake and waiting for response headers have
+//     timeouts of their own;
+//   - up to 32 idle connections per host are kept, instead of 2;
+//   - HTTP/2 is used when available.
+//
+// Use it as a starting point to build a client with different settings, and
+// pass that to WithHTTPClient.
+func NewHTTPClient() *http.Client {
+	return newHTTPClient(newDialer().DialContext)
+}
+
+func newDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+func newHTTPClient(dial func(ctx context.Context, network, address string) (net.Conn, error)) *http.Client {
+	return &http.Client{
+		Timeout: DefaultTimeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dial,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 20 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
+
+// WithHTTPClient makes the client send its requests with hc instead of
+// DefaultHTTPClient, e.g. http.DefaultClient to get the previous behavior.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = hc
+	}
+}
+
+// WithDNSCache makes the client send its requests with a client created like
+// the ones of NewHTTPClient, whose connections to the Nexmo API are dialed
+// through a DNSCache keeping addresses for ttl. This saves a lookup for every
+// new connection, which shows up when sending at high volume.
+func WithDNSCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = newHTTPClient((&DNSCache{TTL: ttl}).DialContext)
+	}
+}
+
+// DNSCache dials connections to the Nexmo API hosts using cached addresses.
+// Connections to other hosts, e.g. a proxy, are dialed normally. Its
+// DialContext method can be used in an http.Transport of your own.
+//
+// The resolver of the standard library does not report the TTL of the
+// records it looks up, so addresses are kept for TTL instead; it should not
+// be longer than the TTL of the Nexmo records. If a lookup fails, expired
+// addresses are used until the next one succeeds.
+type DNSCache struct {
+	TTL      time.Duration // Defaults to a minute.
+	Resolver *net.Resolver // Defaults to net.DefaultResolver.
+	Dialer   *net.Dialer   // Defaults to the dialer of NewHTTPClient.
+	Clock    Clock
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+
+	// Overrides Resolver in tests.
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+type dnsEntry struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+// DialContext dials address on the named network like net.Dialer.DialContext,
+// trying each cached address of Nexmo API hosts in turn.
+func (d *DNSCache) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := d.Dialer
+	if dialer == nil {
+		dialer = newDialer()
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || !isNexmoHost(host) {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := d.addrs(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// addrs returns the addresses of host, looking them up if they are not
+// cached or have expired.
+func (d *DNSCache) addrs(ctx context.Context, host string) ([]net.IPAddr, error) {
+	now := clockOrSystem(d.Clock).Now()
+
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	lookup := d.lookup
+	if lookup == nil {
+		resolver := d.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		lookup = resolver.LookupIPAddr
+	}
+	addrs, err := lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if ok {
+			return entry.addrs, nil
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return nil, err
+	}
+
+	ttl := d.TTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	d.mu.Lock()
+	if d.entries == nil {
+		d.entries = make(map[string]dnsEntry)
+	}
+	d.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(ttl)}
+	d.mu.Unlock()
+	return addrs, nil
+}
+
+// isNexmoHost returns true if host is one of the Nexmo or Vonage API hosts.
+func isNexmoHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, domain := range []string{"nexmo.com", "vonage.com"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// stoppedClock tells the time it is set to.
+type stoppedClock struct {
+	now time.Time
+}
+
+func (c *stoppedClock) Now() time.Time                         { return c.now }
+func (c *stoppedClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func TestDNSCache(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	clock := &stoppedClock{now: time.Unix(1500000000, 0)}
+	var lookups []string
+	var lookupErr error
+	cache := &DNSCache{
+		TTL:   time.Minute,
+		Clock: clock,
+		lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			lookups = append(lookups, host)
+			return []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, lookupErr
+		},
+	}
+
+	dial := func() {
+		t.Helper()
+		conn, err := cache.DialContext(context.Background(), "tcp", net.JoinHostPort("rest.nexmo.com", port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+
+	dial()
+	dial()
+	if len(lookups) != 1 || lookups[0] != "rest.nexmo.com" {
+		t.Errorf("got lookups %q before the TTL expired", lookups)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	dial()
+	if len(lookups) != 2 {
+		t.Errorf("got lookups %q after the TTL expired", lookups)
+	}
+
+	// Expired addresses are used if the lookup fails.
+	clock.now = clock.now.Add(time.Minute)
+	lookupErr = errors.New("server misbehaving")
+	dial()
+	if len(lookups) != 3 {
+		t.Errorf("got lookups %q after a failed lookup", lookups)
+	}
+
+	// Other hosts are not looked up by the cache.
+	conn, err := cache.DialContext(context.Background(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if len(lookups) != 3 {
+		t.Errorf("got lookups %q for another host", lookups)
+	}
+}
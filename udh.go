@@ -0,0 +1,124 @@
+package nexmo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Information element identifiers of the user data header, as defined in
+// 3GPP TS 23.040.
+const (
+	IEIConcat8         = 0x00 // Concatenated message, 8-bit reference.
+	IEIPort8           = 0x04 // Application port addressing, 8-bit ports.
+	IEIPort16          = 0x05 // Application port addressing, 16-bit ports.
+	IEIConcat16        = 0x08 // Concatenated message, 16-bit reference.
+	IEIUSIMToolkitData = 0x70 // (U)SIM toolkit security header, used by OTA.
+)
+
+// ErrInvalidUDH is returned when a user data header can not be parsed.
+var ErrInvalidUDH = errors.New("invalid user data header")
+
+// UDHElement is an information element of a user data header.
+type UDHElement struct {
+	ID   byte
+	Data []byte
+}
+
+// UDHConcat identifies a part of a concatenated message.
+type UDHConcat struct {
+	Reference int // Shared by all parts of the message.
+	Total     int // Number of parts in the message.
+	Part      int // Number of this part, starting at 1.
+}
+
+// UDHPorts holds the application ports a message is addressed to, e.g. 2948
+// for WAP push or 9204 for vCard.
+type UDHPorts struct {
+	Destination int
+	Source      int
+}
+
+// UDH is a parsed user data header.
+type UDH struct {
+	// All information elements, in the order they occur, including the ones
+	// decoded below.
+	Elements []UDHElement
+
+	// Set if the header has a concatenation element.
+	Concat *UDHConcat
+
+	// Set if the header has an application port addressing element.
+	Ports *UDHPorts
+}
+
+// Element returns the data of the first information element with id, and
+// whether there is one.
+func (h *UDH) Element(id byte) ([]byte, bool) {
+	for _, e := range h.Elements {
+		if e.ID == id {
+			return e.Data, true
+		}
+	}
+	return nil, false
+}
+
+// ParseUDH parses a binary user data header, starting with its length byte.
+func ParseUDH(b []byte) (*UDH, error) {
+	if len(b) == 0 || int(b[0]) != len(b)-1 {
+		return nil, ErrInvalidUDH
+	}
+
+	h := new(UDH)
+	for rest := b[1:]; len(rest) > 0; {
+		if len(rest) < 2 || int(rest[1]) > len(rest)-2 {
+			return nil, ErrInvalidUDH
+		}
+		e := UDHElement{ID: rest[0], Data: rest[2 : 2+rest[1]]}
+		rest = rest[2+rest[1]:]
+		h.Elements = append(h.Elements, e)
+
+		if err := h.decode(e); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// decode sets the typed fields of h from e, if it is one of the elements
+// they represent.
+func (h *UDH) decode(e UDHElement) error {
+	d := e.Data
+	switch e.ID {
+	case IEIConcat8:
+		if len(d) != 3 {
+			return fmt.Errorf("%w: concatenation element of %d bytes", ErrInvalidUDH, len(d))
+		}
+		h.Concat = &UDHConcat{Reference: int(d[0]), Total: int(d[1]), Part: int(d[2])}
+	case IEIConcat16:
+		if len(d) != 4 {
+			return fmt.Errorf("%w: concatenation element of %d bytes", ErrInvalidUDH, len(d))
+		}
+		h.Concat = &UDHConcat{Reference: int(d[0])<<8 | int(d[1]), Total: int(d[2]), Part: int(d[3])}
+	case IEIPort8:
+		if len(d) != 2 {
+			return fmt.Errorf("%w: port element of %d bytes", ErrInvalidUDH, len(d))
+		}
+		h.Ports = &UDHPorts{Destination: int(d[0]), Source: int(d[1])}
+	case IEIPort16:
+		if len(d) != 4 {
+			return fmt.Errorf("%w: port element of %d bytes", ErrInvalidUDH, len(d))
+		}
+		h.Ports = &UDHPorts{Destination: int(d[0])<<8 | int(d[1]), Source: int(d[2])<<8 | int(d[3])}
+	}
+	return nil
+}
+
+// ParseUDH parses the user data header of a binary message. Nexmo sends the
+// header hex encoded, but headers already decoded are accepted as well.
+func (m *ReceivedMessage) ParseUDH() (*UDH, error) {
+	if b, err := hex.DecodeString(string(m.UDH)); err == nil {
+		return ParseUDH(b)
+	}
+	return ParseUDH(m.UDH)
+}
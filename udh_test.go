@@ -0,0 +1,46 @@
+package nexmo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUDH(t *testing.T) {
+	// Part 2 of 3 with reference 0x42, addressed to the vCard port.
+	m := &ReceivedMessage{UDH: []byte("0b0003420302050423f40000")}
+	h, err := m.ParseUDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Concat == nil || *h.Concat != (UDHConcat{Reference: 0x42, Total: 3, Part: 2}) {
+		t.Errorf("Concat = %+v", h.Concat)
+	}
+	if h.Ports == nil || *h.Ports != (UDHPorts{Destination: 9204, Source: 0}) {
+		t.Errorf("Ports = %+v", h.Ports)
+	}
+	if len(h.Elements) != 2 {
+		t.Errorf("got %d elements, want 2", len(h.Elements))
+	}
+
+	h, err = ParseUDH([]byte{0x06, 0x08, 0x04, 0x12, 0x34, 0x02, 0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Concat == nil || *h.Concat != (UDHConcat{Reference: 0x1234, Total: 2, Part: 1}) {
+		t.Errorf("Concat = %+v", h.Concat)
+	}
+	if data, ok := h.Element(IEIConcat16); !ok || len(data) != 4 {
+		t.Errorf("Element(IEIConcat16) = %x, %v", data, ok)
+	}
+
+	for _, b := range [][]byte{
+		nil,
+		{0x05, 0x00, 0x03, 0x01},
+		{0x04, 0x00, 0x03, 0x01, 0x02},
+		{0x04, 0x00, 0x02, 0x01, 0x02},
+	} {
+		if _, err := ParseUDH(b); !errors.Is(err, ErrInvalidUDH) {
+			t.Errorf("ParseUDH(%x) error = %v", b, err)
+		}
+	}
+}
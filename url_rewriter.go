@@ -0,0 +1,44 @@
+package nexmo
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Matches the http and https URLs in a text.
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// URLShortener returns the short URL to send to recipient instead of url,
+// e.g. one carrying a tracking token for the recipient.
+type URLShortener func(ctx context.Context, url, recipient string) (string, error)
+
+// URLRewriter is a ContentFilter replacing the URLs in the text of messages
+// with the ones returned by Shorten. Punctuation ending a sentence is not
+// considered part of a URL. Add it to a client with WithContentFilter, so it
+// applies to all messages sent by the client, whether one by one, streamed
+// or as part of a campaign.
+type URLRewriter struct {
+	Shorten URLShortener
+}
+
+// FilterContent implements ContentFilter. Shortening errors reject the
+// message.
+func (r *URLRewriter) FilterContent(ctx context.Context, msg *SMSMessage) (string, error) {
+	var err error
+	text := urlPattern.ReplaceAllStringFunc(msg.Text, func(match string) string {
+		if err != nil {
+			return match
+		}
+		url := strings.TrimRight(match, ".,;:!?)'")
+		var short string
+		if short, err = r.Shorten(ctx, url, msg.To); err != nil {
+			return match
+		}
+		return short + match[len(url):]
+	})
+	if err != nil {
+		return "", err
+	}
+	return text, nil
+}
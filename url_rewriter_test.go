@@ -0,0 +1,33 @@
+package nexmo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestURLRewriter(t *testing.T) {
+	r := &URLRewriter{Shorten: func(ctx context.Context, url, recipient string) (string, error) {
+		if url == "https://example.com/broken" {
+			return "", errors.New("shortener unavailable")
+		}
+		return "https://sho.rt/" + recipient + "/" + url[len(url)-1:], nil
+	}}
+
+	for _, tc := range []struct {
+		text, want string
+	}{
+		{"No links here", "No links here"},
+		{"See https://example.com/offer?id=1.", "See https://sho.rt/447700900000/1."},
+		{"(http://example.com/a) and https://example.com/b!", "(https://sho.rt/447700900000/a) and https://sho.rt/447700900000/b!"},
+	} {
+		got, err := r.FilterContent(context.Background(), &SMSMessage{To: "447700900000", Text: tc.text})
+		if err != nil || got != tc.want {
+			t.Errorf("FilterContent(%q) = %q, %v, want %q", tc.text, got, err, tc.want)
+		}
+	}
+
+	if _, err := r.FilterContent(context.Background(), &SMSMessage{Text: "https://example.com/broken"}); err == nil {
+		t.Error("shortener error was ignored")
+	}
+}
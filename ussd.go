@@ -1,10 +1,7 @@
 package nexmo
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
+	"context"
 	"net/http"
 	"net/url"
 )
@@ -31,34 +28,41 @@ type USSDMessage struct {
 
 // Send the message using the specified USSD client.
 func (c *USSD) Send(msg *USSDMessage) (*MessageResponse, error) {
+	r, err := c.newRequest(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	messageResponse := new(MessageResponse)
+	if err := c.client.do(context.Background(), r, messageResponse); err != nil {
+		return nil, err
+	}
+	return messageResponse, nil
+}
+
+// newRequest validates msg and creates the request sending it.
+func (c *USSD) newRequest(msg *USSDMessage) (*http.Request, error) {
 	if len(msg.From) <= 0 {
-		return nil, errors.New("Invalid From field specified")
+		return nil, ErrMissingFrom
 	}
 
 	if len(msg.To) <= 0 {
-		return nil, errors.New("Invalid To field specified")
+		return nil, ErrMissingTo
 	}
 
 	if len(msg.ClientReference) > 40 {
-		return nil, errors.New("Client reference too long")
+		return nil, ErrClientRefTooLong
 	}
 
-	var messageResponse *MessageResponse
-
 	values := make(url.Values)
 
 	if len(msg.Text) <= 0 {
-		return nil, errors.New("Invalid message text")
+		return nil, ErrMissingText
 	}
 
 	// TODO(inhies): UTF8 and URL encode before setting
 	values.Set("text", msg.Text)
 
-	if !c.client.useOauth {
-		values.Set("api_key", c.client.apiKey)
-		values.Set("api_secret", c.client.apiSecret)
-	}
-
 	if msg.StatusReportRequired {
 		values.Set("status_report_req", "1")
 	}
@@ -71,37 +75,12 @@ func (c *USSD) Send(msg *USSDMessage) (*MessageResponse, error) {
 		values.Set("network-code", msg.NetworkCode)
 	}
 
-	var endpoint string
+	endpoint := EndpointUSSD
 	if msg.Prompt {
-		endpoint = "/ussd-prompt/json"
-	} else {
-		endpoint = "/ussd/json"
+		endpoint = EndpointUSSDPrompt
 	}
 	values.Set("to", msg.To)
 	values.Set("from", msg.From)
 
-	valuesReader := bytes.NewReader([]byte(values.Encode()))
-	var r *http.Request
-	r, _ = http.NewRequest("POST", apiRoot+endpoint, valuesReader)
-
-	r.Header.Add("Accept", "application/json")
-	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := c.client.HTTPClient.Do(r)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(body, &messageResponse)
-	if err != nil {
-		return nil, err
-	}
-
-	return messageResponse, nil
+	return c.client.newRequest(endpoint, values)
 }
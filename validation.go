@@ -0,0 +1,165 @@
+package nexmo
+
+import "errors"
+
+// ValidationError is returned when a message or request can not be sent
+// because one of its fields is invalid. The errors for the usual problems are
+// exported as Err* variables, so they can be compared against directly:
+//
+//	if errors.Is(err, nexmo.ErrMissingTo) { ... }
+//
+// while errors.As distinguishes validation problems in general.
+type ValidationError struct {
+	Field  string // Name of the invalid field, e.g. "From".
+	Reason string
+
+	// Underlying error, if any, e.g. ErrFieldNotAllowed.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid " + e.Field + " field: " + e.Reason
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrFieldNotAllowed is wrapped in the *ValidationError returned for a
+// payload field which is set but not allowed for the type of the message,
+// e.g. a Text in a binary message.
+var ErrFieldNotAllowed = errors.New("field not allowed for the message type")
+
+// maxClientRefLength is the longest client reference Nexmo accepts.
+const maxClientRefLength = 40
+
+// Validation errors.
+var (
+	ErrMissingFrom      = &ValidationError{Field: "From", Reason: "missing"}
+	ErrMissingTo        = &ValidationError{Field: "To", Reason: "missing"}
+	ErrClientRefTooLong = &ValidationError{Field: "ClientReference", Reason: "longer than 40 characters"}
+	ErrMissingText      = &ValidationError{Field: "Text", Reason: "missing"}
+	ErrInvalidBinary    = &ValidationError{Field: "Body", Reason: "binary messages need both a Body and a UDH"}
+	ErrInvalidWAPPush   = &ValidationError{Field: "URL", Reason: "WAP push messages need both a URL and a Title"}
+	ErrInvalidValidity  = &ValidationError{Field: "Validity", Reason: "not between 20s and 168h in milliseconds"}
+	ErrMissingVCard     = &ValidationError{Field: "VCard", Reason: "missing"}
+	ErrMissingVCal      = &ValidationError{Field: "VCal", Reason: "missing"}
+	ErrInvalidType      = &ValidationError{Field: "Type", Reason: "unknown message type"}
+	ErrMissingNumber    = &ValidationError{Field: "Number", Reason: "missing"}
+	ErrMissingBrand     = &ValidationError{Field: "Brand", Reason: "missing"}
+	ErrMissingRequestID = &ValidationError{Field: "RequestID", Reason: "missing"}
+	ErrMissingCode      = &ValidationError{Field: "Code", Reason: "missing"}
+	ErrMissingCommand   = &ValidationError{Field: "Command", Reason: "missing"}
+	ErrMissingChannel   = &ValidationError{Field: "Type", Reason: "the channel of From and To is missing"}
+)
+
+// A messageField is an optional payload field of an SMSMessage.
+type messageField struct {
+	name  string
+	isSet func(*SMSMessage) bool
+}
+
+var (
+	fieldText     = messageField{"Text", func(m *SMSMessage) bool { return m.Text != "" }}
+	fieldBody     = messageField{"Body", func(m *SMSMessage) bool { return len(m.Body) > 0 }}
+	fieldUDH      = messageField{"UDH", func(m *SMSMessage) bool { return len(m.UDH) > 0 }}
+	fieldURL      = messageField{"URL", func(m *SMSMessage) bool { return m.URL != "" }}
+	fieldTitle    = messageField{"Title", func(m *SMSMessage) bool { return m.Title != "" }}
+	fieldValidity = messageField{"Validity", func(m *SMSMessage) bool { return m.Validity != 0 }}
+	fieldVCard    = messageField{"VCard", func(m *SMSMessage) bool { return m.VCard != "" }}
+	fieldVCal     = messageField{"VCal", func(m *SMSMessage) bool { return m.VCal != "" }}
+	fieldClass    = messageField{"Class", func(m *SMSMessage) bool { return m.Class != 0 }}
+)
+
+// messageRule lists the payload fields a message type requires, returning
+// missing if any of them is not set, and those it forbids.
+type messageRule struct {
+	required  []messageField
+	missing   *ValidationError
+	forbidden []messageField
+}
+
+// The valid combinations of message type and payload fields.
+var messageRules = map[string]messageRule{
+	Text: {
+		required:  []messageField{fieldText},
+		missing:   ErrMissingText,
+		forbidden: []messageField{fieldBody, fieldUDH, fieldURL, fieldTitle, fieldValidity, fieldVCard, fieldVCal},
+	},
+	Unicode: {
+		required:  []messageField{fieldText},
+		missing:   ErrMissingText,
+		forbidden: []messageField{fieldBody, fieldUDH, fieldURL, fieldTitle, fieldValidity, fieldVCard, fieldVCal},
+	},
+	Binary: {
+		required:  []messageField{fieldBody, fieldUDH},
+		missing:   ErrInvalidBinary,
+		forbidden: []messageField{fieldText, fieldURL, fieldTitle, fieldValidity, fieldVCard, fieldVCal},
+	},
+	WAPPush: {
+		required:  []messageField{fieldURL, fieldTitle},
+		missing:   ErrInvalidWAPPush,
+		forbidden: []messageField{fieldText, fieldBody, fieldUDH, fieldVCard, fieldVCal, fieldClass},
+	},
+	VCard: {
+		required:  []messageField{fieldVCard},
+		missing:   ErrMissingVCard,
+		forbidden: []messageField{fieldText, fieldBody, fieldUDH, fieldURL, fieldTitle, fieldValidity, fieldVCal, fieldClass},
+	},
+	VCal: {
+		required:  []messageField{fieldVCal},
+		missing:   ErrMissingVCal,
+		forbidden: []messageField{fieldText, fieldBody, fieldUDH, fieldURL, fieldTitle, fieldValidity, fieldVCard, fieldClass},
+	},
+}
+
+// Validate returns a *ValidationError if m can not be sent: if it lacks a
+// sender or recipient, or if its payload fields do not match its type, e.g.
+// a WAP push message without a URL or a binary message with a Text. Checking
+// this before sending avoids being charged for the failed request.
+func (m *SMSMessage) Validate() error {
+	if len(m.From) <= 0 {
+		return ErrMissingFrom
+	}
+	if len(m.To) <= 0 {
+		return ErrMissingTo
+	}
+	if len(m.ClientReference) > maxClientRefLength {
+		return ErrClientRefTooLong
+	}
+
+	rule, ok := messageRules[m.typeName()]
+	if !ok {
+		return ErrInvalidType
+	}
+	for _, f := range rule.required {
+		if !f.isSet(m) {
+			return rule.missing
+		}
+	}
+	for _, f := range rule.forbidden {
+		if f.isSet(m) {
+			return &ValidationError{
+				Field:  f.name,
+				Reason: "not allowed in " + m.typeName() + " messages",
+				Err:    ErrFieldNotAllowed,
+			}
+		}
+	}
+
+	if m.Validity != 0 {
+		if d := m.ValidityDuration(); d < MinWAPPushValidity || d > MaxWAPPushValidity {
+			return ErrInvalidValidity
+		}
+	}
+	return nil
+}
+
+// typeName returns the type of m, which is text if not set.
+func (m *SMSMessage) typeName() string {
+	if m.Type == "" {
+		return Text
+	}
+	return m.Type
+}
@@ -1,10 +1,8 @@
 package nexmo
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
-	"io/ioutil"
 	"net/http"
 )
 
@@ -13,26 +11,9 @@ type Verification struct {
 	client *Client
 }
 
-// MarshalJSON returns a byte slice with the serialized JSON of the
-// VerifyMessageRequest struct.
-func (m *VerifyMessageRequest) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		APIKey    string `json:"api_key"`
-		APISecret string `json:"api_secret"`
-		VerifyMessageRequest
-	}{
-		APIKey:               m.apiKey,
-		APISecret:            m.apiSecret,
-		VerifyMessageRequest: *m,
-	})
-}
-
 // VerifyMessageRequest is the request struct for initiating the verification process
 // for a phone number.
 type VerifyMessageRequest struct {
-	apiKey    string
-	apiSecret string
-
 	Number        string `json:"number"`
 	Brand         string `json:"brand"`
 	SenderID      string `json:"sender_id,omitempty"`
@@ -46,82 +27,48 @@ type VerifyMessageRequest struct {
 // VerifyMessageResponse is the struct for the response from the verify
 // endpoint.
 type VerifyMessageResponse struct {
-	Status    ResponseCode `json:"status,string"`
+	Status    ResponseCode `json:"status"`
 	RequestID string       `json:"request_id"`
 	ErrorText string       `json:"error_text"`
+	ResponseMeta
 }
 
 // Send makes the actual HTTP request to the endpoint and returns the
 // response.
 func (c *Verification) Send(m *VerifyMessageRequest) (*VerifyMessageResponse, error) {
-	if len(m.Number) == 0 {
-		return nil, errors.New("Invalid Number field specified")
-	}
-
-	if len(m.Brand) == 0 {
-		return nil, errors.New("Invalid Brand field specified")
-	}
-
-	var verifyMessageResponse *VerifyMessageResponse
-
-	if !c.client.useOauth {
-		m.apiKey = c.client.apiKey
-		m.apiSecret = c.client.apiSecret
-	}
-
-	var r *http.Request
-	buf, err := json.Marshal(m)
-	if err != nil {
-		return nil, errors.New("invalid message struct - can not convert to JSON")
-	}
+	return c.send(context.Background(), m)
+}
 
-	b := bytes.NewBuffer(buf)
-	r, err = http.NewRequest("POST", apiRootv2+"/verify/json", b)
+func (c *Verification) send(ctx context.Context, m *VerifyMessageRequest) (*VerifyMessageResponse, error) {
+	r, err := c.newSendRequest(m)
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header.Add("Accept", "application/json")
-	r.Header.Add("Content-Type", "application/json")
-
-	resp, err := c.client.HTTPClient.Do(r)
-	if err != nil {
+	verifyMessageResponse := new(VerifyMessageResponse)
+	if err := c.client.do(ctx, r, verifyMessageResponse); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return verifyMessageResponse, nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// newSendRequest validates m and creates the request for Send.
+func (c *Verification) newSendRequest(m *VerifyMessageRequest) (*http.Request, error) {
+	if len(m.Number) == 0 {
+		return nil, ErrMissingNumber
 	}
 
-	err = json.Unmarshal(body, &verifyMessageResponse)
-	if err != nil {
-		return nil, err
+	if len(m.Brand) == 0 {
+		return nil, ErrMissingBrand
 	}
-	return verifyMessageResponse, nil
-}
 
-// MarshalJSON implements the json.Marshaler interface
-func (m *VerifyCheckRequest) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		APIKey    string `json:"api_key"`
-		APISecret string `json:"api_secret"`
-		VerifyCheckRequest
-	}{
-		APIKey:             m.apiKey,
-		APISecret:          m.apiSecret,
-		VerifyCheckRequest: *m,
-	})
+	return c.client.newRequest(EndpointVerify, m)
 }
 
 // A VerifyCheckRequest is sent to Nexmo
 // when we want to verify a user has the
 // phone number he says he does.
 type VerifyCheckRequest struct {
-	apiKey    string
-	apiSecret string
-
 	RequestID string `json:"request_id"`
 	Code      string `json:"code"`
 	IPAddress string `json:"ip_address,omitempty"`
@@ -131,99 +78,105 @@ type VerifyCheckRequest struct {
 // after verifying a user has the
 // phone number he says he does.
 type VerifyCheckResponse struct {
-	Status    ResponseCode `json:"status,string"`
+	Status    ResponseCode `json:"status"`
 	EventID   string       `json:"event_id"`
 	Price     string       `json:"price"`
 	Currency  string       `json:"currency"`
 	ErrorText string       `json:"error_text"`
+	ResponseMeta
 }
 
 // Check (by sending a PIN to a user) whether a user can be contacted at his given phone number.
 // https://developer.nexmo.com/api/verify#verify-check
 func (c *Verification) Check(m *VerifyCheckRequest) (*VerifyCheckResponse, error) {
-	if len(m.RequestID) == 0 {
-		return nil, errors.New("Invalid RequestID field specified")
-	}
-
-	if len(m.Code) == 0 {
-		return nil, errors.New("Invalid Code field specified")
-	}
-
-	var verifyCheckResponse *VerifyCheckResponse
-
-	if !c.client.useOauth {
-		m.apiKey = c.client.apiKey
-		m.apiSecret = c.client.apiSecret
-	}
-
-	var r *http.Request
-	buf, err := json.Marshal(m)
-	if err != nil {
-		return nil, errors.New("invalid message struct - unable to convert to JSON")
-	}
-	b := bytes.NewBuffer(buf)
-	r, err = http.NewRequest("POST", apiRootv2+"/verify/check/json", b)
+	r, err := c.newCheckRequest(m)
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header.Add("Accept", "application/json")
-	r.Header.Add("Content-Type", "application/json")
-
-	resp, err := c.client.HTTPClient.Do(r)
-	if err != nil {
+	verifyCheckResponse := new(VerifyCheckResponse)
+	if err := c.client.do(context.Background(), r, verifyCheckResponse); err != nil {
 		return nil, err
 	}
+	return verifyCheckResponse, nil
+}
 
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// newCheckRequest validates m and creates the request for Check.
+func (c *Verification) newCheckRequest(m *VerifyCheckRequest) (*http.Request, error) {
+	if len(m.RequestID) == 0 {
+		return nil, ErrMissingRequestID
 	}
 
-	err = json.Unmarshal(body, &verifyCheckResponse)
-	if err != nil {
-		return nil, err
+	if len(m.Code) == 0 {
+		return nil, ErrMissingCode
 	}
-	return verifyCheckResponse, nil
-}
 
-// MarshalJSON implements the json.Marshaler interface
-func (m *VerifySearchRequest) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		APIKey    string `json:"api_key"`
-		APISecret string `json:"api_secret"`
-		VerifySearchRequest
-	}{
-		APIKey:              m.apiKey,
-		APISecret:           m.apiSecret,
-		VerifySearchRequest: *m,
-	})
+	return c.client.newRequest(EndpointVerifyCheck, m)
 }
 
 // A VerifySearchRequest is sent to Nexmo
 // when searching for the status of a Verify
 // request.
 type VerifySearchRequest struct {
-	apiKey    string
-	apiSecret string
-
 	RequestID string `json:"request_id,omitempty"`
 }
 
+// VerifyStatus is the status of a verification, as reported by Search. It is
+// one of the Verify* constants, or the numeric error code of a failed search,
+// e.g. "101" when no verification was found.
+type VerifyStatus string
+
+// Verification statuses
+const (
+	VerifyInProgress VerifyStatus = "IN PROGRESS"
+	VerifySuccess    VerifyStatus = "SUCCESS"
+	VerifyFailed     VerifyStatus = "FAILED"
+	VerifyExpired    VerifyStatus = "EXPIRED"
+	VerifyCancelled  VerifyStatus = "CANCELLED"
+)
+
+// String implements the fmt.Stringer interface. It returns the status as sent
+// by Nexmo, unless another description was installed with SetDescriptions.
+func (s VerifyStatus) String() string {
+	if str, ok := currentDescriptions().VerifyStatuses[s]; ok {
+		return str
+	}
+	return string(s)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Statuses are
+// accepted both as strings and as numbers; all values are kept as is.
+func (s *VerifyStatus) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = VerifyStatus(str)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*s = VerifyStatus(n.String())
+	return nil
+}
+
 // A VerifySearchResponse is received from Nexmo in
 // response to a VerifySearchRequest
 type VerifySearchResponse struct {
-	RequestID      string `json:"request_id"`
-	AccountID      string `json:"account_id"`
-	Number         string `json:"number"`
-	SenderID       string `json:"sender_id"`
-	DateSubmitted  string `json:"date_submitted"`
-	DateFinalized  string `json:"date_finalized"`
-	FirstEventDate string `json:"first_event_date"`
-	LastEventDate  string `json:"last_event_date"`
-	Status         string `json:"status"`
+	RequestID      string       `json:"request_id"`
+	AccountID      string       `json:"account_id"`
+	Number         string       `json:"number"`
+	SenderID       string       `json:"sender_id"`
+	DateSubmitted  string       `json:"date_submitted"`
+	DateFinalized  string       `json:"date_finalized"`
+	FirstEventDate string       `json:"first_event_date"`
+	LastEventDate  string       `json:"last_event_date"`
+	Status         VerifyStatus `json:"status"`
 	Checks         []struct {
 		DateReceived string `json:"date_received"`
 		Code         string `json:"code"`
@@ -233,71 +186,32 @@ type VerifySearchResponse struct {
 	Price     string `json:"price"`
 	Currency  string `json:"currency"`
 	ErrorText string `json:"error_text"`
+	ResponseMeta
 }
 
 // Search sends the verify search request to Nexmo.
 // https://developer.nexmo.com/api/verify#verify-search
 func (c *Verification) Search(m *VerifySearchRequest) (*VerifySearchResponse, error) {
-	var verifySearchResponse *VerifySearchResponse
-
-	if !c.client.useOauth {
-		m.apiKey = c.client.apiKey
-		m.apiSecret = c.client.apiSecret
-	}
-
-	var r *http.Request
-	buf, err := json.Marshal(m)
-	if err != nil {
-		return nil, errors.New("invalid message struct - unable to convert to JSON")
-	}
-
-	b := bytes.NewBuffer(buf)
-	r, err = http.NewRequest("POST", apiRootv2+"/verify/search/json", b)
-	if err != nil {
-		return nil, err
-	}
-
-	r.Header.Add("Accept", "application/json")
-	r.Header.Add("Content-Type", "application/json")
-
-	resp, err := c.client.HTTPClient.Do(r)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	r, err := c.newSearchRequest(m)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(body, &verifySearchResponse)
-	if err != nil {
+	verifySearchResponse := new(VerifySearchResponse)
+	if err := c.client.do(context.Background(), r, verifySearchResponse); err != nil {
 		return nil, err
 	}
-
 	return verifySearchResponse, nil
 }
 
-// MarshalJSON implements the json.Marshaler interface
-func (m *VerifyControlRequest) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		APIKey    string `json:"api_key"`
-		APISecret string `json:"api_secret"`
-		VerifyControlRequest
-	}{
-		APIKey:               m.apiKey,
-		APISecret:            m.apiSecret,
-		VerifyControlRequest: *m,
-	})
+// newSearchRequest validates m and creates the request for Search.
+func (c *Verification) newSearchRequest(m *VerifySearchRequest) (*http.Request, error) {
+	return c.client.newRequest(EndpointVerifySearch, m)
 }
 
 // VerifyControlRequest is the request struct for control verificaion such as cancel verification request
 // and trigger next verification process
 type VerifyControlRequest struct {
-	apiKey    string
-	apiSecret string
-
 	RequestID string `json:"request_id"`
 	Command   string `json:"cmd"`
 }
@@ -305,60 +219,36 @@ type VerifyControlRequest struct {
 // VerifyControlResponse is received from Nexmo in
 // response to a VerifyControlRequest
 type VerifyControlResponse struct {
-	Status    ResponseCode `json:"status,string"`
+	Status    ResponseCode `json:"status"`
 	Command   string       `json:"command"`
 	ErrorText string       `json:"error_text"`
+	ResponseMeta
 }
 
 // Control the progress of Verify Requests
 // https://developer.nexmo.com/api/verify#verify-control
 func (c *Verification) Control(m *VerifyControlRequest) (*VerifyControlResponse, error) {
-	if len(m.RequestID) == 0 {
-		return nil, errors.New("Invalid Request ID field specified")
-	}
-
-	if len(m.Command) == 0 {
-		return nil, errors.New("Invalid Command field specified")
-	}
-
-	var verifyControlResponse *VerifyControlResponse
-
-	if !c.client.useOauth {
-		m.apiKey = c.client.apiKey
-		m.apiSecret = c.client.apiSecret
-	}
-
-	var r *http.Request
-	buf, err := json.Marshal(m)
-	if err != nil {
-		return nil, errors.New("invalid message struct - unable to convert to JSON")
-	}
-
-	b := bytes.NewBuffer(buf)
-	r, err = http.NewRequest("POST", apiRootv2+"/verify/control/json", b)
+	r, err := c.newControlRequest(m)
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header.Add("Accept", "application/json")
-	r.Header.Add("Content-Type", "application/json")
-
-	resp, err := c.client.HTTPClient.Do(r)
-	if err != nil {
+	verifyControlResponse := new(VerifyControlResponse)
+	if err := c.client.do(context.Background(), r, verifyControlResponse); err != nil {
 		return nil, err
 	}
+	return verifyControlResponse, nil
+}
 
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// newControlRequest validates m and creates the request for Control.
+func (c *Verification) newControlRequest(m *VerifyControlRequest) (*http.Request, error) {
+	if len(m.RequestID) == 0 {
+		return nil, ErrMissingRequestID
 	}
 
-	err = json.Unmarshal(body, &verifyControlResponse)
-	if err != nil {
-		return nil, err
+	if len(m.Command) == 0 {
+		return nil, ErrMissingCommand
 	}
 
-	return verifyControlResponse, nil
+	return c.client.newRequest(EndpointVerifyControl, m)
 }
@@ -0,0 +1,84 @@
+package nexmo
+
+import (
+	"context"
+	"sync"
+)
+
+// VerifyBatchResult is the outcome of one of the verifications started by
+// Verification.SendBatch.
+type VerifyBatchResult struct {
+	Request  *VerifyMessageRequest
+	Response *VerifyMessageResponse
+	Err      error
+}
+
+// BatchOption configures Verification.SendBatch.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	rate  float64
+	burst int
+}
+
+// WithBatchRate limits a batch to starting perSecond verifications per second
+// on average, with bursts of up to burst verifications. If perSecond is 0 or
+// less, the batch is not limited.
+func WithBatchRate(perSecond float64, burst int) BatchOption {
+	return func(cfg *batchConfig) {
+		cfg.rate = perSecond
+		cfg.burst = burst
+	}
+}
+
+// SendBatch starts the verifications of requests, running up to concurrency
+// of them in parallel, and returns their results in the order of requests.
+// A failed verification does not stop the others; once ctx is done, the
+// verifications not started yet fail with the error of ctx.
+func (c *Verification) SendBatch(ctx context.Context, requests []*VerifyMessageRequest, concurrency int, opts ...BatchOption) []VerifyBatchResult {
+	cfg := &batchConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var limiter *rateLimiter
+	if cfg.rate > 0 {
+		limiter = newRateLimiter(clockOrSystem(c.client.Clock), cfg.rate, cfg.burst)
+	}
+
+	results := make([]VerifyBatchResult, len(requests))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		results[i].Request = req
+
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(res *VerifyBatchResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if limiter != nil {
+				if res.Err = limiter.waitContext(ctx); res.Err != nil {
+					return
+				}
+			}
+			res.Response, res.Err = c.send(ctx, res.Request)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,175 @@
+package nexmo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// VerifyFlow starts verifications and escalates those whose code is not
+// checked in time: first by triggering the next event of the Verify
+// workflow, usually a text-to-speech call, then by calling Fallback, e.g. to
+// read the code out with a call placed by the application.
+type VerifyFlow struct {
+	Verify *Verification
+
+	// How long to wait for the code before escalating. Defaults to two
+	// minutes.
+	Window time.Duration
+
+	// Number of times the next event is triggered. Defaults to 1; set it
+	// to -1 to go straight to Fallback.
+	NextEvents int
+
+	// Called once all next events are used up, or could not be triggered.
+	Fallback func(ctx context.Context, req *VerifyMessageRequest, requestID string) error
+
+	// Defaults to SystemClock.
+	Clock Clock
+}
+
+// VerifyOutcome is the result of a verification started by VerifyFlow.
+type VerifyOutcome struct {
+	RequestID string
+
+	// VerifySuccess if the code was checked, or the status reported by
+	// Search once every escalation has been tried, e.g. VerifyExpired.
+	Status VerifyStatus
+
+	// Number of next events triggered, and whether Fallback was called.
+	NextEvents int
+	FellBack   bool
+
+	// Set if the verification could not be followed to its end.
+	Err error
+}
+
+// VerifyAttempt is a verification in progress.
+type VerifyAttempt struct {
+	RequestID string
+
+	flow    *VerifyFlow
+	req     *VerifyMessageRequest
+	checked chan struct{}
+	once    sync.Once
+	done    chan struct{}
+	outcome VerifyOutcome
+}
+
+// Start starts the verification of req, and escalates it in the background
+// until its code is checked with VerifyAttempt.Check or ctx is done.
+func (f *VerifyFlow) Start(ctx context.Context, req *VerifyMessageRequest) (*VerifyAttempt, error) {
+	resp, err := f.Verify.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != ResponseSuccess {
+		return nil, fmt.Errorf("starting verification of %s: %v: %s", req.Number, resp.Status, resp.ErrorText)
+	}
+
+	a := &VerifyAttempt{
+		RequestID: resp.RequestID,
+		flow:      f,
+		req:       req,
+		checked:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go a.run(ctx)
+	return a, nil
+}
+
+// Check checks code, and ends the verification if it is correct.
+func (a *VerifyAttempt) Check(code string) (*VerifyCheckResponse, error) {
+	resp, err := a.flow.Verify.Check(&VerifyCheckRequest{RequestID: a.RequestID, Code: code})
+	if err == nil && resp.Status == ResponseSuccess {
+		a.once.Do(func() { close(a.checked) })
+	}
+	return resp, err
+}
+
+// Await waits for the verification to end and returns its outcome. If ctx is
+// done first, its error is returned.
+func (a *VerifyAttempt) Await(ctx context.Context) (VerifyOutcome, error) {
+	select {
+	case <-a.done:
+		return a.outcome, nil
+	case <-ctx.Done():
+		return VerifyOutcome{}, ctx.Err()
+	}
+}
+
+// run escalates the verification until it ends, and records its outcome.
+func (a *VerifyAttempt) run(ctx context.Context) {
+	defer close(a.done)
+	f := a.flow
+	clock := clockOrSystem(f.Clock)
+	out := &a.outcome
+	out.RequestID = a.RequestID
+
+	for {
+		select {
+		case <-a.checked:
+			out.Status = VerifySuccess
+			return
+		case <-ctx.Done():
+			out.Err = ctx.Err()
+			return
+		case <-clock.After(f.window()):
+		}
+
+		if out.NextEvents < f.nextEvents() && a.triggerNextEvent() == nil {
+			out.NextEvents++
+			continue
+		}
+		if f.Fallback != nil && !out.FellBack {
+			out.FellBack = true
+			if out.Err = f.Fallback(ctx, a.req, a.RequestID); out.Err != nil {
+				return
+			}
+			continue
+		}
+
+		// Nothing is left to try; the code may still have been checked in
+		// the meantime.
+		select {
+		case <-a.checked:
+			out.Status = VerifySuccess
+			return
+		default:
+		}
+		resp, err := f.Verify.Search(&VerifySearchRequest{RequestID: a.RequestID})
+		if err != nil {
+			out.Err = err
+			return
+		}
+		out.Status = resp.Status
+		return
+	}
+}
+
+// triggerNextEvent moves the verification to the next event of its workflow.
+func (a *VerifyAttempt) triggerNextEvent() error {
+	resp, err := a.flow.Verify.Control(&VerifyControlRequest{RequestID: a.RequestID, Command: "trigger_next_event"})
+	if err != nil {
+		return err
+	}
+	if resp.Status != ResponseSuccess {
+		return fmt.Errorf("triggering next event of %s: %v: %s", a.RequestID, resp.Status, resp.ErrorText)
+	}
+	return nil
+}
+
+func (f *VerifyFlow) window() time.Duration {
+	if f.Window <= 0 {
+		return 2 * time.Minute
+	}
+	return f.Window
+}
+
+func (f *VerifyFlow) nextEvents() int {
+	if f.NextEvents == 0 {
+		return 1
+	}
+	return f.NextEvents
+}
@@ -0,0 +1,75 @@
+package nexmo
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerifyFlow(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var calls []string
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls = append(calls, req.URL.Path)
+		mu.Unlock()
+		body := map[string]string{
+			"/verify/json":         `{"status":"0","request_id":"abcdef"}`,
+			"/verify/check/json":   `{"status":"0","event_id":"1"}`,
+			"/verify/control/json": `{"status":"0","command":"trigger_next_event"}`,
+			"/verify/search/json":  `{"request_id":"abcdef","status":"EXPIRED"}`,
+		}[req.URL.Path]
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	var fellBack string
+	flow := &VerifyFlow{
+		Verify: client.Verify(),
+		Window: 10 * time.Millisecond,
+		Fallback: func(ctx context.Context, req *VerifyMessageRequest, requestID string) error {
+			fellBack = req.Number + " " + requestID
+			return nil
+		},
+	}
+	req := &VerifyMessageRequest{Number: "447700900000", Brand: "gonexmo"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a, err := flow.Start(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := a.Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != VerifyExpired || out.NextEvents != 1 || !out.FellBack || out.Err != nil {
+		t.Errorf("got outcome %+v", out)
+	}
+	if fellBack != "447700900000 abcdef" {
+		t.Errorf("fallback called with %q", fellBack)
+	}
+	want := []string{"/verify/json", "/verify/control/json", "/verify/search/json"}
+	if strings.Join(calls, " ") != strings.Join(want, " ") {
+		t.Errorf("got calls %q, want %q", calls, want)
+	}
+
+	flow.Window = time.Hour
+	if a, err = flow.Start(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Check("1234"); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := a.Await(ctx); err != nil || out.Status != VerifySuccess || out.NextEvents != 0 {
+		t.Errorf("got outcome %+v, %v", out, err)
+	}
+}
@@ -1,58 +1,49 @@
 package nexmo
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
-func testSend(t *testing.T) *VerifyMessageResponse {
-	time.Sleep(1 * time.Second) // Sleep 1 second due to API limitation
-	if testPhoneNumber == "" {
-		t.Fatal("no test phone number specified. Please set NEXMO_NUM")
-	}
-	client, err := NewClient(testAPIKey, testAPISecret)
-	if err != nil {
-		t.Error("failed to create Client with error:", err)
-	}
-
+func testSend(t *testing.T, client *Client) *VerifyMessageResponse {
 	message := &VerifyMessageRequest{
-		Number:   testPhoneNumber,
+		Number:   liveRecipient(t),
 		Brand:    testFrom,
 		SenderID: testFrom,
 	}
 
-	messageResponse, err := client.Verify.Send(message)
+	messageResponse, err := client.Verify().Send(message)
 	if err != nil {
-		t.Error("failed to send verification request with error:", err)
+		t.Fatal("failed to send verification request with error:", err)
 	}
 
 	return messageResponse
 }
 
 func TestSend(t *testing.T) {
-	messageResponse := testSend(t)
+	messageResponse := testSend(t, liveClient(t))
 	t.Logf("Sent Verification SMS, response was: %#v\n", messageResponse)
 }
 
 func TestSendCheck(t *testing.T) {
-	// We need the request ID, so we have to run this first.
-	sendResponse := testSend(t)
+	client := liveClient(t)
 
-	time.Sleep(1 * time.Second) // Sleep 1 second due to API limitation
-	if testPhoneNumber == "" {
-		t.Fatal("No test phone number specified. Please set NEXMO_NUM")
-	}
-	client, err := NewClient(testAPIKey, testAPISecret)
-	if err != nil {
-		t.Error("Failed to create Client with error:", err)
-	}
+	// We need the request ID, so we have to run this first.
+	sendResponse := testSend(t, client)
 
 	message := &VerifyCheckRequest{
 		RequestID: sendResponse.RequestID,
 		Code:      "1122", // Take a random code here, the number will not be verified properly though.
 	}
 
-	messageResponse, err := client.Verify.Check(message)
+	messageResponse, err := client.Verify().Check(message)
 	if err != nil {
 		t.Error("Failed to send verification check request with error:", err)
 	}
@@ -61,26 +52,98 @@ func TestSendCheck(t *testing.T) {
 }
 
 func TestSendSearch(t *testing.T) {
-	// We need the request id, so we have to run this first.
-	sendResponse := testSend(t)
+	client := liveClient(t)
 
-	time.Sleep(1 * time.Second) // Sleep 1 second due to API limitation
-	if testPhoneNumber == "" {
-		t.Fatal("No test phone number specified. Please set NEXMO_NUM")
-	}
-	client, err := NewClient(testAPIKey, testAPISecret)
-	if err != nil {
-		t.Error("Failed to create Client with error:", err)
-	}
+	// We need the request id, so we have to run this first.
+	sendResponse := testSend(t, client)
 
 	message := &VerifySearchRequest{
 		RequestID: sendResponse.RequestID,
 	}
 
-	messageResponse, err := client.Verify.Search(message)
+	messageResponse, err := client.Verify().Search(message)
 	if err != nil {
 		t.Error("Failed to send verification search request with error:", err)
 	}
 
 	t.Logf("Sent Verification SMS, response was: %#v\n", messageResponse)
 }
+
+func TestVerifyStatusJSON(t *testing.T) {
+	for body, want := range map[string]VerifyStatus{
+		`{"status":"IN PROGRESS"}`: VerifyInProgress,
+		`{"status":"101"}`:         "101",
+		`{"status":101}`:           "101",
+	} {
+		var resp VerifySearchResponse
+		if err := json.Unmarshal([]byte(body), &resp); err != nil {
+			t.Errorf("%s: %v", body, err)
+		} else if resp.Status != want {
+			t.Errorf("%s: got %q, want %q", body, resp.Status, want)
+		}
+	}
+}
+
+func TestVerifySendBatch(t *testing.T) {
+	client, err := NewClient("k3y", "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var m map[string]string
+		json.NewDecoder(req.Body).Decode(&m)
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"status":"0","request_id":"req-` + m["number"] + `"}`)),
+		}, nil
+	})}
+
+	var requests []*VerifyMessageRequest
+	for i := 0; i < 10; i++ {
+		requests = append(requests, &VerifyMessageRequest{Number: fmt.Sprint("4477009000", i), Brand: "gonexmo"})
+	}
+	// Invalid requests fail on their own.
+	requests = append(requests, &VerifyMessageRequest{Brand: "gonexmo"})
+
+	results := client.Verify().SendBatch(context.Background(), requests, 3)
+	if len(results) != len(requests) {
+		t.Fatalf("got %d results", len(results))
+	}
+	for i, res := range results[:10] {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		if res.Response.RequestID != "req-"+requests[i].Number {
+			t.Errorf("result %d has request ID %q", i, res.Response.RequestID)
+		}
+	}
+	if results[10].Err != ErrMissingNumber {
+		t.Errorf("got error %v for the invalid request", results[10].Err)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("%d requests were in flight", maxInFlight)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, res := range client.Verify().SendBatch(ctx, requests[:2], 1) {
+		if res.Err != context.Canceled {
+			t.Errorf("got error %v after the context was canceled", res.Err)
+		}
+	}
+}
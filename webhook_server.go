@@ -0,0 +1,169 @@
+package nexmo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Default paths the WebhookServer mounts its handlers on.
+const (
+	DefaultMessagePath    = "/webhooks/inbound"
+	DefaultDeliveryPath   = "/webhooks/delivery-receipt"
+	DefaultStatusPath     = "/webhooks/status"
+	DefaultVerifyPath     = "/webhooks/verify"
+	DefaultVoiceEventPath = "/webhooks/voice/event"
+	DefaultHealthPath     = "/healthz"
+)
+
+// DefaultEventBuffer is the capacity of the channels created by
+// NewWebhookServer.
+const DefaultEventBuffer = 100
+
+// WebhookServer is a ready to use HTTP server receiving Nexmo callbacks and
+// passing them on to its channels. Handlers are only mounted for the paths
+// which are not empty.
+//
+// Create one with NewWebhookServer, adjust the fields as needed and call
+// ListenAndServe. The fields must not be modified after that.
+type WebhookServer struct {
+	// TCP address to listen on, e.g. ":8080".
+	Addr string
+
+	// Paths the handlers are mounted on.
+	MessagePath    string
+	DeliveryPath   string
+	StatusPath     string
+	VerifyPath     string
+	VoiceEventPath string
+	HealthPath     string
+
+	// Checks run by the health handler, see NewHealthHandler.
+	HealthChecks []func() error
+
+	// Only accept callbacks from trusted Nexmo IPs.
+	VerifyIPs bool
+
+	// If both are set, the server is started with TLS.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Options applied to all handlers.
+	Options []HandlerOption
+
+	// Decoded callbacks are passed out on these.
+	Messages     chan *ReceivedMessage
+	Receipts     chan *DeliveryReceipt
+	Statuses     chan *MessageStatus
+	VerifyEvents chan *VerifyEvent
+	VoiceEvents  chan *VoiceEvent
+
+	handlerOnce sync.Once
+	handler     http.Handler
+	stop        context.CancelFunc // Stops the handlers.
+
+	mu       sync.Mutex
+	server   *http.Server
+	shutdown bool // Set by Shutdown.
+}
+
+// NewWebhookServer creates a WebhookServer listening on addr, with all
+// handlers mounted on their default paths and channels buffering up to
+// DefaultEventBuffer callbacks each.
+func NewWebhookServer(addr string) *WebhookServer {
+	return &WebhookServer{
+		Addr:           addr,
+		MessagePath:    DefaultMessagePath,
+		DeliveryPath:   DefaultDeliveryPath,
+		StatusPath:     DefaultStatusPath,
+		VerifyPath:     DefaultVerifyPath,
+		VoiceEventPath: DefaultVoiceEventPath,
+		HealthPath:     DefaultHealthPath,
+		Messages:       make(chan *ReceivedMessage, DefaultEventBuffer),
+		Receipts:       make(chan *DeliveryReceipt, DefaultEventBuffer),
+		Statuses:       make(chan *MessageStatus, DefaultEventBuffer),
+		VerifyEvents:   make(chan *VerifyEvent, DefaultEventBuffer),
+		VoiceEvents:    make(chan *VoiceEvent, DefaultEventBuffer),
+	}
+}
+
+// Handler returns an http.Handler with all configured handlers mounted. It can
+// be used to serve the callbacks from an existing server instead of calling
+// ListenAndServe. Every call returns the same handler, which is stopped by
+// Shutdown.
+func (s *WebhookServer) Handler() http.Handler {
+	s.handlerOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		opts := append([]HandlerOption(nil), s.Options...)
+		s.handler = s.newHandler(append(opts, WithHandlerContext(ctx)))
+		s.stop = cancel
+	})
+	return s.handler
+}
+
+func (s *WebhookServer) newHandler(opts []HandlerOption) http.Handler {
+	mux := http.NewServeMux()
+
+	if s.MessagePath != "" {
+		mux.Handle(s.MessagePath,
+			NewMessageHandler(s.Messages, s.VerifyIPs, opts...))
+	}
+	if s.DeliveryPath != "" {
+		mux.Handle(s.DeliveryPath,
+			NewDeliveryHandler(s.Receipts, s.VerifyIPs, opts...))
+	}
+	if s.StatusPath != "" {
+		mux.Handle(s.StatusPath,
+			NewMessageStatusHandler(s.Statuses, s.VerifyIPs, opts...))
+	}
+	if s.VerifyPath != "" {
+		mux.Handle(s.VerifyPath,
+			NewVerifyEventHandler(s.VerifyEvents, s.VerifyIPs, opts...))
+	}
+	if s.VoiceEventPath != "" {
+		mux.Handle(s.VoiceEventPath,
+			NewVoiceEventHandler(s.VoiceEvents, s.VerifyIPs, opts...))
+	}
+	if s.HealthPath != "" {
+		mux.Handle(s.HealthPath, NewHealthHandler(s.HealthChecks...))
+	}
+	return mux
+}
+
+// ListenAndServe starts the server and blocks until it is shut down. Like
+// http.Server.ListenAndServe it always returns a non-nil error, which is
+// http.ErrServerClosed after Shutdown has been called, even if it was called
+// before ListenAndServe.
+func (s *WebhookServer) ListenAndServe() error {
+	s.mu.Lock()
+	if s.shutdown {
+		s.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	s.server = &http.Server{Addr: s.Addr, Handler: s.Handler()}
+	srv := s.server
+	s.mu.Unlock()
+
+	if s.TLSCertFile != "" && s.TLSKeyFile != "" {
+		return srv.ListenAndServeTLS(s.TLSCertFile, s.TLSKeyFile)
+	}
+	return srv.ListenAndServe()
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight callbacks to be
+// handled until ctx is done, then stops the handlers. Callbacks still buffered
+// with WithBuffer are dropped.
+func (s *WebhookServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.server
+	s.shutdown = true
+	s.mu.Unlock()
+
+	var err error
+	if srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+	s.Handler() // Sets s.stop, so a handler created later is stopped too.
+	s.stop()
+	return err
+}
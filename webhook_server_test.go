@@ -0,0 +1,102 @@
+package nexmo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookServerHandler(t *testing.T) {
+	s := NewWebhookServer(":0")
+	s.VerifyPath = ""
+	h := s.Handler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newFormRequest("GET", testInboundValues))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unmounted path: got status %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	req := newFormRequest("POST", testInboundValues)
+	req.URL.Path = DefaultMessagePath
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = newFormRequest("GET", testReceiptValues)
+	req.URL.Path = DefaultDeliveryPath
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("POST", DefaultVoiceEventPath, strings.NewReader(
+		`{"uuid":"aaaa","conversation_uuid":"CON-bbbb","from":"447700900001",`+
+			`"to":"447700900000","status":"answered","direction":"inbound",`+
+			`"timestamp":"2018-08-06T12:00:00.000Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(s.Messages) != 1 || len(s.Receipts) != 1 || len(s.VoiceEvents) != 1 {
+		t.Fatalf("got %d messages, %d receipts and %d voice events, want one of each",
+			len(s.Messages), len(s.Receipts), len(s.VoiceEvents))
+	}
+	if e := <-s.VoiceEvents; e.Status != "answered" || e.ConversationUUID != "CON-bbbb" {
+		t.Errorf("unexpected voice event %#v", e)
+	}
+}
+
+func TestWebhookServerShutdown(t *testing.T) {
+	s := NewWebhookServer("127.0.0.1:0")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ListenAndServe()
+	}()
+
+	// Wait for the server to be started.
+	for i := 0; i < 100; i++ {
+		s.mu.Lock()
+		started := s.server != nil
+		s.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal("failed to shut down:", err)
+	}
+
+	if err := <-done; err != http.ErrServerClosed {
+		t.Errorf("ListenAndServe returned %v, want %v", err, http.ErrServerClosed)
+	}
+
+	if err := s.ListenAndServe(); err != http.ErrServerClosed {
+		t.Errorf("ListenAndServe after Shutdown returned %v, want %v", err, http.ErrServerClosed)
+	}
+
+	// The handler is built once and stopped along with the server.
+	h := s.Handler()
+	if h != s.Handler() {
+		t.Error("Handler returned a new handler")
+	}
+	req := newFormRequest("POST", testInboundValues)
+	req.URL.Path = DefaultMessagePath
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("callback after Shutdown: got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestWebhookServerShutdownFirst(t *testing.T) {
+	s := NewWebhookServer("127.0.0.1:0")
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ListenAndServe(); err != http.ErrServerClosed {
+		t.Errorf("ListenAndServe returned %v, want %v", err, http.ErrServerClosed)
+	}
+}